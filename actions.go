@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// inActions reports whether pr-view runs in a GitHub Actions job. There it
+// defaults to the job's repo and API, turns warnings and errors into
+// annotations and adds the listing to the job summary.
+func inActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// actionsDefaults points the client at the job's GitHub, for workflows on
+// GitHub Enterprise Server. The token is GITHUB_TOKEN already.
+func actionsDefaults(cfg *Config) {
+	if inActions() {
+		cfg.GitHub.APIURL = firstNonEmpty(cfg.GitHub.APIURL, os.Getenv("GITHUB_API_URL"))
+	}
+}
+
+// actionsRepo is the repo the workflow runs in, listed when nothing is
+// tracked: a job starts without pr-view's local files.
+func actionsRepo() string {
+	if !inActions() {
+		return ""
+	}
+	return os.Getenv("GITHUB_REPOSITORY")
+}
+
+// escapeCommand escapes data for a workflow command; properties such as
+// title also need : and , escaped.
+func escapeCommand(s string, property bool) string {
+	r := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	if property {
+		r = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	}
+	return r.Replace(s)
+}
+
+// annotate prints a workflow command like ::warning title=T::message. The
+// runner reads them from stderr too, which keeps stdout for the output.
+func annotate(w io.Writer, level, title, msg string) {
+	props := ""
+	if title != "" {
+		props = " title=" + escapeCommand(title, true)
+	}
+	fmt.Fprintf(w, "::%s%s::%s\n", level, props, escapeCommand(msg, false))
+}
+
+// actionsHandler logs warnings and errors as annotations, so they show on
+// the run's page, and everything else through next.
+type actionsHandler struct {
+	next  slog.Handler
+	w     io.Writer
+	attrs []slog.Attr
+}
+
+func (h *actionsHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *actionsHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn {
+		return h.next.Handle(ctx, r)
+	}
+	msg := []string{r.Message}
+	add := func(a slog.Attr) bool {
+		msg = append(msg, a.Key+"="+redact(a.Value.String()))
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+	level := "warning"
+	if r.Level >= slog.LevelError {
+		level = "error"
+	}
+	annotate(h.w, level, "", strings.Join(msg, " "))
+	return nil
+}
+
+func (h *actionsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &actionsHandler{next: h.next.WithAttrs(attrs), w: h.w, attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h *actionsHandler) WithGroup(name string) slog.Handler {
+	return &actionsHandler{next: h.next.WithGroup(name), w: h.w, attrs: h.attrs}
+}
+
+// actionsReport adds the listing to the job summary as a Markdown table
+// and annotates PRs past their SLA, when the timeline was fetched to tell.
+func actionsReport(cfg *Config, results []PRResult, opts *listOptions) {
+	sla := slaConfigured(cfg) && opts.needs[enrichTimeline]
+	now := time.Now()
+	md := strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`, "\n", " ")
+	var b strings.Builder
+	var failed []string
+	n, bots := 0, 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed = append(failed, res.Repo)
+			continue
+		}
+		bots += len(res.Bots)
+		repo, _, _ := strings.Cut(res.Repo, "#")
+		for _, pr := range res.PRs {
+			n++
+			review := strings.ToLower(strings.ReplaceAll(pr.ReviewDecision, "_", " "))
+			if review == "" && len(pr.RequestedReviewers) > 0 {
+				var who []string
+				for _, u := range pr.RequestedReviewers {
+					who = append(who, "@"+u.Login)
+				}
+				review = "waiting on " + strings.Join(who, ", ")
+			}
+			fmt.Fprintf(&b, "| [%s#%d](%s) | %s | @%s | %s | %s |\n", repo, pr.Number, pr.HTMLURL,
+				md.Replace(pr.Title), pr.User.Login, fmtTime(cfg, pr.UpdatedAt), firstNonEmpty(md.Replace(review), "-"))
+			if !sla {
+				continue
+			}
+			if breaches := slaBreaches(slaFor(cfg, repo), pr, now); len(breaches) > 0 {
+				annotate(os.Stderr, "warning", "Review SLA", fmt.Sprintf("%s#%d %s: %s %s", repo, pr.Number, pr.Title, strings.Join(breaches, ", "), pr.HTMLURL))
+			}
+		}
+	}
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "### %d open %s\n\n", n, plural(n, opts.noun()))
+	if n > 0 {
+		out.WriteString("| PR | Title | Author | Updated | Review |\n| --- | --- | --- | --- | --- |\n")
+		out.WriteString(b.String())
+	}
+	if bots > 0 {
+		fmt.Fprintf(&out, "\n_Not shown: %d %s by bots, --show-bots lists them._\n", bots, plural(bots, opts.noun()))
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&out, "\n_Couldn't fetch: %s_\n", strings.Join(failed, ", "))
+	}
+	out.WriteString("\n")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		slog.Warn("writing job summary", "err", err)
+		return
+	}
+	defer f.Close()
+	if _, err := io.WriteString(f, out.String()); err != nil {
+		slog.Warn("writing job summary", "err", err)
+	}
+}
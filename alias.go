@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runAlias runs the alias name with args appended to its expansion. Like
+// git, an expansion starting with ! is a shell command.
+func runAlias(cfg *Config, hc *http.Client, name, expansion string, args []string, loadErr error) int {
+	if shell, ok := strings.CutPrefix(expansion, "!"); ok {
+		// "$@" passes the extra args through unchanged; $0 names the alias
+		cmd := exec.Command("sh", "-c", shell+` "$@"`, name)
+		cmd.Args = append(cmd.Args, args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			var exit *exec.ExitError
+			if errors.As(err, &exit) {
+				return exit.ExitCode()
+			}
+			slog.Error("running alias", "alias", name, "err", err)
+			return 1
+		}
+		return 0
+	}
+	words, err := splitWords(expansion)
+	if err != nil || len(words) == 0 {
+		fmt.Printf("alias %s: invalid expansion %q\n", name, expansion)
+		return 2
+	}
+	slog.Debug("expanding alias", "alias", name, "expansion", expansion)
+	return dispatch(cfg, hc, words[0], append(words[1:], args...), loadErr, true)
+}
+
+// splitWords splits s on spaces like a shell would, honoring single and
+// double quotes and backslash escapes, without any expansion.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
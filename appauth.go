@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// appTokenSource authenticates as a GitHub App: it signs a JWT with the
+// app's private key and exchanges it for installation tokens, one per
+// account the app is installed on, minted again shortly before they expire.
+type appTokenSource struct {
+	cfg    AppConfig
+	apiURL string
+
+	mu  sync.Mutex
+	key *rsa.PrivateKey
+	// installations maps lowercased account logins to installation IDs,
+	// listed on first use.
+	installations map[string]int64
+	first         int64
+	tokens        map[int64]appToken
+	slug          string
+}
+
+type appToken struct {
+	value   string
+	expires time.Time
+}
+
+func newAppTokenSource(cfg AppConfig, apiURL string) *appTokenSource {
+	return &appTokenSource{cfg: cfg, apiURL: firstNonEmpty(strings.TrimRight(apiURL, "/"), githubAPI), tokens: map[int64]appToken{}}
+}
+
+// Token returns a token for the default installation: installation_id, or
+// the first one listed.
+func (s *appTokenSource) Token() (string, error) {
+	return s.tokenFor("")
+}
+
+// tokenFor returns a token for the installation on owner, falling back to
+// the default installation when owner is empty or the app isn't installed
+// there.
+func (s *appTokenSource) tokenFor(owner string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, err := s.installation(owner)
+	if err != nil {
+		return "", err
+	}
+	// installation tokens last an hour; don't hand out one about to expire
+	if t, ok := s.tokens[id]; ok && time.Until(t.expires) > 5*time.Minute {
+		return t.value, nil
+	}
+	var resp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := s.do("POST", fmt.Sprintf("/app/installations/%d/access_tokens", id), nil, &resp); err != nil {
+		return "", fmt.Errorf("minting installation token: %w", err)
+	}
+	registerSecret(resp.Token)
+	s.tokens[id] = appToken{value: resp.Token, expires: resp.ExpiresAt}
+	return resp.Token, nil
+}
+
+func (s *appTokenSource) installation(owner string) (int64, error) {
+	if owner == "" && s.cfg.InstallationID != 0 {
+		return s.cfg.InstallationID, nil
+	}
+	if s.installations == nil {
+		var list []struct {
+			ID      int64 `json:"id"`
+			Account struct {
+				Login string `json:"login"`
+			} `json:"account"`
+		}
+		if err := s.do("GET", "/app/installations?per_page=100", nil, &list); err != nil {
+			return 0, fmt.Errorf("listing app installations: %w", err)
+		}
+		s.installations = map[string]int64{}
+		for _, in := range list {
+			s.installations[strings.ToLower(in.Account.Login)] = in.ID
+		}
+		if len(list) > 0 {
+			s.first = list[0].ID
+		}
+	}
+	if id, ok := s.installations[strings.ToLower(owner)]; ok {
+		return id, nil
+	}
+	if s.cfg.InstallationID != 0 {
+		return s.cfg.InstallationID, nil
+	}
+	if s.first == 0 {
+		return 0, fmt.Errorf("the GitHub App isn't installed anywhere")
+	}
+	return s.first, nil
+}
+
+// login is the app's bot account, e.g. pr-view[bot], which is who "you"
+// are when authenticated as the app.
+func (s *appTokenSource) login() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.slug == "" {
+		var app struct {
+			Slug string `json:"slug"`
+		}
+		if err := s.do("GET", "/app", nil, &app); err != nil {
+			return "", err
+		}
+		s.slug = app.Slug
+	}
+	return s.slug + "[bot]", nil
+}
+
+// forget drops the installations listed so far, after the app was installed
+// on another account or uninstalled from one. Tokens already minted stay
+// until they expire.
+func (s *appTokenSource) forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.installations, s.first = nil, 0
+}
+
+// setWebhook points the app's webhook at url, signed with secret, so its
+// deliveries for every installation reach pr-view.
+func (s *appTokenSource) setWebhook(url, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in := map[string]any{"url": url, "content_type": "json", "secret": secret, "insecure_ssl": "0"}
+	return s.do("PATCH", "/app/hook/config", in, &struct{}{})
+}
+
+// jwt signs the short-lived token that authenticates as the app itself.
+func (s *appTokenSource) jwt() (string, error) {
+	if s.key == nil {
+		key, err := loadAppKey(s.cfg)
+		if err != nil {
+			return "", err
+		}
+		s.key = key
+	}
+	appID := firstNonEmpty(s.cfg.AppID, os.Getenv("GITHUB_APP_ID"))
+	if appID == "" {
+		return "", fmt.Errorf("auth.app.app_id (or GITHUB_APP_ID) must be set for the app token source")
+	}
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		// a minute back allows for clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(nil, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// loadAppKey reads the app's PEM private key from private_key_file, or
+// from GITHUB_APP_PRIVATE_KEY, which holds the key itself for CI secrets.
+func loadAppKey(cfg AppConfig) (*rsa.PrivateKey, error) {
+	var data []byte
+	if cfg.PrivateKeyFile != "" {
+		var err error
+		if data, err = os.ReadFile(cfg.PrivateKeyFile); err != nil {
+			return nil, err
+		}
+	} else if k := os.Getenv("GITHUB_APP_PRIVATE_KEY"); k != "" {
+		data = []byte(k)
+	} else {
+		return nil, fmt.Errorf("auth.app.private_key_file (or GITHUB_APP_PRIVATE_KEY) must be set for the app token source")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("app private key isn't PEM encoded")
+	}
+	// GitHub hands out PKCS#1 keys, converted ones are often PKCS#8
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("app private key isn't an RSA key")
+	}
+	return key, nil
+}
+
+// do calls an app endpoint with the JWT. Like the Vault source it goes
+// through baseTransport: the JWT changes on every call, so caching the
+// response would only fill the cache.
+func (s *appTokenSource) do(method, path string, in, out any) error {
+	jwt, err := s.jwt()
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, s.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "pr-view")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	client := &http.Client{Timeout: 15 * time.Second, Transport: baseTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// requestOwner is the account a request is about, which picks the app
+// installation whose token sends it. It is empty for requests that don't
+// name a repo or org, like GraphQL mutations by node ID.
+func requestOwner(path string, in any) string {
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		path = u.Path
+	}
+	path = strings.TrimPrefix(path, "/api/v3")
+	for _, prefix := range []string{"/repos/", "/orgs/", "/users/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			owner, _, _ := strings.Cut(rest, "/")
+			return owner
+		}
+	}
+	body, _ := in.(map[string]any)
+	vars, _ := body["variables"].(map[string]any)
+	for _, k := range []string{"owner", "o0"} {
+		if o, ok := vars[k].(string); ok {
+			return o
+		}
+	}
+	return ""
+}
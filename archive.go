@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cmdArchive hides PRs from `list` locally, leaving GitHub notifications
+// and subscriptions alone.
+func cmdArchive(cfg *Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("usage: pr-view archive owner/repo#number|<PR_URL>...")
+		return 2
+	}
+	keys, ok := prKeys(args)
+	if !ok {
+		return 2
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	err = states.Update(func(st *State) error {
+		if st.Archived == nil {
+			st.Archived = map[string]time.Time{}
+		}
+		for _, k := range keys {
+			if _, ok := st.Archived[k]; !ok {
+				st.Archived[k] = time.Now().UTC()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("saving state", "err", err)
+		return 1
+	}
+	fmt.Printf("archived %d PRs, see them with: pr-view archived\n", len(keys))
+	return 0
+}
+
+// cmdArchived lists archived PRs, newest first, or restores them to the
+// active list with `archived restore`.
+func cmdArchived(cfg *Config, args []string) int {
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	if len(args) > 0 && args[0] == "restore" {
+		keys, ok := prKeys(args[1:])
+		if !ok || len(keys) == 0 {
+			fmt.Println("usage: pr-view archived restore owner/repo#number|<PR_URL>...")
+			return 2
+		}
+		restored := 0
+		err := states.Update(func(st *State) error {
+			for _, k := range keys {
+				if _, ok := st.Archived[k]; ok {
+					delete(st.Archived, k)
+					restored++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("saving state", "err", err)
+			return 1
+		}
+		fmt.Printf("restored %d PRs\n", restored)
+		return 0
+	}
+	if len(args) > 0 {
+		fmt.Println("usage: pr-view archived [restore owner/repo#number...]")
+		return 2
+	}
+	st, err := states.Load()
+	if err != nil {
+		slog.Error("loading state", "err", err)
+		return 1
+	}
+	keys := make([]string, 0, len(st.Archived))
+	for k := range st.Archived {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int { return st.Archived[b].Compare(st.Archived[a]) })
+	if len(keys) == 0 {
+		fmt.Println("no archived PRs")
+		return 0
+	}
+	for _, k := range keys {
+		fmt.Printf("%-40s archived %s\n", k, fmtTime(cfg, st.Archived[k]))
+	}
+	return 0
+}
+
+// prKeys normalizes PR arguments to state keys, printing an error for the
+// first one that isn't a PR.
+func prKeys(args []string) ([]string, bool) {
+	var keys []string
+	for _, arg := range args {
+		entry, err := normalizeEntry(arg)
+		if err != nil || !strings.Contains(entry, "#") {
+			fmt.Println("expected a PR: owner/repo#number or a PR URL:", arg)
+			return nil, false
+		}
+		repo, num, _ := strings.Cut(entry, "#")
+		n, _ := strconv.Atoi(num)
+		keys = append(keys, prKey(repo, n))
+	}
+	return keys, true
+}
+
+// dropArchived removes archived PRs from the results. Results left empty
+// by it show as having no open PRs, like any other repo.
+func dropArchived(results []PRResult, archived map[string]time.Time) {
+	if len(archived) == 0 {
+		return
+	}
+	for i := range results {
+		res := &results[i]
+		res.PRs = slices.DeleteFunc(res.PRs, func(pr PullRequest) bool {
+			_, ok := archived[prKey(res.Repo, pr.Number)]
+			return ok
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TokenSource produces the GitHub token used for API requests.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// envTokenSource reads the token from an environment variable. It is the
+// default source and an unset variable means unauthenticated requests.
+type envTokenSource struct {
+	name string
+}
+
+func (s envTokenSource) Token() (string, error) {
+	return os.Getenv(s.name), nil
+}
+
+// opTokenSource reads the token with the 1Password CLI, so it never has to
+// live in the environment or in plaintext config.
+type opTokenSource struct {
+	ref    string
+	binary string
+}
+
+func (s opTokenSource) Token() (string, error) {
+	cmd := exec.Command(s.binary, "read", "--no-newline", s.ref)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("op read %s: %v: %s", s.ref, err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("op read %s returned an empty token", s.ref)
+	}
+	return token, nil
+}
+
+// vaultTokenSource reads the token from a Vault KV secret, authenticating
+// with VAULT_TOKEN (or ~/.vault-token) or an AppRole.
+type vaultTokenSource struct {
+	cfg VaultConfig
+}
+
+func (s vaultTokenSource) Token() (string, error) {
+	c := s.cfg
+	addr := strings.TrimRight(firstNonEmpty(c.Addr, os.Getenv("VAULT_ADDR")), "/")
+	if addr == "" {
+		return "", fmt.Errorf("vault address not set (auth.vault.addr or VAULT_ADDR)")
+	}
+	if c.Path == "" {
+		return "", fmt.Errorf("auth.vault.path must be set for the vault token source")
+	}
+	vaultToken, err := s.login(addr)
+	if err != nil {
+		return "", err
+	}
+	registerSecret(vaultToken)
+	mount := firstNonEmpty(c.Mount, "secret")
+	field := firstNonEmpty(c.Field, "token")
+	secretURL := fmt.Sprintf("%s/v1/%s/data/%s", addr, mount, strings.Trim(c.Path, "/"))
+	if c.KVVersion == 1 {
+		secretURL = fmt.Sprintf("%s/v1/%s/%s", addr, mount, strings.Trim(c.Path, "/"))
+	}
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := s.do("GET", secretURL, vaultToken, nil, &secret); err != nil {
+		return "", err
+	}
+	data := secret.Data
+	if c.KVVersion != 1 {
+		// KV v2 nests the secret under data.data
+		data, _ = secret.Data["data"].(map[string]any)
+	}
+	token, _ := data[field].(string)
+	if token == "" {
+		return "", fmt.Errorf("vault secret %s has no %q field", c.Path, field)
+	}
+	return token, nil
+}
+
+// login returns a Vault client token, exchanging the AppRole credentials
+// when role_id is configured.
+func (s vaultTokenSource) login(addr string) (string, error) {
+	c := s.cfg
+	roleID := firstNonEmpty(c.RoleID, os.Getenv("VAULT_ROLE_ID"))
+	if roleID == "" {
+		if t := os.Getenv("VAULT_TOKEN"); t != "" {
+			return t, nil
+		}
+		home, err := os.UserHomeDir()
+		if err == nil {
+			if b, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				return strings.TrimSpace(string(b)), nil
+			}
+		}
+		return "", fmt.Errorf("no vault credentials: set VAULT_TOKEN or configure an approle")
+	}
+	secretID := firstNonEmpty(c.SecretID, os.Getenv("VAULT_SECRET_ID"))
+	registerSecret(secretID)
+	mount := firstNonEmpty(c.AppRoleMount, "approle")
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": roleID, "secret_id": secretID}
+	if err := s.do("POST", fmt.Sprintf("%s/v1/auth/%s/login", addr, mount), "", body, &login); err != nil {
+		return "", fmt.Errorf("vault approle login: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault approle login returned no token")
+	}
+	return login.Auth.ClientToken, nil
+}
+
+// do talks to Vault through baseTransport rather than the shared API
+// client so secrets never land in the response cache.
+func (s vaultTokenSource) do(method, url, vaultToken string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if vaultToken != "" {
+		req.Header.Set("X-Vault-Token", vaultToken)
+	}
+	if ns := firstNonEmpty(s.cfg.Namespace, os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	client := &http.Client{Timeout: 15 * time.Second, Transport: baseTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("vault error: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func newTokenSource(cfg AuthConfig, apiURL string) (TokenSource, error) {
+	switch cfg.TokenSource {
+	case "", "env":
+		name := cfg.EnvVar
+		if name == "" {
+			name = "GITHUB_TOKEN"
+		}
+		return envTokenSource{name: name}, nil
+	case "op":
+		if cfg.OpRef == "" {
+			return nil, fmt.Errorf("auth.op_ref must be set for the op token source (e.g. op://vault/item/token)")
+		}
+		binary := cfg.OpBinary
+		if binary == "" {
+			binary = "op"
+		}
+		return opTokenSource{ref: cfg.OpRef, binary: binary}, nil
+	case "vault":
+		return vaultTokenSource{cfg: cfg.Vault}, nil
+	case "app":
+		return newAppTokenSource(cfg.App, apiURL), nil
+	default:
+		return nil, fmt.Errorf("unknown auth.token_source %q (expected env, op, vault or app)", cfg.TokenSource)
+	}
+}
+
+// githubToken returns the token for API requests from the configured source
+// and registers it for redaction.
+func githubToken(cfg *Config) (string, error) {
+	token, _, err := githubTokenSource(cfg)
+	return token, err
+}
+
+// githubTokenSource is githubToken that also returns the source, which the
+// client keeps when it mints tokens as it goes.
+func githubTokenSource(cfg *Config) (string, TokenSource, error) {
+	src, err := newTokenSource(cfg.Auth, cfg.GitHub.APIURL)
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := src.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	registerSecret(token)
+	return token, src, nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// batchSize caps the number of aliased repositories per GraphQL query to
+// stay well inside GitHub's query complexity limits.
+const batchSize = 20
+
+// prFragment selects the PullRequest fields for batched listings. Keep it in
+// sync with gqlPullRequest.toPullRequest.
+const prFragment = `fragment prFields on PullRequest {
+	id
+	number
+	title
+	body
+	url
+	state
+	createdAt
+	updatedAt
+	author { login __typename }
+	labels(first: 20) { nodes { name } }
+	milestone { title }
+	headRefName
+	headRefOid
+	baseRefName
+	baseRefOid
+	headRepository { nameWithOwner }
+	baseRepository { nameWithOwner }
+	isDraft
+	autoMergeRequest { enabledBy { login __typename } mergeMethod }
+	reviewRequests(first: 20) { nodes { requestedReviewer { __typename ... on User { login } ... on Team { slug } } } }
+}`
+
+type gqlPullRequest struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Author    *struct {
+		Login    string `json:"login"`
+		Typename string `json:"__typename"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+	Milestone      *Milestone `json:"milestone"`
+	HeadRefName    string     `json:"headRefName"`
+	HeadRefOid     string     `json:"headRefOid"`
+	BaseRefName    string     `json:"baseRefName"`
+	BaseRefOid     string     `json:"baseRefOid"`
+	HeadRepository *struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"headRepository"`
+	BaseRepository *struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"baseRepository"`
+	IsDraft          bool `json:"isDraft"`
+	AutoMergeRequest *struct {
+		EnabledBy *struct {
+			Login    string `json:"login"`
+			Typename string `json:"__typename"`
+		} `json:"enabledBy"`
+		MergeMethod string `json:"mergeMethod"`
+	} `json:"autoMergeRequest"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer *struct {
+				Typename string `json:"__typename"`
+				Login    string `json:"login"`
+				Slug     string `json:"slug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+}
+
+func (g gqlPullRequest) toPullRequest() PullRequest {
+	pr := PullRequest{
+		NodeID:    g.ID,
+		Number:    g.Number,
+		Title:     g.Title,
+		Body:      g.Body,
+		HTMLURL:   g.URL,
+		State:     strings.ToLower(g.State),
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+		Labels:    g.Labels.Nodes,
+		Milestone: g.Milestone,
+		Head:      Ref{Ref: g.HeadRefName, SHA: g.HeadRefOid},
+		Base:      Ref{Ref: g.BaseRefName, SHA: g.BaseRefOid},
+		Draft:     g.IsDraft,
+	}
+	if g.Author != nil { // nil for deleted ("ghost") users
+		pr.User = User{Login: g.Author.Login, Type: g.Author.Typename}
+	}
+	if r := g.HeadRepository; r != nil {
+		pr.Head.Repo = &RefRepo{FullName: r.NameWithOwner}
+	}
+	if r := g.BaseRepository; r != nil {
+		pr.Base.Repo = &RefRepo{FullName: r.NameWithOwner}
+	}
+	if am := g.AutoMergeRequest; am != nil {
+		pr.AutoMerge = &AutoMerge{MergeMethod: strings.ToLower(am.MergeMethod)}
+		if am.EnabledBy != nil {
+			pr.AutoMerge.EnabledBy = User{Login: am.EnabledBy.Login, Type: am.EnabledBy.Typename}
+		}
+	}
+	for _, n := range g.ReviewRequests.Nodes {
+		switch r := n.RequestedReviewer; {
+		case r == nil: // mannequins and deleted users
+		case r.Typename == "Team":
+			pr.RequestedTeams = append(pr.RequestedTeams, Team{Slug: r.Slug})
+		case r.Login != "":
+			pr.RequestedReviewers = append(pr.RequestedReviewers, User{Login: r.Login, Type: r.Typename})
+		}
+	}
+	return pr
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// graphqlErrors is returned when a GraphQL response carries errors.
+type graphqlErrors []graphqlError
+
+func (e graphqlErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ge := range e {
+		msgs[i] = ge.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+// graphql runs query with vars and decodes the data field into out.
+func (c *GitHubClient) graphql(query string, vars map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors graphqlErrors   `json:"errors"`
+	}
+	if err := c.do("POST", c.graphqlURL(), map[string]any{"query": query, "variables": vars}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// canBatch reports whether an entry fetched with q can be served by the
+// GraphQL batch. GraphQL needs a token, only orders PRs by creation or
+// update time, and returns at most 100 nodes without paginating.
+func canBatch(c *GitHubClient, repo string, q prQuery) bool {
+	return c.token != "" && !strings.Contains(repo, "#") && !q.Issues &&
+		(q.Sort == "created" || q.Sort == "updated") && q.Limit <= 100
+}
+
+// fetchBatch lists the open PRs of several repos of one owner in a single
+// GraphQL query, returning the PRs and error for each repo in order.
+func fetchBatch(c *GitHubClient, repos []string, queries []prQuery) ([][]PullRequest, []error) {
+	prs := make([][]PullRequest, len(repos))
+	errs := make([]error, len(repos))
+	var params, fields []string
+	vars := map[string]any{}
+	for i, repo := range repos {
+		owner, name, _ := strings.Cut(repo, "/")
+		q := queries[i]
+		first := q.Limit
+		if first <= 0 {
+			first = 30 // the REST default
+		}
+		field := "UPDATED_AT"
+		if q.Sort == "created" {
+			field = "CREATED_AT"
+		}
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!, $f%d: Int!", i, i, i))
+		fields = append(fields, fmt.Sprintf(
+			"r%d: repository(owner: $o%d, name: $n%d) { pullRequests(states: OPEN, first: $f%d, orderBy: {field: %s, direction: %s}) { nodes { ...prFields } } }",
+			i, i, i, i, field, strings.ToUpper(q.Direction)))
+		vars[fmt.Sprintf("o%d", i)] = owner
+		vars[fmt.Sprintf("n%d", i)] = name
+		vars[fmt.Sprintf("f%d", i)] = first
+	}
+	query := fmt.Sprintf("query(%s) {\n%s\n}\n%s", strings.Join(params, ", "), strings.Join(fields, "\n"), prFragment)
+
+	var resp struct {
+		Data map[string]*struct {
+			PullRequests struct {
+				Nodes []gqlPullRequest `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"data"`
+		Errors graphqlErrors `json:"errors"`
+	}
+	if err := c.do("POST", c.graphqlURL(), map[string]any{"query": query, "variables": vars}, &resp); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return prs, errs
+	}
+	// errors are attributed to the repo alias at the start of their path;
+	// anything else fails the whole batch
+	for _, ge := range resp.Errors {
+		i := -1
+		if len(ge.Path) > 0 {
+			if alias, ok := ge.Path[0].(string); ok {
+				fmt.Sscanf(alias, "r%d", &i)
+			}
+		}
+		if i < 0 || i >= len(repos) {
+			for j := range errs {
+				errs[j] = graphqlErrors{ge}
+			}
+			return prs, errs
+		}
+		errs[i] = graphqlRepoError(ge)
+		if ae, ok := errs[i].(*apiError); ok && ae.StatusCode == http.StatusForbidden && strings.Contains(ge.Message, "SAML") {
+			// GraphQL names no org, but the batch is all one owner's
+			ae.SSOOrg, _, _ = strings.Cut(repos[i], "/")
+		}
+	}
+	for i := range repos {
+		if errs[i] != nil {
+			continue
+		}
+		repo := resp.Data[fmt.Sprintf("r%d", i)]
+		if repo == nil {
+			errs[i] = &apiError{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: "repository not found"}
+			continue
+		}
+		for _, n := range repo.PullRequests.Nodes {
+			prs[i] = append(prs[i], n.toPullRequest())
+		}
+	}
+	return prs, errs
+}
+
+// graphqlRepoError maps the GraphQL error types that correspond to
+// persistent REST failures onto apiError, so the circuit breaker treats both
+// transports alike.
+func graphqlRepoError(ge graphqlError) error {
+	switch ge.Type {
+	case "NOT_FOUND":
+		return &apiError{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: ge.Message}
+	case "FORBIDDEN":
+		return &apiError{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Body: ge.Message}
+	case "RATE_LIMITED":
+		return &apiError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", Body: ge.Message}
+	}
+	return graphqlErrors{ge}
+}
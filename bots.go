@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// dependencyBots are the logins (without the [bot] suffix) of apps that open
+// dependency update PRs.
+var dependencyBots = []string{
+	"dependabot",
+	"dependabot-preview",
+	"renovate",
+	"renovate-bot",
+	"depfu",
+	"pyup-bot",
+	"snyk-bot",
+}
+
+// botLogin returns the login without the "[bot]" suffix REST adds for apps.
+func botLogin(u User) string {
+	return strings.TrimSuffix(strings.ToLower(u.Login), "[bot]")
+}
+
+func isBot(u User) bool {
+	return u.Type == "Bot" || strings.HasSuffix(strings.ToLower(u.Login), "[bot]")
+}
+
+// isDependencyPR reports whether pr is a bot-authored dependency update:
+// opened by a known dependency bot, or by any bot with a "dependencies"
+// label.
+func isDependencyPR(pr PullRequest) bool {
+	if slices.Contains(dependencyBots, botLogin(pr.User)) {
+		return true
+	}
+	if !isBot(pr.User) {
+		return false
+	}
+	for _, l := range pr.Labels {
+		if strings.EqualFold(l.Name, "dependencies") {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseBots moves dependency PRs out of each result's PRs into Bots, so
+// they render as one summary row per repo.
+func collapseBots(results []PRResult) {
+	for i := range results {
+		if results[i].Pinned {
+			continue
+		}
+		var keep []PullRequest
+		for _, pr := range results[i].PRs {
+			if isDependencyPR(pr) {
+				results[i].Bots = append(results[i].Bots, pr)
+			} else {
+				keep = append(keep, pr)
+			}
+		}
+		results[i].PRs = keep
+	}
+}
+
+// botSummary describes collapsed dependency PRs, e.g.
+// "(3 dependency PRs by dependabot, renovate; --show-bots to expand)".
+func botSummary(prs []PullRequest) string {
+	seen := map[string]bool{}
+	var authors []string
+	for _, pr := range prs {
+		if l := botLogin(pr.User); !seen[l] {
+			seen[l] = true
+			authors = append(authors, l)
+		}
+	}
+	sort.Strings(authors)
+	noun := "PRs"
+	if len(prs) == 1 {
+		noun = "PR"
+	}
+	return fmt.Sprintf("(%d dependency %s by %s; --show-bots to expand)", len(prs), noun, strings.Join(authors, ", "))
+}
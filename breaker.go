@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Minute
+)
+
+// breakerStatuses are API failures that won't fix themselves between two
+// runs, as opposed to network errors and 5xx responses.
+var breakerStatuses = []int{
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusGone,
+	http.StatusTooManyRequests,
+}
+
+// BreakerState tracks consecutive persistent failures for one tracked entry.
+// While OpenUntil is in the future the entry is skipped.
+type BreakerState struct {
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error,omitempty"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+// breakerOpenError is reported for entries skipped by an open breaker.
+type breakerOpenError struct {
+	state BreakerState
+}
+
+func (e *breakerOpenError) Error() string {
+	return fmt.Sprintf("skipped until %s after %d failures: %s",
+		e.state.OpenUntil.Local().Format("15:04"), e.state.Failures, e.state.LastError)
+}
+
+type breaker struct {
+	cfg BreakerConfig
+	now time.Time
+}
+
+func newBreaker(cfg BreakerConfig) breaker {
+	return breaker{cfg: cfg, now: time.Now()}
+}
+
+func (b breaker) threshold() int {
+	if b.cfg.Threshold > 0 {
+		return b.cfg.Threshold
+	}
+	return defaultBreakerThreshold
+}
+
+func (b breaker) cooldown() time.Duration {
+	if b.cfg.Cooldown > 0 {
+		return time.Duration(b.cfg.Cooldown)
+	}
+	return defaultBreakerCooldown
+}
+
+// check returns a breakerOpenError if repo should be skipped this run.
+func (b breaker) check(st *State, repo string) error {
+	if b.cfg.Disabled {
+		return nil
+	}
+	if s, ok := st.Breakers[repo]; ok && b.now.Before(s.OpenUntil) {
+		return &breakerOpenError{state: *s}
+	}
+	return nil
+}
+
+// record updates the breaker for repo with the outcome of a fetch. Success
+// closes it; a persistent failure counts towards tripping it, and once
+// tripped every further failure (the half-open retry after the cool-down)
+// re-opens it straight away.
+func (b breaker) record(st *State, repo string, err error) {
+	if err == nil {
+		delete(st.Breakers, repo)
+		return
+	}
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || !slices.Contains(breakerStatuses, apiErr.StatusCode) {
+		return
+	}
+	if st.Breakers == nil {
+		st.Breakers = map[string]*BreakerState{}
+	}
+	s, ok := st.Breakers[repo]
+	if !ok {
+		s = &BreakerState{}
+		st.Breakers[repo] = s
+	}
+	s.Failures++
+	s.LastError = apiErr.Status
+	if s.Failures >= b.threshold() {
+		s.OpenUntil = b.now.Add(b.cooldown())
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// bulkAction is what bulk does to each matching PR.
+type bulkAction struct {
+	desc string
+	run  func(c *GitHubClient, repo string, pr PullRequest) error
+}
+
+var bulkActionNames = []string{"add-label:NAME", "remove-label:NAME", "comment:TEXT", "request-review:LOGIN[,LOGIN...]", "close"}
+
+// parseBulkAction parses --action, e.g. add-label:needs-rebase.
+func parseBulkAction(s string) (bulkAction, error) {
+	name, arg, _ := strings.Cut(s, ":")
+	if arg == "" && name != "close" {
+		return bulkAction{}, fmt.Errorf("invalid --action %q, expected one of %s", s, strings.Join(bulkActionNames, ", "))
+	}
+	switch name {
+	case "add-label":
+		return bulkAction{"add label " + arg + " to", func(c *GitHubClient, repo string, pr PullRequest) error {
+			return addLabel(c, repo, pr.Number, arg)
+		}}, nil
+	case "remove-label":
+		return bulkAction{"remove label " + arg + " from", func(c *GitHubClient, repo string, pr PullRequest) error {
+			return removeLabel(c, repo, pr.Number, arg)
+		}}, nil
+	case "comment":
+		return bulkAction{fmt.Sprintf("comment %q on", arg), func(c *GitHubClient, repo string, pr PullRequest) error {
+			return commentOnPR(c, repo, pr.Number, arg)
+		}}, nil
+	case "request-review":
+		logins := strings.Split(arg, ",")
+		return bulkAction{"request review from " + strings.Join(logins, ", ") + " on", func(c *GitHubClient, repo string, pr PullRequest) error {
+			return requestReviewers(c, repo, pr.Number, logins)
+		}}, nil
+	case "close":
+		return bulkAction{"close", func(c *GitHubClient, repo string, pr PullRequest) error {
+			return c.do("PATCH", fmt.Sprintf("/repos/%s/pulls/%d", repo, pr.Number), map[string]string{"state": "closed"}, nil)
+		}}, nil
+	}
+	return bulkAction{}, fmt.Errorf("invalid --action %q, expected one of %s", s, strings.Join(bulkActionNames, ", "))
+}
+
+// expandFilters replaces each --filter a,b in args with the list flags --a
+// --b, for the filters a view can use.
+func expandFilters(args []string) ([]string, error) {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return append(out, args[i:]...), nil
+		}
+		value, ok := strings.CutPrefix(strings.TrimPrefix(a, "-"), "-filter")
+		if !ok || (value != "" && value[0] != '=') {
+			out = append(out, a)
+			continue
+		}
+		if value != "" {
+			value = value[1:]
+		} else if i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if value == "" {
+			return nil, errors.New("--filter needs filter names")
+		}
+		for _, f := range strings.Split(value, ",") {
+			if f = strings.TrimSpace(f); !slices.Contains(viewFilters, f) {
+				return nil, fmt.Errorf("unknown filter %q (expected %s)", f, strings.Join(viewFilters, ", "))
+			}
+			out = append(out, "--"+f)
+		}
+	}
+	return out, nil
+}
+
+// confirm asks a yes/no question on stdout and reads the answer from in;
+// anything but y or yes is no.
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+const bulkUsage = "usage: pr-view bulk --action add-label:NAME|remove-label:NAME|comment:TEXT|request-review:LOGIN,...|close [--filter ready,needs-rebase,...] [list filters]"
+
+// cmdBulk applies an action to every PR the filters match across the
+// tracked repos. It always previews the PRs and asks before changing
+// anything.
+func cmdBulk(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("bulk", flag.ContinueOnError)
+	actionFlag := fs.String("action", "", "what to do to each PR: "+strings.Join(bulkActionNames, ", "))
+	// expanded before parsing, the flag is only defined for the usage text
+	fs.String("filter", "", "comma-separated filters as in views: "+strings.Join(viewFilters, ", "))
+	args, err := expandFilters(args)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil || fs.NArg() > 0 || *actionFlag == "" {
+		fmt.Println(bulkUsage)
+		return 2
+	}
+	action, err := parseBulkAction(*actionFlag)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("bulk works on PRs, --issues doesn't apply")
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	results, err := collectPRs(cfg, gh, opts)
+	if errors.Is(err, errNoRepos) {
+		fmt.Println(err)
+		return 0
+	}
+	if err != nil {
+		slog.Error("listing PRs", "err", err)
+		return 1
+	}
+
+	type target struct {
+		repo string
+		pr   PullRequest
+	}
+	var targets []target
+	code := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("listing", "repo", res.Repo, "err", redact(res.Err))
+			code = 1
+			continue
+		}
+		for _, pr := range append(res.PRs, res.Bots...) {
+			if strings.ToLower(pr.State) == "open" {
+				targets = append(targets, target{repoName(res.Repo), pr})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("no PRs match")
+		return code
+	}
+	for _, t := range targets {
+		fmt.Printf("  %s#%d  %s\n", t.repo, t.pr.Number, truncate(t.pr.Title, 60))
+	}
+	if !confirm(os.Stdin, fmt.Sprintf("%s these %d %s?", action.desc, len(targets), plural(len(targets), "PR"))) {
+		fmt.Println("nothing changed")
+		return code
+	}
+	done := 0
+	for _, t := range targets {
+		ref := fmt.Sprintf("%s#%d", t.repo, t.pr.Number)
+		if err := action.run(gh, t.repo, t.pr); err != nil {
+			slog.Error("bulk action failed", "pr", ref, "err", err)
+			code = 1
+			continue
+		}
+		done++
+	}
+	fmt.Printf("done: %d of %d %s\n", done, len(targets), plural(len(targets), "PR"))
+	return code
+}
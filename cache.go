@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachedHeaders are the response headers worth replaying from the cache;
+// everything else (rate limit, dates) comes from the fresh 304 response.
+var cachedHeaders = []string{"Content-Type", "Link"}
+
+type cacheEntry struct {
+	ETag   string            `json:"etag"`
+	Header map[string]string `json:"header"`
+	Body   []byte            `json:"body"`
+	Stored time.Time         `json:"stored"`
+	// Checked is when GitHub last confirmed the entry, by a 200 or a 304.
+	Checked time.Time `json:"checked,omitzero"`
+}
+
+// cacheTransport keeps GitHub API responses on disk and revalidates them
+// with If-None-Match. A 304 answer does not count against the rate limit and
+// skips the download, while results stay as fresh as an uncached request.
+type cacheTransport struct {
+	next     http.RoundTripper
+	dir      string
+	security SecurityConfig
+	// fresh is how long a checked entry is served without revalidating,
+	// set while a daemon keeps the cache warm.
+	fresh time.Duration
+}
+
+func cacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "pr-view", "http")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// cacheKey depends on the credentials as well as the URL so one token never
+// sees responses fetched with another.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	h.Write([]byte{0})
+	io.WriteString(h, req.Header.Get("Accept"))
+	h.Write([]byte{0})
+	io.WriteString(h, req.Header.Get("Authorization"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	ctx, s := startSpan(req.Context(), "cache lookup", "url.full", req.URL.String())
+	path := filepath.Join(t.dir, cacheKey(req)+".json")
+	entry, ok := t.load(path)
+	s.set("cache.stored", ok)
+	if ok && t.fresh > 0 && time.Since(entry.Checked) < t.fresh && !mustRevalidate(ctx) {
+		s.set("cache.hit", true)
+		s.set("cache.warm", true)
+		s.finish(nil)
+		return entry.response(req), nil
+	}
+	req = req.Clone(ctx)
+	if ok {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		s.finish(err)
+		return nil, err
+	}
+	defer func() { s.set("cache.hit", resp.Header.Get("X-From-Cache") != ""); s.finish(nil) }()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		resp.Body.Close()
+		if t.fresh > 0 || mustRevalidate(ctx) {
+			// only worth a write when a warm cache reads it
+			entry.Checked = time.Now()
+			t.store(path, entry)
+		}
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		for k, v := range entry.Header {
+			resp.Header.Set(k, v)
+		}
+		resp.Header.Set("X-From-Cache", "1")
+		resp.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		resp.ContentLength = int64(len(entry.Body))
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		now := time.Now()
+		e := cacheEntry{ETag: resp.Header.Get("ETag"), Header: map[string]string{}, Body: body, Stored: now, Checked: now}
+		for _, k := range cachedHeaders {
+			if v := resp.Header.Get(k); v != "" {
+				e.Header[k] = v
+			}
+		}
+		// a failed cache write only costs us the next revalidation
+		t.store(path, e)
+	}
+	return resp, nil
+}
+
+// response replays the entry as a 200 without asking GitHub.
+func (e cacheEntry) response(req *http.Request) *http.Response {
+	h := http.Header{}
+	for k, v := range e.Header {
+		h.Set(k, v)
+	}
+	h.Set("X-From-Cache", "warm")
+	return &http.Response{
+		Status: "200 OK", StatusCode: http.StatusOK, Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1,
+		Header: h, Body: io.NopCloser(bytes.NewReader(e.Body)), ContentLength: int64(len(e.Body)), Request: req,
+	}
+}
+
+func (t *cacheTransport) load(path string) (cacheEntry, bool) {
+	data, err := readLocalFile(t.security, path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.ETag == "" {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (t *cacheTransport) store(path string, e cacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return writeLocalFile(t.security, path, data)
+}
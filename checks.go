@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CI states returned by ciState.
+const (
+	ciSuccess = "success"
+	ciFailure = "failure"
+	ciPending = "pending"
+	ciNone    = "none"
+	// ciMissing is a required check that hasn't reported on the head yet.
+	ciMissing = "missing"
+)
+
+type checkRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	CheckSuite struct {
+		ID int64 `json:"id"`
+	} `json:"check_suite"`
+	App struct {
+		Slug string `json:"slug"`
+	} `json:"app"`
+}
+
+type commitStatus struct {
+	Context   string `json:"context"`
+	State     string `json:"state"`
+	TargetURL string `json:"target_url"`
+}
+
+// fetchChecks returns the check runs and the latest commit status per
+// context for sha.
+func fetchChecks(c *GitHubClient, repo, sha string) ([]checkRun, []commitStatus, error) {
+	var runs struct {
+		CheckRuns []checkRun `json:"check_runs"`
+	}
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/commits/%s/check-runs?per_page=100", repo, sha), nil, &runs); err != nil {
+		return nil, nil, err
+	}
+	var combined struct {
+		Statuses []commitStatus `json:"statuses"`
+	}
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/commits/%s/status", repo, sha), nil, &combined); err != nil {
+		return nil, nil, err
+	}
+	return runs.CheckRuns, combined.Statuses, nil
+}
+
+// checkRunState maps a check run onto the ciState vocabulary.
+func checkRunState(r checkRun) string {
+	if r.Status != "completed" {
+		return ciPending
+	}
+	switch r.Conclusion {
+	case "success", "neutral", "skipped":
+		return ciSuccess
+	}
+	return ciFailure
+}
+
+// statusState maps a commit status onto the ciState vocabulary.
+func statusState(s commitStatus) string {
+	switch s.State {
+	case "success":
+		return ciSuccess
+	case "pending":
+		return ciPending
+	}
+	return ciFailure
+}
+
+// ciState aggregates both check runs and commit statuses of sha: failure if
+// anything failed, pending if anything is still running, success if
+// everything passed, and none if no CI reported at all.
+func ciState(c *GitHubClient, repo, sha string) (string, error) {
+	runs, statuses, err := fetchChecks(c, repo, sha)
+	if err != nil {
+		return "", err
+	}
+	var states []string
+	for _, r := range runs {
+		states = append(states, checkRunState(r))
+	}
+	for _, s := range statuses {
+		states = append(states, statusState(s))
+	}
+	return aggregateCIState(states), nil
+}
+
+func aggregateCIState(states []string) string {
+	if len(states) == 0 {
+		return ciNone
+	}
+	state := ciSuccess
+	for _, s := range states {
+		switch s {
+		case ciFailure:
+			return ciFailure
+		case ciPending:
+			state = ciPending
+		}
+	}
+	return state
+}
+
+// requiredCheck is one status check the base branch requires, with its
+// state on the PR head.
+type requiredCheck struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+var protectedContextsCache onceCache[[]string]
+
+// requiredContexts returns the status check names branch protection requires
+// on branch, or nil when the branch isn't protected. It reads the branch
+// endpoint, which unlike the protection endpoint doesn't need admin access.
+func requiredContexts(c *GitHubClient, repo, branch string) ([]string, error) {
+	return protectedContextsCache.get(repo+"@"+branch, func() ([]string, error) {
+		var b struct {
+			Protection struct {
+				RequiredStatusChecks struct {
+					Contexts []string `json:"contexts"`
+					Checks   []struct {
+						Context string `json:"context"`
+					} `json:"checks"`
+				} `json:"required_status_checks"`
+			} `json:"protection"`
+		}
+		if err := c.do("GET", fmt.Sprintf("/repos/%s/branches/%s", repo, url.PathEscape(branch)), nil, &b); err != nil {
+			return nil, err
+		}
+		rsc := b.Protection.RequiredStatusChecks
+		contexts := rsc.Contexts
+		for _, ch := range rsc.Checks {
+			if !containsFold(contexts, ch.Context) {
+				contexts = append(contexts, ch.Context)
+			}
+		}
+		return contexts, nil
+	})
+}
+
+// enrichRequiredChecks sets the state of each check required on the base
+// branch, matching check runs by name and statuses by context.
+var enrichRequiredChecks = &enricher{name: "required checks", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	contexts, err := requiredContexts(c, repo, pr.Base.Ref)
+	if err != nil {
+		return err
+	}
+	pr.RequiredChecks = nil
+	if len(contexts) == 0 {
+		return nil
+	}
+	runs, statuses, err := fetchChecks(c, repo, pr.Head.SHA)
+	if err != nil {
+		return err
+	}
+	// reruns leave several runs with the same name; the newest one counts
+	latest := map[string]checkRun{}
+	for _, r := range runs {
+		if prev, ok := latest[r.Name]; !ok || r.ID > prev.ID {
+			latest[r.Name] = r
+		}
+	}
+	for _, name := range contexts {
+		state := ciMissing
+		if r, ok := latest[name]; ok {
+			state = checkRunState(r)
+		}
+		for _, s := range statuses {
+			if s.Context == name {
+				state = statusState(s)
+			}
+		}
+		pr.RequiredChecks = append(pr.RequiredChecks, requiredCheck{Name: name, State: state})
+	}
+	return nil
+}}
+
+// requiredChecksSummary describes what's blocking the required checks, e.g.
+// "failing: test; missing: lint", or "ok" when they all passed.
+func requiredChecksSummary(checks []requiredCheck) string {
+	if len(checks) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, state := range []string{ciFailure, ciMissing, ciPending} {
+		var names []string
+		for _, ch := range checks {
+			if ch.State == state {
+				names = append(names, ch.Name)
+			}
+		}
+		if len(names) > 0 {
+			label := map[string]string{ciFailure: "failing", ciMissing: "missing", ciPending: "pending"}[state]
+			parts = append(parts, label+": "+strings.Join(names, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "ok"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// repoName strips the #number suffix of a tracked entry, leaving owner/repo.
+func repoName(entry string) string {
+	name, _, _ := strings.Cut(entry, "#")
+	return name
+}
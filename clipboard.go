@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands are the clipboard tools tried in order, per OS.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+	cmds := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append([][]string{{"wl-copy"}}, cmds...)
+	}
+	return cmds
+}
+
+// errNoClipboard means no clipboard tool was found and the terminal can't
+// be reached either.
+var errNoClipboard = errors.New("no clipboard tool found (pbcopy, clip, wl-copy, xclip or xsel)")
+
+// copyToClipboard puts text on the system clipboard with the first
+// clipboard tool found. Without one, e.g. over SSH, it falls back to the
+// OSC 52 escape, which many terminals turn into a clipboard write; via
+// reports which way it went.
+func copyToClipboard(text string) (via string, err error) {
+	for _, c := range clipboardCommands() {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%s: %w: %s", c[0], err, strings.TrimSpace(string(out)))
+		}
+		return c[0], nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return "", errNoClipboard
+	}
+	defer tty.Close()
+	if _, err := fmt.Fprintf(tty, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text))); err != nil {
+		return "", err
+	}
+	return "the terminal (OSC 52)", nil
+}
+
+const copyUsage = "usage: pr-view copy owner/repo#number [--markdown]"
+
+// cmdCopy puts a PR's URL, or a Markdown link to it, on the clipboard.
+func cmdCopy(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	markdown := fs.Bool("markdown", false, "copy a Markdown link with the PR title instead of the bare URL")
+	repo, number, err := parsePRCommand(fs, args)
+	if err != nil {
+		fmt.Println(copyUsage)
+		return 2
+	}
+	entry := fmt.Sprintf("%s#%d", repo, number)
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	prs, err := fetchPRs(gh, entry, prQuery{})
+	if err != nil {
+		slog.Error("fetching PR", "err", err)
+		return 1
+	}
+	text := prs[0].HTMLURL
+	if *markdown {
+		// brackets would end the link text early
+		title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(prs[0].Title)
+		text = fmt.Sprintf("[%s: %s](%s)", entry, title, prs[0].HTMLURL)
+	}
+	via, err := copyToClipboard(text)
+	if err != nil {
+		slog.Error("copying to clipboard", "err", err)
+		return 1
+	}
+	fmt.Printf("copied %s to the clipboard via %s\n", text, via)
+	return 0
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// codeownersPaths are the locations GitHub looks for CODEOWNERS, in order.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeowners is a parsed CODEOWNERS file. The last matching rule wins, as on
+// GitHub.
+type codeowners []codeownersRule
+
+func parseCodeowners(data string) codeowners {
+	var rules codeowners
+	for _, line := range strings.Split(data, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 && (i == 0 || line[i-1] != '\\') {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, codeownersRule{pattern: codeownersPattern(fields[0]), owners: fields[1:]})
+	}
+	return rules
+}
+
+// codeownersPattern compiles a gitignore-style CODEOWNERS pattern. A match on
+// a directory covers everything below it.
+func codeownersPattern(p string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.TrimPrefix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("(/.*)?$")
+	return regexp.MustCompile(b.String())
+}
+
+// owners returns the owners of path, or nil if no rule matches or the
+// matching rule removes ownership.
+func (co codeowners) owners(path string) []string {
+	for i := len(co) - 1; i >= 0; i-- {
+		if co[i].pattern.MatchString(path) {
+			return co[i].owners
+		}
+	}
+	return nil
+}
+
+var codeownersCache onceCache[codeowners]
+
+// repoCodeowners fetches and parses the CODEOWNERS file of repo at ref, once
+// per process. A repo without one has no rules.
+func repoCodeowners(c *GitHubClient, repo, ref string) (codeowners, error) {
+	return codeownersCache.get(repo+"@"+ref, func() (codeowners, error) {
+		for _, p := range codeownersPaths {
+			var file struct {
+				Content  string `json:"content"`
+				Encoding string `json:"encoding"`
+			}
+			err := c.do("GET", fmt.Sprintf("/repos/%s/contents/%s?ref=%s", repo, p, url.QueryEscape(ref)), nil, &file)
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", p, err)
+			}
+			data := []byte(file.Content)
+			if file.Encoding == "base64" {
+				if data, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", "")); err != nil {
+					return nil, fmt.Errorf("decoding %s: %w", p, err)
+				}
+			}
+			return parseCodeowners(string(data)), nil
+		}
+		return nil, nil
+	})
+}
+
+// enrichOwners sets the code owners required by the PR's changed files,
+// read from CODEOWNERS on the base branch.
+var enrichOwners = &enricher{name: "code owners", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	rules, err := repoCodeowners(c, repo, pr.Base.Ref)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	pr.Owners = nil
+	for _, f := range pr.Files {
+		for _, o := range rules.owners(f) {
+			if key := strings.ToLower(o); !seen[key] {
+				seen[key] = true
+				pr.Owners = append(pr.Owners, o)
+			}
+		}
+	}
+	return nil
+}}
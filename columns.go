@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiRed     = "\x1b[31m"
+	ansiBoldRed = "\x1b[1;31m"
+)
+
+// colorEnabled reports whether output goes to a terminal and NO_COLOR
+// (https://no-color.org) isn't set.
+var colorEnabled = sync.OnceValue(func() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+})
+
+// paint wraps s in an ANSI color when color output is enabled.
+func paint(color, s string) string {
+	if !colorEnabled() || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// visibleLen is the on-screen width of s, ignoring color escapes.
+func visibleLen(s string) int {
+	return len([]rune(ansiEscape.ReplaceAllString(s, "")))
+}
+
+// column is one column of the list table. needs lists the enrichers that
+// provide the fields value reads. Values longer than maxWidth are truncated
+// in the table, but not with --plain.
+type column struct {
+	name     string
+	header   string
+	needs    []*enricher
+	maxWidth int
+	value    func(cfg *Config, res PRResult, pr PullRequest) string
+}
+
+var defaultColumns = []string{"repo", "url", "title"}
+
+// columnRegistry holds every column `--columns` accepts, in help order.
+var columnRegistry = []column{
+	{name: "row", header: "#", value: func(cfg *Config, res PRResult, pr PullRequest) string { return strconv.Itoa(pr.Row) }},
+	{name: "repo", header: "REPO", value: func(cfg *Config, res PRResult, pr PullRequest) string { return res.Repo }},
+	{name: "number", header: "#", value: func(cfg *Config, res PRResult, pr PullRequest) string { return strconv.Itoa(pr.Number) }},
+	{name: "url", header: "URL", value: func(cfg *Config, res PRResult, pr PullRequest) string { return pr.HTMLURL }},
+	{name: "title", header: "TITLE", maxWidth: 60, value: func(cfg *Config, res PRResult, pr PullRequest) string {
+		prefix := ""
+		if pr.Unread {
+			prefix += "* "
+		}
+		if res.Pinned {
+			prefix += "[pinned] "
+		}
+		return prefix + pr.Title
+	}},
+	{name: "author", header: "AUTHOR", value: func(cfg *Config, res PRResult, pr PullRequest) string { return pr.User.Login }},
+	{name: "created", header: "CREATED",
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return fmtTime(cfg, pr.CreatedAt) }},
+	{name: "updated", header: "UPDATED",
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return fmtTime(cfg, pr.UpdatedAt) }},
+	{name: "merge", header: "MERGE", needs: []*enricher{enrichDetail},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return mergeState(pr) }},
+	{name: "dco", header: "DCO", needs: []*enricher{enrichCommits},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return dcoLabel(pr.Commits) }},
+	{name: "signed", header: "SIGNED", needs: []*enricher{enrichCommits},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return signaturesLabel(pr.Commits) }},
+	{name: "fork", header: "FORK",
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return firstNonEmpty(pr.headFork(), "-") }},
+	{name: "auto-merge", header: "AUTO-MERGE",
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return autoMergeLabel(pr) }},
+	{name: "queue", header: "QUEUE", needs: []*enricher{enrichMergeQueue},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return queueLabel(pr.MergeQueue) }},
+	{name: "owners", header: "OWNERS", needs: []*enricher{enrichFiles, enrichOwners},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string {
+			return firstNonEmpty(strings.Join(pr.Owners, ", "), "-")
+		}},
+	{name: "behind", header: "BEHIND", needs: []*enricher{enrichBehind},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return strconv.Itoa(pr.BehindBy) }},
+	{name: "project", header: "PROJECT", needs: []*enricher{enrichProjects},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return projectsSummary(pr.Projects) }},
+	{name: "size", header: "SIZE", needs: []*enricher{enrichDetail},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return sizeLabel(pr) }},
+	{name: "in-review", header: "IN REVIEW", needs: []*enricher{enrichTimeline},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return reviewTimeLabel(cfg.List, pr) }},
+	{name: "template", header: "TEMPLATE", needs: []*enricher{enrichTemplateCheck},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return templateLabel(pr.TemplateIssues) }},
+	{name: "title-check", header: "TITLE?",
+		value: func(cfg *Config, res PRResult, pr PullRequest) string {
+			return titleLabel(cfg, repoName(res.Repo), pr.Title)
+		}},
+	{name: "sla", header: "SLA", needs: []*enricher{enrichTimeline},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return slaLabel(cfg, repoName(res.Repo), pr) }},
+	{name: "deploy", header: "DEPLOYMENTS", needs: []*enricher{enrichDeployments},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return deploymentsSummary(pr.Deployments) }},
+	{name: "notified", header: "NOTIFIED", needs: []*enricher{enrichNotifications},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string { return notifyReasonLabel(pr.NotifyReason) }},
+	{name: "checks", header: "REQUIRED CHECKS", needs: []*enricher{enrichRequiredChecks},
+		value: func(cfg *Config, res PRResult, pr PullRequest) string {
+			return requiredChecksSummary(pr.RequiredChecks)
+		}},
+}
+
+func columnNames() []string {
+	var names []string
+	for _, c := range columnRegistry {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// parseColumns resolves a comma-separated list of column names.
+func parseColumns(s string) ([]column, error) {
+	var cols []column
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, c := range columnRegistry {
+			if c.name == name {
+				cols = append(cols, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(columnNames(), ", "))
+		}
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("--columns needs at least one column")
+	}
+	return cols, nil
+}
+
+func firstNonEmptySlice(vals ...[]string) []string {
+	for _, v := range vals {
+		if len(v) > 0 {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// prCommit is one commit of a PR with what the compliance checks look at.
+type prCommit struct {
+	SHA         string `json:"sha"`
+	Message     string `json:"message"`
+	AuthorEmail string `json:"author_email"`
+	Merge       bool   `json:"merge,omitempty"`
+	// Verified is whether GitHub verified the commit's GPG, SSH or S/MIME
+	// signature; Reason says why not, e.g. "unsigned" or "unknown_key".
+	Verified bool   `json:"verified"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// enrichCommits sets the commits of the PR. GitHub lists at most 250.
+var enrichCommits = &enricher{name: "commits", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	pr.Commits = nil
+	next := fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=100", repo, pr.Number)
+	for next != "" {
+		var page []struct {
+			SHA    string `json:"sha"`
+			Commit struct {
+				Message string `json:"message"`
+				Author  struct {
+					Email string `json:"email"`
+				} `json:"author"`
+				Verification struct {
+					Verified bool   `json:"verified"`
+					Reason   string `json:"reason"`
+				} `json:"verification"`
+			} `json:"commit"`
+			Parents []struct{} `json:"parents"`
+		}
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			return err
+		}
+		for _, cm := range page {
+			pr.Commits = append(pr.Commits, prCommit{
+				SHA:         cm.SHA,
+				Message:     cm.Commit.Message,
+				AuthorEmail: cm.Commit.Author.Email,
+				Merge:       len(cm.Parents) > 1,
+				Verified:    cm.Commit.Verification.Verified,
+				Reason:      cm.Commit.Verification.Reason,
+			})
+		}
+	}
+	return nil
+}}
+
+// signedOffBy matches a Signed-off-by trailer and captures its email.
+var signedOffBy = regexp.MustCompile(`(?mi)^signed-off-by:.*<([^>]+)>\s*$`)
+
+// signedOff reports whether the commit carries a Signed-off-by trailer from
+// its author, as the DCO requires.
+func (c prCommit) signedOff() bool {
+	for _, m := range signedOffBy.FindAllStringSubmatch(c.Message, -1) {
+		if strings.EqualFold(m[1], c.AuthorEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingSignOff returns the commits without their author's sign-off.
+// Merge commits are exempt, like the DCO app does.
+func missingSignOff(commits []prCommit) []prCommit {
+	var missing []prCommit
+	for _, c := range commits {
+		if !c.Merge && !c.signedOff() {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// dcoLabel renders sign-off compliance: "ok", or how many commits lack a
+// sign-off in red.
+func dcoLabel(commits []prCommit) string {
+	if len(commits) == 0 {
+		return "-"
+	}
+	missing := missingSignOff(commits)
+	if len(missing) == 0 {
+		return paint(ansiGreen, "ok")
+	}
+	return paint(ansiRed, fmt.Sprintf("%d/%d not signed off", len(missing), len(commits)))
+}
+
+// unverified returns the commits without a verified signature.
+func unverified(commits []prCommit) []prCommit {
+	var bad []prCommit
+	for _, c := range commits {
+		if !c.Verified {
+			bad = append(bad, c)
+		}
+	}
+	return bad
+}
+
+// signaturesLabel renders signature verification: "ok", or how many commits
+// aren't verified in red.
+func signaturesLabel(commits []prCommit) string {
+	if len(commits) == 0 {
+		return "-"
+	}
+	bad := unverified(commits)
+	if len(bad) == 0 {
+		return paint(ansiGreen, "ok")
+	}
+	return paint(ansiRed, fmt.Sprintf("%d/%d unverified", len(bad), len(commits)))
+}
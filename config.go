@@ -0,0 +1,431 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const configFileName = "config.json"
+
+// Config holds user settings from ~/.config/pr-view/config.json. Every field
+// is optional; the zero value means "use the default".
+type Config struct {
+	Storage  StorageConfig  `json:"storage"`
+	Sync     SyncConfig     `json:"sync"`
+	Cache    CacheConfig    `json:"cache"`
+	Security SecurityConfig `json:"security"`
+	Auth     AuthConfig     `json:"auth"`
+	Network  NetworkConfig  `json:"network"`
+	Breaker  BreakerConfig  `json:"breaker"`
+	GitHub   GitHubConfig   `json:"github"`
+	List     ListConfig     `json:"list"`
+	SLA      SLASettings    `json:"sla"`
+	Log      LogConfig      `json:"log"`
+	Time     TimeConfig     `json:"time"`
+	Nudge    NudgeConfig    `json:"nudge"`
+	Stale    StaleConfig    `json:"stale"`
+	Daemon   DaemonConfig   `json:"daemon"`
+	Webhook  WebhookConfig  `json:"webhook"`
+	Tracing  TracingConfig  `json:"tracing"`
+	// UpdateCheck opts in to a daily check for newer releases.
+	UpdateCheck bool `json:"update_check,omitempty"`
+	// Hooks run commands on PR events in daemon mode.
+	Hooks []HookConfig `json:"hooks,omitempty"`
+	// Aliases map a command name to the command line it stands for, e.g.
+	// "mine": "list --blocked-on-me --sort updated".
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Views are named sets of list flags, applied with --view.
+	Views map[string]ViewConfig `json:"views,omitempty"`
+	// RepoSettings holds per-entry overrides keyed by owner/repo.
+	RepoSettings map[string]RepoSettings `json:"repo_settings,omitempty"`
+}
+
+// ListConfig holds defaults for `pr-view list` flags.
+type ListConfig struct {
+	// Limit is the maximum number of PRs fetched per repo.
+	Limit int `json:"limit,omitempty"`
+	// MaxTotal caps the number of PRs shown across all repos.
+	MaxTotal int `json:"max_total,omitempty"`
+	// Sort is created, updated (default), popularity or long-running.
+	Sort string `json:"sort,omitempty"`
+	// Direction is asc or desc (default).
+	Direction string `json:"direction,omitempty"`
+	// ShowBots lists dependency bot PRs individually.
+	ShowBots bool `json:"show_bots,omitempty"`
+	// Columns are the table columns, default repo, url and title.
+	Columns []string `json:"columns,omitempty"`
+	// ReviewWarn and ReviewAlert highlight PRs waiting this long for their
+	// first review, default 24h and 72h.
+	ReviewWarn  Duration `json:"review_warn,omitempty"`
+	ReviewAlert Duration `json:"review_alert,omitempty"`
+	// TitlePatterns opt in to title checks: regexps of which a PR title has
+	// to match one, or the presets conventional and ticket.
+	TitlePatterns []string `json:"title_patterns,omitempty"`
+	// Incremental fetches only PRs updated since the last run, like
+	// --incremental.
+	Incremental bool `json:"incremental,omitempty"`
+	// Format is text (default) or json, which makes --json the default.
+	Format string `json:"format,omitempty"`
+}
+
+// ViewConfig is a saved combination of list flags. Unset fields keep the
+// list defaults.
+type ViewConfig struct {
+	// Filters are list filter flags without dashes, e.g. ready or unread.
+	Filters   []string `json:"filters,omitempty"`
+	MaxSize   string   `json:"max_size,omitempty"`
+	Sort      string   `json:"sort,omitempty"`
+	Direction string   `json:"direction,omitempty"`
+	Columns   []string `json:"columns,omitempty"`
+	Limit     int      `json:"limit,omitempty"`
+	MaxTotal  int      `json:"max_total,omitempty"`
+	ShowBots  bool     `json:"show_bots,omitempty"`
+	GroupBy   string   `json:"group_by,omitempty"`
+}
+
+// LogConfig controls diagnostics written to stderr.
+type LogConfig struct {
+	// Format is text (default) or json.
+	Format string `json:"format,omitempty"`
+	// Level is debug, info (default), warn or error; -v and -vv lower it.
+	Level string `json:"level,omitempty"`
+}
+
+// TimeConfig controls how timestamps are shown.
+type TimeConfig struct {
+	// Style is relative ("3h ago", default) or absolute.
+	Style string `json:"style,omitempty"`
+	// Format is the strftime-style format of absolute times, default
+	// "%Y-%m-%d %H:%M".
+	Format string `json:"format,omitempty"`
+	// Timezone is the IANA zone absolute times are shown in, e.g.
+	// "Europe/Berlin" or "UTC"; default the system's.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// NudgeConfig holds defaults for `pr-view nudge`.
+type NudgeConfig struct {
+	// OlderThan is how long a PR goes without activity before it's stale,
+	// default 7d.
+	OlderThan Duration `json:"older_than,omitempty"`
+	// Message is the reminder comment; {author}, {reviewers}, {age}, {repo}
+	// and {number} are replaced.
+	Message string `json:"message,omitempty"`
+	// ReRequest re-requests the pending reviews instead of commenting.
+	ReRequest bool `json:"re_request,omitempty"`
+}
+
+// StaleConfig has the daemon label PRs without activity as stale, and take
+// the label off once they see activity again.
+type StaleConfig struct {
+	// Enabled turns stale labeling on; it's off by default.
+	Enabled bool `json:"enabled,omitempty"`
+	// Label is the label applied, default "stale".
+	Label string `json:"label,omitempty"`
+	// After is how long a PR goes without activity before it's stale,
+	// default 30d.
+	After Duration `json:"after,omitempty"`
+	// ExcludeLabels exempts PRs with any of these labels.
+	ExcludeLabels []string `json:"exclude_labels,omitempty"`
+	// ExcludeAuthors exempts PRs by these users.
+	ExcludeAuthors []string `json:"exclude_authors,omitempty"`
+	// ExcludeRepos exempts repos matching these patterns, e.g. "my-org/infra-*".
+	ExcludeRepos []string `json:"exclude_repos,omitempty"`
+	// ExcludeDrafts exempts draft PRs.
+	ExcludeDrafts bool `json:"exclude_drafts,omitempty"`
+}
+
+// TracingConfig sends OpenTelemetry spans to an OTLP/HTTP collector. The
+// OTEL_EXPORTER_OTLP_* variables work too.
+type TracingConfig struct {
+	// Endpoint is the full traces URL, e.g. http://localhost:4318/v1/traces.
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// ServiceName defaults to pr-view.
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// HookConfig runs Command through the shell whenever Event happens, with
+// the event and PR as JSON on stdin.
+type HookConfig struct {
+	// Event is new_pr, ci_failed (on your own PRs) or review_requested
+	// (from you or one of your teams).
+	Event   string `json:"event"`
+	Command string `json:"command"`
+	// Timeout bounds each run, 1m by default.
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// DaemonConfig holds settings for `pr-view daemon`.
+type DaemonConfig struct {
+	// Interval is the time between polls, default 5m.
+	Interval Duration `json:"interval,omitempty"`
+	// LogFile defaults to daemon.log in the user cache dir.
+	LogFile string `json:"log_file,omitempty"`
+	// LogMaxSizeMB rotates the log past this size, default 10.
+	LogMaxSizeMB int `json:"log_max_size_mb,omitempty"`
+	// LogMaxAge rotates the log once it is this old, default 168h.
+	LogMaxAge Duration `json:"log_max_age,omitempty"`
+	// LogBackups is how many rotated logs to keep, default 5.
+	LogBackups *int `json:"log_backups,omitempty"`
+	// OnNewPR, OnCIFailed and OnReviewRequested are shorthands for hooks
+	// on those events with the default timeout.
+	OnNewPR           string `json:"on_new_pr,omitempty"`
+	OnCIFailed        string `json:"on_ci_failed,omitempty"`
+	OnReviewRequested string `json:"on_review_requested,omitempty"`
+	// WarmCache refreshes what `list` needs on every poll, and lets other
+	// commands answer from the cache while the daemon runs.
+	WarmCache bool `json:"warm_cache,omitempty"`
+	// Webhook is an address to receive webhook deliveries on. Repos they
+	// arrive for are only polled every Reconcile, default 1h, to catch up
+	// on missed ones.
+	Webhook   string   `json:"webhook,omitempty"`
+	Reconcile Duration `json:"reconcile,omitempty"`
+}
+
+// WebhookConfig holds defaults for `pr-view webhook`.
+type WebhookConfig struct {
+	// Addr is the address to listen on, default :9000.
+	Addr string `json:"addr,omitempty"`
+	// Secret is the webhook's secret; PR_VIEW_WEBHOOK_SECRET works too.
+	Secret string `json:"secret,omitempty"`
+	// URL is where GitHub reaches daemon --webhook. As a GitHub App, the
+	// daemon points the app's webhook there on start.
+	URL string `json:"url,omitempty"`
+}
+
+type RepoSettings struct {
+	// Limit overrides list.limit for this repo.
+	Limit int `json:"limit,omitempty"`
+	// SLA overrides the sla settings for this repo.
+	SLA *SLAConfig `json:"sla,omitempty"`
+	// TitlePatterns overrides list.title_patterns for this repo.
+	TitlePatterns []string `json:"title_patterns,omitempty"`
+}
+
+// SLAConfig holds review service levels; zero means no SLA.
+type SLAConfig struct {
+	// FirstReview is the time a ready PR may wait for its first review.
+	FirstReview Duration `json:"first_review,omitempty"`
+	// Merge is the time a PR may stay open after being marked ready.
+	Merge Duration `json:"merge,omitempty"`
+}
+
+// SLASettings are the default SLAs and named groups of repos with their own.
+type SLASettings struct {
+	SLAConfig
+	// Groups match repos by owner/repo or a pattern such as owner/*; the
+	// first matching group in name order applies.
+	Groups map[string]SLAGroup `json:"groups,omitempty"`
+}
+
+type SLAGroup struct {
+	Repos []string `json:"repos"`
+	SLAConfig
+}
+
+type GitHubConfig struct {
+	// APIURL is the REST API root, https://api.github.com by default. For
+	// GitHub Enterprise Server use https://HOST/api/v3.
+	APIURL string `json:"api_url,omitempty"`
+}
+
+type StorageConfig struct {
+	// Backend is "json" (default) or "sqlite", for the repo list only;
+	// the state stays in state.json.
+	Backend string `json:"backend,omitempty"`
+	// Path overrides the location of the repo store file.
+	Path string `json:"path,omitempty"`
+	// SQLiteBinary is the sqlite3 executable used by the sqlite backend.
+	SQLiteBinary string `json:"sqlite_binary,omitempty"`
+}
+
+type SyncConfig struct {
+	// GistID is the private gist used by `config push` and `config pull`.
+	GistID string `json:"gist_id,omitempty"`
+}
+
+type CacheConfig struct {
+	// Enabled turns on the on-disk cache of GitHub API responses,
+	// revalidated with ETags.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+type SecurityConfig struct {
+	// EncryptCache encrypts cached API responses and other local PR data.
+	EncryptCache bool `json:"encrypt_cache,omitempty"`
+	// KeySource is "file" (default) or "keychain" (macOS keychain or the
+	// freedesktop secret service via secret-tool).
+	KeySource string `json:"key_source,omitempty"`
+	// KeyFile overrides ~/.config/pr-view/encryption.key for the file source.
+	KeyFile string `json:"key_file,omitempty"`
+}
+
+type AuthConfig struct {
+	// TokenSource is "env" (default), "op" (1Password CLI), "vault" or
+	// "app" (a GitHub App's installation tokens).
+	TokenSource string `json:"token_source,omitempty"`
+	// EnvVar overrides GITHUB_TOKEN for the env source.
+	EnvVar string `json:"env_var,omitempty"`
+	// OpRef is the secret reference passed to `op read`.
+	OpRef    string      `json:"op_ref,omitempty"`
+	OpBinary string      `json:"op_binary,omitempty"`
+	Vault    VaultConfig `json:"vault"`
+	App      AppConfig   `json:"app"`
+	// EnvVars and OpRefs name more tokens, read from the environment or
+	// with `op read`, to spread reads over. Writes, and reads about the
+	// token's own account, always use the token above.
+	EnvVars []string `json:"env_vars,omitempty"`
+	OpRefs  []string `json:"op_refs,omitempty"`
+	// Rotation picks the token for each read: "most-remaining" (default)
+	// or "round-robin".
+	Rotation string `json:"rotation,omitempty"`
+	// Preflight checks the token's scopes and SAML SSO authorization per
+	// org before each list, like --preflight.
+	Preflight bool `json:"preflight,omitempty"`
+}
+
+// VaultConfig locates the token in a Vault KV secret. Addr, Namespace,
+// RoleID and SecretID fall back to the standard VAULT_* variables.
+type VaultConfig struct {
+	Addr      string `json:"addr,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Mount is the KV engine mount, "secret" by default.
+	Mount string `json:"mount,omitempty"`
+	// Path is the secret path inside the mount.
+	Path string `json:"path,omitempty"`
+	// Field is the key holding the token, "token" by default.
+	Field string `json:"field,omitempty"`
+	// KVVersion selects the KV engine version, 2 by default.
+	KVVersion int `json:"kv_version,omitempty"`
+	// RoleID and SecretID enable AppRole login instead of VAULT_TOKEN.
+	RoleID       string `json:"role_id,omitempty"`
+	SecretID     string `json:"secret_id,omitempty"`
+	AppRoleMount string `json:"approle_mount,omitempty"`
+}
+
+// AppConfig authenticates as a GitHub App. AppID and the key fall back to
+// GITHUB_APP_ID and GITHUB_APP_PRIVATE_KEY (the PEM itself).
+type AppConfig struct {
+	// AppID is the numeric app ID or the app's client ID.
+	AppID          string `json:"app_id,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	// InstallationID is used for requests that don't name an account,
+	// instead of the first installation.
+	InstallationID int64 `json:"installation_id,omitempty"`
+}
+
+type NetworkConfig struct {
+	// Proxy overrides HTTPS_PROXY/HTTP_PROXY; NoProxy lists hosts (and
+	// their subdomains) that bypass it.
+	Proxy   string   `json:"proxy,omitempty"`
+	NoProxy []string `json:"no_proxy,omitempty"`
+	// CABundle is a PEM file of extra trusted roots, e.g. for a corporate
+	// TLS-intercepting proxy.
+	CABundle           string `json:"ca_bundle,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	// Timeout bounds each request attempt, 15s by default.
+	Timeout Duration `json:"timeout,omitempty"`
+	// Retries is how often failed GETs are retried, 2 by default.
+	Retries *int `json:"retries,omitempty"`
+	// RetryOn lists the HTTP statuses worth retrying, 500/502/503/504 by
+	// default.
+	RetryOn []int `json:"retry_on,omitempty"`
+	// MaxRetryAfter is how long a request waits out secondary rate limits
+	// before failing, 5m by default.
+	MaxRetryAfter Duration `json:"max_retry_after,omitempty"`
+}
+
+// BreakerConfig controls the per-repo circuit breaker that skips entries
+// which keep failing with auth, not-found or rate-limit errors.
+type BreakerConfig struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Threshold is the number of consecutive failures that trips the
+	// breaker, 3 by default.
+	Threshold int `json:"threshold,omitempty"`
+	// Cooldown is how long a tripped entry is skipped, 30m by default.
+	Cooldown Duration `json:"cooldown,omitempty"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("30s") in
+// the config file.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\"")
+	}
+	v, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// configDir returns ~/.config/pr-view, creating it if needed.
+func configDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".config", "pr-view")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// LoadConfig reads the config file. A missing file yields the defaults.
+func LoadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to the config file.
+func SaveConfig(cfg *Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeFileAtomic(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config keys are the dotted JSON paths of the file, e.g. list.limit or
+// repo_settings.owner/repo.limit. Map keys may contain dots themselves.
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// validateConfig checks the settings that only take a fixed set of values,
+// so `config set` and `config edit` can reject them before saving.
+func validateConfig(cfg *Config) error {
+	if f := cfg.Log.Format; f != "" && f != "text" && f != "json" {
+		return fmt.Errorf("log.format: unknown format %q (expected text or json)", f)
+	}
+	if l := cfg.Log.Level; l != "" {
+		var lvl slog.Level
+		if err := lvl.UnmarshalText([]byte(l)); err != nil {
+			return fmt.Errorf("log.level: %q is not debug, info, warn or error", l)
+		}
+	}
+	if err := validateTime(cfg.Time); err != nil {
+		return err
+	}
+	for _, p := range cfg.Stale.ExcludeRepos {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("stale.exclude_repos: invalid pattern %q", p)
+		}
+	}
+	switch cfg.Storage.Backend {
+	case "", "json", "sqlite":
+	default:
+		return fmt.Errorf("storage.backend: unknown backend %q (expected json or sqlite)", cfg.Storage.Backend)
+	}
+	switch cfg.Auth.TokenSource {
+	case "", "env", "op", "vault", "app":
+	default:
+		return fmt.Errorf("auth.token_source: unknown source %q (expected env, op, vault or app)", cfg.Auth.TokenSource)
+	}
+	switch cfg.Auth.Rotation {
+	case "", "most-remaining", "round-robin":
+	default:
+		return fmt.Errorf("auth.rotation: unknown rotation %q (expected most-remaining or round-robin)", cfg.Auth.Rotation)
+	}
+	switch cfg.Security.KeySource {
+	case "", "file", "keychain":
+	default:
+		return fmt.Errorf("security.key_source: unknown source %q (expected file or keychain)", cfg.Security.KeySource)
+	}
+	if s := cfg.List.Sort; s != "" && !slices.Contains(listSorts, s) {
+		return fmt.Errorf("list.sort: %q is not one of %s", s, strings.Join(listSorts, ", "))
+	}
+	if d := cfg.List.Direction; d != "" && !slices.Contains(listDirections, d) {
+		return fmt.Errorf("list.direction: %q is not asc or desc", d)
+	}
+	switch cfg.List.Format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("list.format: unknown format %q (expected text or json)", cfg.List.Format)
+	}
+	if len(cfg.List.Columns) > 0 {
+		if _, err := parseColumns(strings.Join(cfg.List.Columns, ",")); err != nil {
+			return fmt.Errorf("list.columns: %w", err)
+		}
+	}
+	if err := validateTitlePatterns(cfg.List.TitlePatterns); err != nil {
+		return fmt.Errorf("list.title_patterns: %w", err)
+	}
+	for repo, rs := range cfg.RepoSettings {
+		if err := validateTitlePatterns(rs.TitlePatterns); err != nil {
+			return fmt.Errorf("repo_settings.%s.title_patterns: %w", repo, err)
+		}
+	}
+	for i, h := range cfg.Hooks {
+		if !slices.Contains(hookEvents, h.Event) {
+			return fmt.Errorf("hooks[%d].event: unknown event %q (expected %s)", i, h.Event, strings.Join(hookEvents, ", "))
+		}
+		if strings.TrimSpace(h.Command) == "" {
+			return fmt.Errorf("hooks[%d].command: missing", i)
+		}
+	}
+	for name, a := range cfg.Aliases {
+		if strings.HasPrefix(a, "!") {
+			continue
+		}
+		if words, err := splitWords(a); err != nil || len(words) == 0 {
+			return fmt.Errorf("aliases.%s: invalid expansion %q", name, a)
+		}
+	}
+	for name, v := range cfg.Views {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("views.%s: %w", name, err)
+		}
+		if len(v.Columns) > 0 {
+			if _, err := parseColumns(strings.Join(v.Columns, ",")); err != nil {
+				return fmt.Errorf("views.%s.columns: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configFields returns the JSON-visible fields of struct type t by key,
+// with embedded structs flattened as encoding/json does.
+func configFields(t reflect.Type) ([]string, map[string]reflect.StructField) {
+	var keys []string
+	fields := map[string]reflect.StructField{}
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		keys = append(keys, name)
+		fields[name] = f
+	}
+	return keys, fields
+}
+
+// walkConfig finds the value at path below v and calls fn on it. When write
+// is set, nil pointers and maps on the way are allocated and map entries
+// are stored back after fn, or deleted if fn left them zero.
+func walkConfig(v reflect.Value, path []string, write bool, fn func(reflect.Value) error) error {
+	if len(path) == 0 {
+		return fn(v)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			if !write {
+				return walkConfig(reflect.New(v.Type().Elem()).Elem(), path, write, fn)
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return walkConfig(v.Elem(), path, write, fn)
+	case reflect.Struct:
+		keys, fields := configFields(v.Type())
+		f, ok := fields[path[0]]
+		if !ok {
+			return fmt.Errorf("unknown key %q (expected one of %s)", path[0], strings.Join(keys, ", "))
+		}
+		return walkConfig(v.FieldByIndex(f.Index), path[1:], write, fn)
+	case reflect.Map:
+		// the shortest key whose remainder resolves wins, so
+		// repo_settings.owner/my.repo.limit finds "owner/my.repo"
+		var first error
+		for n := 1; n <= len(path); n++ {
+			key := reflect.ValueOf(strings.Join(path[:n], "."))
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if cur := v.MapIndex(key); cur.IsValid() {
+				elem.Set(cur)
+			}
+			if err := walkConfig(elem, path[n:], write, fn); err != nil {
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			if write {
+				if v.IsNil() {
+					v.Set(reflect.MakeMap(v.Type()))
+				}
+				if elem.IsZero() {
+					v.SetMapIndex(key, reflect.Value{})
+				} else {
+					v.SetMapIndex(key, elem)
+				}
+				if v.Len() == 0 {
+					v.Set(reflect.Zero(v.Type()))
+				}
+			}
+			return nil
+		}
+		return first
+	}
+	return fmt.Errorf("%q is not a section", path[0])
+}
+
+// setConfigValue parses s into v according to its type. Lists are
+// comma-separated; anything else structured takes JSON.
+func setConfigValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setConfigValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30m, 24h or 7d", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(s), "[") {
+			break
+		}
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setConfigValue(e, item); err != nil {
+				return err
+			}
+			list = reflect.Append(list, e)
+		}
+		v.Set(list)
+		return nil
+	default:
+		if !strings.HasPrefix(strings.TrimSpace(s), "{") {
+			return fmt.Errorf("this is a section, set one of its keys or pass a JSON object")
+		}
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map || v.Kind() == reflect.Struct {
+		p := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+			return err
+		}
+		v.Set(p.Elem())
+	}
+	return nil
+}
+
+// formatConfigValue prints scalars bare and sections as JSON.
+func formatConfigValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == durationType:
+		if v.Int() == 0 {
+			return "", nil
+		}
+		return time.Duration(v.Int()).String(), nil
+	case v.Kind() == reflect.String:
+		return v.String(), nil
+	case v.Kind() == reflect.Bool, v.Kind() == reflect.Int:
+		return fmt.Sprint(v.Interface()), nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Struct:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ","), nil
+	}
+	data, err := json.MarshalIndent(v.Interface(), "", "  ")
+	return string(data), err
+}
+
+func splitConfigKey(key string) ([]string, error) {
+	if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+	return strings.Split(key, "."), nil
+}
+
+// cmdConfigGet prints one setting, or the whole file without a key.
+func cmdConfigGet(args []string) int {
+	if len(args) > 1 {
+		fmt.Println("usage: pr-view config get [key]")
+		return 2
+	}
+	// reload: main applies flag overrides to its copy
+	cfg, err := LoadConfig()
+	if err != nil {
+		slog.Error("loading config", "err", err)
+		return 1
+	}
+	var path []string
+	if len(args) == 1 {
+		if path, err = splitConfigKey(args[0]); err != nil {
+			fmt.Println(err)
+			return 2
+		}
+	}
+	err = walkConfig(reflect.ValueOf(cfg).Elem(), path, false, func(v reflect.Value) error {
+		s, err := formatConfigValue(v)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	return 0
+}
+
+// cmdConfigSet sets (value non-nil) or unsets one setting, validating the
+// result before saving.
+func cmdConfigSet(key string, value *string) int {
+	path, err := splitConfigKey(key)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		slog.Error("loading config", "err", err)
+		return 1
+	}
+	err = walkConfig(reflect.ValueOf(cfg).Elem(), path, true, func(v reflect.Value) error {
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		return setConfigValue(v, *value)
+	})
+	if err != nil {
+		fmt.Printf("%s: %v\n", key, err)
+		return 2
+	}
+	if err := validateConfig(cfg); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if err := SaveConfig(cfg); err != nil {
+		slog.Error("saving config", "err", err)
+		return 1
+	}
+	return 0
+}
+
+// decodeConfigStrict parses a config file rejecting unknown keys, which
+// LoadConfig would silently ignore.
+func decodeConfigStrict(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, validateConfig(cfg)
+}
+
+// cmdConfigEdit opens the config file in $VISUAL or $EDITOR and saves it
+// only once it parses and validates.
+func cmdConfigEdit() int {
+	path, err := configPath()
+	if err != nil {
+		slog.Error("locating config", "err", err)
+		return 1
+	}
+	orig, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		orig, err = []byte("{\n}\n"), nil
+	}
+	if err != nil {
+		slog.Error("reading config", "err", err)
+		return 1
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "config-*.json")
+	if err != nil {
+		slog.Error("creating temp file", "err", err)
+		return 1
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(orig)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		slog.Error("writing temp file", "err", err)
+		return 1
+	}
+	editor := strings.Fields(firstNonEmpty(os.Getenv("VISUAL"), os.Getenv("EDITOR")))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+		if runtime.GOOS == "windows" {
+			editor = []string{"notepad"}
+		}
+	}
+	in := bufio.NewReader(os.Stdin)
+	for {
+		cmd := exec.Command(editor[0], append(editor[1:], tmp.Name())...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			slog.Error("running editor", "editor", strings.Join(editor, " "), "err", err)
+			return 1
+		}
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			slog.Error("reading edited config", "err", err)
+			return 1
+		}
+		if bytes.Equal(data, orig) {
+			fmt.Println("no changes")
+			return 0
+		}
+		if _, err = decodeConfigStrict(data); err == nil {
+			unlock, err := lockFile(path)
+			if err != nil {
+				slog.Error("locking config", "err", err)
+				return 1
+			}
+			defer unlock()
+			if err := writeFileAtomic(path, data, 0o600); err != nil {
+				slog.Error("saving config", "err", err)
+				return 1
+			}
+			fmt.Println("saved", path)
+			return 0
+		}
+		fmt.Println("invalid config:", err)
+		fmt.Print("edit again? [Y/n] ")
+		answer, _ := in.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+			fmt.Println("discarded changes")
+			return 1
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const defaultDaemonInterval = 5 * time.Minute
+
+// defaultReconcileInterval is the time between polls of repos deliveries
+// keep up to date; the polls only catch what deliveries missed.
+const defaultReconcileInterval = time.Hour
+
+// daemonStop stops the daemon: on SIGINT and SIGTERM, or when the Windows
+// service manager asks.
+var daemonStop = make(chan os.Signal, 1)
+
+// daemonLogPath is where the daemon logs unless daemon.log_file says
+// otherwise.
+func daemonLogPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "pr-view", "daemon.log"), nil
+}
+
+// daemonFlags defines the daemon's own flags; the list flags come on top.
+func daemonFlags(cfg *Config) (fs *flag.FlagSet, interval *time.Duration, logFile *string) {
+	fs = flag.NewFlagSet("daemon", flag.ContinueOnError)
+	interval = fs.Duration("interval", time.Duration(cfg.Daemon.Interval), "time between polls (default 5m)")
+	logFile = fs.String("log-file", cfg.Daemon.LogFile, "log file, rotated by size and age (default: user cache dir/pr-view/daemon.log; - for stderr)")
+	fs.BoolVar(&cfg.Daemon.WarmCache, "warm-cache", cfg.Daemon.WarmCache, "keep the cache warm so other commands answer from it while the daemon runs")
+	fs.StringVar(&cfg.Daemon.Webhook, "webhook", cfg.Daemon.Webhook, "also act on webhook deliveries received on this address, e.g. :9000; repos they arrive for are only polled to reconcile")
+	fs.Func("reconcile", "with --webhook, time between polls of repos that get deliveries (default 1h)", func(v string) error {
+		d, err := time.ParseDuration(v)
+		cfg.Daemon.Reconcile = Duration(d)
+		return err
+	})
+	fs.StringVar(&cfg.Webhook.URL, "webhook-url", cfg.Webhook.URL, "as a GitHub App, point the app's webhook at this public URL of --webhook")
+	return fs, interval, logFile
+}
+
+// cmdDaemon polls the tracked repos every interval, logging each poll and
+// the PRs that appeared since the last one. Besides the daemon's own flags,
+// list flags are accepted too, e.g. `pr-view daemon --interval 2m --ready`.
+// `daemon install`, `uninstall`, `start` and `stop` manage it as a
+// service.
+func cmdDaemon(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "install":
+			return cmdDaemonInstall(cfg, args[1:])
+		case "uninstall":
+			return cmdDaemonUninstall(args[1:])
+		case "start", "stop":
+			return cmdDaemonStartStop(args[1:], args[0] == "start")
+		case "status":
+			return cmdDaemonStatus(cfg, args[1:])
+		case "service":
+			// started by the Windows service manager, see daemon install
+			return runDaemonService(func() int { return cmdDaemon(cfg, hc, args[1:]) })
+		}
+	}
+	fs, interval, logFile := daemonFlags(cfg)
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil {
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("the daemon watches PRs, --issues isn't supported")
+		return 2
+	}
+	if *interval <= 0 {
+		*interval = defaultDaemonInterval
+	}
+	reconcile := time.Duration(cfg.Daemon.Reconcile)
+	if reconcile <= 0 {
+		reconcile = defaultReconcileInterval
+	}
+	if *logFile != "-" {
+		path := *logFile
+		if path == "" {
+			if path, err = daemonLogPath(); err != nil {
+				slog.Error("locating log file", "err", err)
+				return 1
+			}
+		}
+		maxSize := int64(cfg.Daemon.LogMaxSizeMB)
+		if maxSize <= 0 {
+			maxSize = defaultLogMaxSizeMB
+		}
+		maxAge := time.Duration(cfg.Daemon.LogMaxAge)
+		if maxAge <= 0 {
+			maxAge = defaultLogMaxAge
+		}
+		backups := defaultLogBackups
+		if cfg.Daemon.LogBackups != nil {
+			backups = *cfg.Daemon.LogBackups
+		}
+		w, err := openRotatingFile(path, maxSize<<20, maxAge, backups)
+		if err != nil {
+			slog.Error("opening log file", "err", err)
+			return 1
+		}
+		defer w.Close()
+		if err := setupLogging(w, cfg.Log.Format, true); err != nil {
+			slog.Error("setting up logging", "err", err)
+			return 1
+		}
+		fmt.Println("pr-view daemon logging to", path)
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	if cfg.Daemon.WarmCache {
+		if !cfg.Cache.Enabled {
+			fmt.Println("--warm-cache needs the cache, turn it on with: pr-view config set cache.enabled true")
+			return 2
+		}
+		// the daemon keeps the cache warm, so it must never read it warm
+		gh = gh.withContext(withRevalidate(gh.ctx))
+		defer stopWarming()
+	}
+
+	// events about your own PRs and review requests need to know who you are
+	var me *viewer
+	if hasHook(cfg, eventCIFailed) || hasHook(cfg, eventReviewRequested) {
+		if me, err = gh.viewer(); err != nil {
+			slog.Error("ci_failed and review_requested hooks need your identity", "err", err)
+			return 1
+		}
+	}
+
+	live := newLiveStatus(cfg.Daemon.Webhook, *interval, reconcile)
+	defer clearLiveStatus()
+	// deliveries are handled between polls, so they never race one
+	var deliveries chan webhookDelivery
+	var webhookErr <-chan error
+	if cfg.Daemon.Webhook != "" {
+		secret := firstNonEmpty(cfg.Webhook.Secret, os.Getenv("PR_VIEW_WEBHOOK_SECRET"))
+		if secret == "" {
+			fmt.Println("--webhook needs a secret to verify deliveries: set webhook.secret or PR_VIEW_WEBHOOK_SECRET")
+			return 2
+		}
+		registerSecret(secret)
+		if cfg.Webhook.URL != "" {
+			if gh.app == nil {
+				fmt.Println("--webhook-url sets a GitHub App's webhook, but the token source isn't app; configure the webhook on GitHub instead")
+				return 2
+			}
+			if err := gh.app.setWebhook(cfg.Webhook.URL, secret); err != nil {
+				slog.Error("pointing the app's webhook at the daemon", "err", err)
+				return 1
+			}
+			slog.Info("app webhook set", "url", cfg.Webhook.URL)
+		}
+		deliveries = make(chan webhookDelivery, 100)
+		opts.due = func(entry string) bool { return live.due(entry, time.Now()) }
+		var srv *http.Server
+		srv, webhookErr = serveWebhooks(cfg.Daemon.Webhook, secret, deliveries)
+		defer stopWebhooks(srv)
+	}
+
+	stop := daemonStop
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	slog.Info("daemon started", "interval", *interval, "pid", os.Getpid(), "hooks", len(cfg.hooks()), "stale_labeling", cfg.Stale.Enabled, "warm_cache", cfg.Daemon.WarmCache, "webhook", cfg.Daemon.Webhook)
+	var seen map[string]prSnapshot
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if cfg.Daemon.WarmCache {
+			warmCache(cfg, gh, *interval)
+		}
+		seen = daemonPoll(cfg, gh, opts, me, live, seen)
+	wait:
+		for {
+			select {
+			case sig := <-stop:
+				slog.Info("daemon stopping", "signal", sig.String())
+				return 0
+			case err := <-webhookErr:
+				slog.Error("listening for webhooks", "err", err)
+				return 1
+			case d := <-deliveries:
+				if daemonDelivery(cfg, gh, opts, me, live, seen, d) {
+					ticker.Reset(*interval)
+					break wait
+				}
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
+
+// daemonPoll runs one poll and returns what it saw of each PR, logging and
+// running hooks for what changed since prev. The first poll (prev nil) only
+// records what's there. me is only needed for ci_failed and
+// review_requested hooks.
+func daemonPoll(cfg *Config, gh *GitHubClient, opts *listOptions, me *viewer, live *liveStatus, prev map[string]prSnapshot) map[string]prSnapshot {
+	start := time.Now()
+	results, err := collectPRs(cfg, gh, opts)
+	if err != nil {
+		slog.Error("poll failed", "err", err)
+		flushTraces()
+		return prev
+	}
+	cur := map[string]prSnapshot{}
+	prs, failed, pushed := 0, 0, 0
+	for _, res := range results {
+		if res.Err != nil {
+			if errors.Is(res.Err, errNotDue) {
+				pushed++
+			} else {
+				failed++
+				slog.Warn("fetching repo", "repo", res.Repo, "err", res.Err)
+			}
+			// keep what we knew, or its PRs would all be new next time
+			prefix := strings.ToLower(repoName(res.Repo)) + "#"
+			for k, s := range prev {
+				if strings.HasPrefix(k, prefix) {
+					cur[k] = s
+				}
+			}
+			continue
+		}
+		live.repo(res.Repo).Poll = start
+		for _, pr := range append(res.PRs, res.Bots...) {
+			prs++
+			daemonObserve(cfg, gh, me, res.Repo, pr, prev, cur)
+		}
+	}
+	live.save()
+	if cfg.Stale.Enabled {
+		staleSweep(cfg, gh, results)
+	}
+	slog.Info("poll done", "prs", prs, "repos", len(results), "failed", failed, "pushed", pushed, "duration", time.Since(start).Round(time.Millisecond))
+	flushTraces()
+	return cur
+}
+
+// daemonObserve records what the daemon sees of pr in cur and runs hooks
+// for what changed since prev, which is nil on the first poll.
+func daemonObserve(cfg *Config, gh *GitHubClient, me *viewer, entry string, pr PullRequest, prev, cur map[string]prSnapshot) {
+	repo := repoName(entry)
+	key := prKey(entry, pr.Number)
+	var snap prSnapshot
+	if me != nil {
+		if hasHook(cfg, eventCIFailed) && strings.EqualFold(pr.User.Login, me.Login) && pr.CIState == "" {
+			if err := enrichCI.run(gh, repo, &pr); err != nil {
+				slog.Warn("fetching CI state", "pr", key, "err", err)
+			}
+		}
+		snap = prSnapshot{ci: pr.CIState, requested: reviewRequestedFrom(pr, repo, me)}
+	}
+	cur[key] = snap
+	if prev == nil {
+		return
+	}
+	var last *prSnapshot
+	if s, ok := prev[key]; ok {
+		last = &s
+	}
+	for _, event := range prEvents(last, snap) {
+		if event == eventNewPR {
+			slog.Info("new PR", "pr", key, "title", pr.Title, "author", pr.User.Login, "url", pr.HTMLURL)
+		}
+		runHooks(cfg, hookPayload{Event: event, Repo: repo, PR: pr})
+	}
+}
+
+// daemonDelivery applies a webhook delivery to seen, what the last poll
+// saw, running hooks as a poll would: each PR it names goes through the
+// list pipeline on its own, so the daemon's filters apply. It reports
+// whether the delivery calls for a poll right away, like the app being
+// installed on another account.
+func daemonDelivery(cfg *Config, gh *GitHubClient, opts *listOptions, me *viewer, live *liveStatus, seen map[string]prSnapshot, d webhookDelivery) bool {
+	if strings.HasPrefix(d.event, "installation") {
+		deliveryUpdates(cfg, gh, me, d)
+		return true
+	}
+	if seen == nil {
+		// nothing to compare with until a poll went through
+		return false
+	}
+	updates := deliveryUpdates(cfg, gh, me, d)
+	now := time.Now()
+	for _, u := range updates {
+		live.repo(u.repo).Delivery = now
+	}
+	if len(updates) > 0 {
+		live.save()
+	}
+	for _, u := range updates {
+		key := prKey(u.repo, u.pr.Number)
+		prev := map[string]prSnapshot{}
+		if s, ok := seen[key]; ok {
+			prev[key] = s
+		}
+		// closed PRs and those the filters now leave out drop out, as in a poll
+		delete(seen, key)
+		if u.pr.State != "open" {
+			continue
+		}
+		o := *opts
+		o.source = func(*GitHubClient, *listOptions) ([]PRResult, error) {
+			return []PRResult{{Repo: u.repo, PRs: []PullRequest{u.pr}}}, nil
+		}
+		results, err := collectPRs(cfg, gh, &o)
+		if err != nil {
+			slog.Warn("handling delivery", "pr", key, "err", err)
+			maps.Copy(seen, prev) // keep what we knew
+			continue
+		}
+		for _, res := range results {
+			for _, pr := range append(res.PRs, res.Bots...) {
+				daemonObserve(cfg, gh, me, res.Repo, pr, prev, seen)
+			}
+		}
+	}
+	return false
+}
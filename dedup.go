@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// dedupTransport sends identical GETs once: requests that arrive while the
+// same one is in flight wait for it and share its response. Within a run
+// marked with withRunMemo, finished responses are shared too, so a PR
+// fetched for both owner/repo and owner/repo#123 is enriched with one set
+// of requests.
+type dedupTransport struct {
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is one request and, once done is closed, its response.
+type flight struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// runMemo holds the responses of one run, see withRunMemo.
+type runMemo struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type runMemoKey struct{}
+
+// withRunMemo returns a context whose GETs are each sent once for as long
+// as it lives. Only read-only runs should use it, as a response fetched
+// before a write would be served after it.
+func withRunMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runMemoKey{}, &runMemo{calls: map[string]*flight{}})
+}
+
+func (t *dedupTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	key := cacheKey(req)
+	mu, calls := &t.mu, &t.calls
+	memo, _ := req.Context().Value(runMemoKey{}).(*runMemo)
+	if memo != nil {
+		mu, calls = &memo.mu, &memo.calls
+	}
+	mu.Lock()
+	if *calls == nil {
+		*calls = map[string]*flight{}
+	}
+	if f, ok := (*calls)[key]; ok {
+		mu.Unlock()
+		select {
+		case <-f.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return f.share(req, true)
+	}
+	f := &flight{done: make(chan struct{})}
+	(*calls)[key] = f
+	mu.Unlock()
+
+	f.resp, f.err = t.next.RoundTrip(req)
+	if f.err == nil {
+		f.body, f.err = io.ReadAll(f.resp.Body)
+		f.resp.Body.Close()
+	}
+	close(f.done)
+	// a run keeps its successes, failures are worth another try
+	if memo == nil || f.err != nil || f.resp.StatusCode > 299 {
+		mu.Lock()
+		delete(*calls, key)
+		mu.Unlock()
+	}
+	return f.share(req, false)
+}
+
+// share returns a copy of the response with its own body. Shared copies are
+// marked for the request log.
+func (f *flight) share(req *http.Request, shared bool) (*http.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	if shared {
+		resp.Header.Set("X-Deduplicated", "1")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(f.body))
+	resp.Request = req
+	return &resp, nil
+}
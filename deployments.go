@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deployment is the latest deployment of the PR head to one environment,
+// such as a preview environment, with its latest status.
+type deployment struct {
+	Environment string `json:"environment"`
+	State       string `json:"state"`
+	URL         string `json:"url,omitempty"`
+}
+
+// enrichDeployments sets the deployments of the PR head, newest per
+// environment. Each deployment costs one more request for its status.
+var enrichDeployments = &enricher{name: "deployments", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	var deps []struct {
+		ID          int64  `json:"id"`
+		Environment string `json:"environment"`
+	}
+	// newest first
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/deployments?sha=%s&per_page=100", repo, pr.Head.SHA), nil, &deps); err != nil {
+		return err
+	}
+	pr.Deployments = nil
+	seen := map[string]bool{}
+	for _, d := range deps {
+		if seen[d.Environment] {
+			continue
+		}
+		seen[d.Environment] = true
+		var statuses []struct {
+			State          string `json:"state"`
+			EnvironmentURL string `json:"environment_url"`
+		}
+		if err := c.do("GET", fmt.Sprintf("/repos/%s/deployments/%d/statuses?per_page=1", repo, d.ID), nil, &statuses); err != nil {
+			return err
+		}
+		dep := deployment{Environment: d.Environment, State: "pending"}
+		if len(statuses) > 0 {
+			dep.State, dep.URL = statuses[0].State, statuses[0].EnvironmentURL
+		}
+		pr.Deployments = append(pr.Deployments, dep)
+	}
+	return nil
+}}
+
+// deploymentColors color deployment states like CI.
+var deploymentColors = map[string]string{
+	"success":  ansiGreen,
+	"failure":  ansiRed,
+	"error":    ansiRed,
+	"inactive": "",
+}
+
+// deploymentsSummary renders the deployments as "env: state url", comma
+// separated, URLs only for live ones.
+func deploymentsSummary(deps []deployment) string {
+	var parts []string
+	for _, d := range deps {
+		color, ok := deploymentColors[d.State]
+		if !ok {
+			color = ansiYellow
+		}
+		part := d.Environment + ": " + paint(color, d.State)
+		if d.URL != "" && d.State == "success" {
+			part += " " + d.URL
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
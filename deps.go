@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bumpPattern extracts the versions from titles like
+// "Bump lodash from 4.17.20 to 4.17.21".
+var bumpPattern = regexp.MustCompile(`(?i)\bfrom\s+v?(\d+(?:\.\d+)*)\S*\s+to\s+v?(\d+(?:\.\d+)*)`)
+
+// updateType classifies a dependency PR as "major", "minor" or "patch" from
+// the versions in its title, falling back to semver labels, or "unknown".
+func updateType(pr PullRequest) string {
+	if m := bumpPattern.FindStringSubmatch(pr.Title); m != nil {
+		from, to := strings.Split(m[1], "."), strings.Split(m[2], ".")
+		for i, kind := range []string{"major", "minor"} {
+			if segment(from, i) != segment(to, i) {
+				return kind
+			}
+		}
+		return "patch"
+	}
+	for _, l := range pr.Labels {
+		name := strings.ToLower(l.Name)
+		for _, kind := range []string{"major", "minor", "patch"} {
+			if name == kind || strings.HasSuffix(name, "-"+kind) || strings.HasSuffix(name, ": "+kind) {
+				return kind
+			}
+		}
+	}
+	return "unknown"
+}
+
+func segment(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
+
+func cmdDeps(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 || args[0] != "approve" {
+		fmt.Println("usage: pr-view deps approve [--filter minor|patch] [--auto-merge] [--dry-run]")
+		return 2
+	}
+	fs := flag.NewFlagSet("deps approve", flag.ContinueOnError)
+	filter := fs.String("filter", "minor|patch", "regexp matched against the update type (major, minor, patch, unknown)")
+	autoMerge := fs.Bool("auto-merge", false, "enable auto-merge instead of approving")
+	mergeMethod := fs.String("merge-method", "squash", "auto-merge method: merge, squash or rebase")
+	dryRun := fs.Bool("dry-run", false, "show what would be done without doing it")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	filterRe, err := regexp.Compile("^(?:" + *filter + ")$")
+	if err != nil {
+		fmt.Println("invalid --filter:", err)
+		return 2
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		slog.Error("initializing store", "err", err)
+		return 1
+	}
+	repos, err := store.Load()
+	if err != nil {
+		slog.Error("loading repos", "err", err)
+		return 1
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	var me *viewer
+	if !*autoMerge {
+		// to leave the PRs you approved on an earlier run alone
+		if me, err = gh.viewer(); err != nil {
+			slog.Error("approving needs your identity", "err", err)
+			return 1
+		}
+	}
+	query := func(string) prQuery { return prQuery{Limit: 100, Sort: "updated", Direction: "desc"} }
+	results := fetchAll(gh, repos, query, func(string) error { return nil })
+
+	code := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("listing", "repo", res.Repo, "err", res.Err)
+			code = 1
+			continue
+		}
+		repo := repoName(res.Repo)
+		for _, pr := range res.PRs {
+			if !isDependencyPR(pr) || strings.ToLower(pr.State) != "open" {
+				continue
+			}
+			kind := updateType(pr)
+			ref := fmt.Sprintf("%s#%d", repo, pr.Number)
+			desc := fmt.Sprintf("%s (%s) %s", ref, kind, truncate(pr.Title, 60))
+			if !filterRe.MatchString(kind) {
+				continue
+			}
+			if *autoMerge && pr.AutoMerge != nil {
+				fmt.Println("auto-merge already enabled on", desc)
+				continue
+			}
+			if !*autoMerge {
+				if err := enrichReviews.run(gh, repo, &pr); err != nil {
+					slog.Error("fetching reviews for", "pr", ref, "err", err)
+					code = 1
+					continue
+				}
+				if approvedBy(pr.Reviews, me.Login) {
+					fmt.Println("already approved", desc)
+					continue
+				}
+			}
+			state, err := ciState(gh, repo, pr.Head.SHA)
+			if err != nil {
+				slog.Error("checking CI for", "pr", ref, "err", err)
+				code = 1
+				continue
+			}
+			if state != ciSuccess {
+				fmt.Printf("skipped %s: CI %s\n", desc, state)
+				continue
+			}
+			action := "approve"
+			if *autoMerge {
+				action = "enable auto-merge on"
+			}
+			if *dryRun {
+				fmt.Printf("would %s %s\n", action, desc)
+				continue
+			}
+			if *autoMerge {
+				err = enableAutoMerge(gh, pr.NodeID, *mergeMethod)
+			} else {
+				err = approvePR(gh, repo, pr.Number, "")
+			}
+			if err != nil {
+				slog.Error("trying to "+action, "pr", ref, "err", err)
+				code = 1
+				continue
+			}
+			if *autoMerge {
+				fmt.Println("enabled auto-merge on", desc)
+			} else {
+				fmt.Println("approved", desc)
+			}
+		}
+	}
+	return code
+}
+
+// approvedBy reports whether login's latest verdict among reviews is an
+// approval that still stands.
+func approvedBy(reviews []review, login string) bool {
+	approved := false
+	for _, r := range reviews {
+		if !strings.EqualFold(r.User.Login, login) {
+			continue
+		}
+		switch r.State {
+		case "APPROVED":
+			approved = true
+		case "CHANGES_REQUESTED", "DISMISSED":
+			approved = false
+		}
+	}
+	return approved
+}
+
+// approvePR submits an approving review.
+func approvePR(c *GitHubClient, repo string, number int, body string) error {
+	review := map[string]string{"event": "APPROVE"}
+	if body != "" {
+		review["body"] = body
+	}
+	return c.do("POST", fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, number), review, nil)
+}
+
+// enableAutoMerge turns on auto-merge for the PR with the given node ID.
+func enableAutoMerge(c *GitHubClient, nodeID, method string) error {
+	const mutation = `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+	enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`
+	return c.graphql(mutation, map[string]any{"id": nodeID, "method": strings.ToUpper(method)}, nil)
+}
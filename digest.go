@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DigestState is what the last digest saw, to tell what changed since.
+type DigestState struct {
+	At time.Time `json:"at"`
+	// PRs maps owner/repo#number to the PR's title and URL.
+	PRs map[string]DigestPR `json:"prs,omitempty"`
+}
+
+// DigestPR is how a digest remembers a PR, enough to list it once closed.
+type DigestPR struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+var digestFormats = []string{"text", "markdown", "html"}
+
+// digestItem is one PR line of a digest.
+type digestItem struct {
+	ref, title, url, author string
+	updated                 time.Time
+}
+
+type digestSection struct {
+	title string
+	items []digestItem
+}
+
+// digest is the review queue and what changed since the previous digest.
+type digest struct {
+	title    string
+	note     string // when the previous digest was
+	sections []digestSection
+	failed   []string
+}
+
+// buildDigest sorts the open PRs of results into the sections of a digest
+// for me, comparing them with prev, the state of the last digest.
+func buildDigest(cfg *Config, results []PRResult, me *viewer, prev DigestState) (digest, map[string]DigestPR) {
+	var review, mine, added, updated, closed []digestItem
+	cur := map[string]DigestPR{}
+	total := 0
+	for _, res := range results {
+		if res.Err != nil {
+			// keep what we knew, or its PRs would all show as closed
+			prefix := strings.ToLower(repoName(res.Repo)) + "#"
+			for k, p := range prev.PRs {
+				if strings.HasPrefix(k, prefix) {
+					cur[k] = p
+				}
+			}
+			continue
+		}
+		repo := repoName(res.Repo)
+		for _, pr := range append(res.PRs, res.Bots...) {
+			key := prKey(repo, pr.Number)
+			if _, dup := cur[key]; dup || strings.ToLower(pr.State) != "open" {
+				continue
+			}
+			total++
+			cur[key] = DigestPR{Title: pr.Title, URL: pr.HTMLURL}
+			item := digestItem{fmt.Sprintf("%s#%d", repo, pr.Number), pr.Title, pr.HTMLURL, pr.User.Login, pr.UpdatedAt}
+			if reviewRequestedFrom(pr, repo, me) {
+				review = append(review, item)
+			}
+			if strings.EqualFold(pr.User.Login, me.Login) {
+				mine = append(mine, item)
+			}
+			if prev.At.IsZero() {
+				continue
+			}
+			if _, ok := prev.PRs[key]; !ok {
+				added = append(added, item)
+			} else if pr.UpdatedAt.After(prev.At) {
+				updated = append(updated, item)
+			}
+		}
+	}
+	for k, p := range prev.PRs {
+		if _, ok := cur[k]; !ok {
+			closed = append(closed, digestItem{ref: k, title: p.Title, url: p.URL})
+		}
+	}
+	slices.SortFunc(closed, func(a, b digestItem) int { return strings.Compare(a.ref, b.ref) })
+
+	now := time.Now()
+	if loc, err := timeLocation(cfg.Time.Timezone); err == nil {
+		now = now.In(loc)
+	}
+	d := digest{title: fmt.Sprintf("PR digest, %s: %d open %s", now.Format("Mon Jan 2 2006"), total, plural(total, "PR"))}
+	d.sections = append(d.sections,
+		digestSection{fmt.Sprintf("Waiting for your review (%d)", len(review)), review},
+		digestSection{fmt.Sprintf("Your open PRs (%d)", len(mine)), mine})
+	if prev.At.IsZero() {
+		d.note = "First digest, changes since the previous one show from the next on."
+	} else {
+		d.note = "Changes since the previous digest, " + fmtTime(cfg, prev.At) + "."
+		d.sections = append(d.sections,
+			digestSection{fmt.Sprintf("New (%d)", len(added)), added},
+			digestSection{fmt.Sprintf("Updated (%d)", len(updated)), updated},
+			digestSection{fmt.Sprintf("Closed or merged (%d)", len(closed)), closed})
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			d.failed = append(d.failed, repoName(res.Repo))
+		}
+	}
+	return d, cur
+}
+
+// detail is the author and last update of an item, for those still
+// open.
+func (it digestItem) detail(cfg *Config) string {
+	if it.author == "" {
+		return ""
+	}
+	return fmt.Sprintf("by %s, updated %s", it.author, fmtTime(cfg, it.updated))
+}
+
+func (d digest) writeText(cfg *Config, w io.Writer) {
+	fmt.Fprintln(w, d.title)
+	fmt.Fprintln(w, d.note)
+	for _, s := range d.sections {
+		fmt.Fprintf(w, "\n%s\n", s.title)
+		if len(s.items) == 0 {
+			fmt.Fprintln(w, "  none")
+		}
+		for _, it := range s.items {
+			line := fmt.Sprintf("  %s  %s", it.ref, it.title)
+			if detail := it.detail(cfg); detail != "" {
+				line += " (" + detail + ")"
+			}
+			fmt.Fprintln(w, line)
+			if it.url != "" {
+				fmt.Fprintf(w, "    %s\n", it.url)
+			}
+		}
+	}
+	if len(d.failed) > 0 {
+		fmt.Fprintf(w, "\nCouldn't fetch: %s\n", strings.Join(d.failed, ", "))
+	}
+}
+
+func (d digest) writeMarkdown(cfg *Config, w io.Writer) {
+	fmt.Fprintf(w, "# %s\n\n%s\n", d.title, d.note)
+	for _, s := range d.sections {
+		fmt.Fprintf(w, "\n## %s\n\n", s.title)
+		if len(s.items) == 0 {
+			fmt.Fprintln(w, "_none_")
+		}
+		for _, it := range s.items {
+			ref := it.ref
+			if it.url != "" {
+				ref = fmt.Sprintf("[%s](%s)", it.ref, it.url)
+			}
+			line := fmt.Sprintf("- %s %s", ref, strings.NewReplacer("[", `\[`, "]", `\]`).Replace(it.title))
+			if detail := it.detail(cfg); detail != "" {
+				line += " · " + detail
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	if len(d.failed) > 0 {
+		fmt.Fprintf(w, "\n_Couldn't fetch: %s_\n", strings.Join(d.failed, ", "))
+	}
+}
+
+func (d digest) writeHTML(cfg *Config, w io.Writer) {
+	esc := html.EscapeString
+	fmt.Fprintf(w, "<h1>%s</h1>\n<p>%s</p>\n", esc(d.title), esc(d.note))
+	for _, s := range d.sections {
+		fmt.Fprintf(w, "<h2>%s</h2>\n", esc(s.title))
+		if len(s.items) == 0 {
+			fmt.Fprintln(w, "<p><em>none</em></p>")
+			continue
+		}
+		fmt.Fprintln(w, "<ul>")
+		for _, it := range s.items {
+			ref := esc(it.ref)
+			if it.url != "" {
+				ref = fmt.Sprintf(`<a href="%s">%s</a>`, esc(it.url), ref)
+			}
+			line := fmt.Sprintf("<li>%s %s", ref, esc(it.title))
+			if detail := it.detail(cfg); detail != "" {
+				line += " <small>" + esc(detail) + "</small>"
+			}
+			fmt.Fprintln(w, line+"</li>")
+		}
+		fmt.Fprintln(w, "</ul>")
+	}
+	if len(d.failed) > 0 {
+		fmt.Fprintf(w, "<p><em>Couldn't fetch: %s</em></p>\n", esc(strings.Join(d.failed, ", ")))
+	}
+}
+
+const digestUsage = "usage: pr-view digest [--format text|markdown|html] [--dry-run] [list filters]"
+
+// cmdDigest prints a summary of the review queue and of what changed since
+// the previous digest, meant to be piped into mail or chat from cron. Each
+// run records what it saw for the next one unless --dry-run.
+func cmdDigest(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("digest", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: "+strings.Join(digestFormats, ", "))
+	dryRun := fs.Bool("dry-run", false, "don't record this digest, the next one compares with the previous")
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil || fs.NArg() > 0 {
+		fmt.Println(digestUsage)
+		return 2
+	}
+	if !slices.Contains(digestFormats, *format) {
+		fmt.Printf("unknown format %q (expected %s)\n", *format, strings.Join(digestFormats, ", "))
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("digest works on PRs, --issues doesn't apply")
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	me, err := gh.viewer()
+	if err != nil {
+		slog.Error("the digest needs your identity", "err", err)
+		return 1
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	st, err := states.Load()
+	if err != nil {
+		slog.Error("loading state", "err", err)
+		return 1
+	}
+	results, err := collectPRs(cfg, gh, opts)
+	if errors.Is(err, errNoRepos) {
+		fmt.Println(err)
+		return 0
+	}
+	if err != nil {
+		slog.Error("listing PRs", "err", err)
+		return 1
+	}
+
+	d, seen := buildDigest(cfg, results, me, st.Digest)
+	switch *format {
+	case "markdown":
+		d.writeMarkdown(cfg, os.Stdout)
+	case "html":
+		d.writeHTML(cfg, os.Stdout)
+	default:
+		d.writeText(cfg, os.Stdout)
+	}
+	code := 0
+	if len(d.failed) > 0 {
+		code = 1
+	}
+	if *dryRun {
+		return code
+	}
+	err = states.Update(func(st *State) error {
+		st.Digest = DigestState{At: time.Now(), PRs: seen}
+		return nil
+	})
+	if err != nil {
+		slog.Error("saving state", "err", err)
+		return 1
+	}
+	return code
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// diagnosis is the outcome of one doctor check. fix says what to do about
+// a warning or failure.
+type diagnosis struct {
+	status string // ok, warn or fail
+	name   string
+	detail string
+	fix    string
+}
+
+// tokenScopes are the classic token scopes pr-view features rely on, with
+// what breaks without them.
+var tokenScopes = []struct {
+	scope, implied, feature string
+}{
+	{"repo", "", "private repos can't be listed"},
+	{"read:org", "admin:org write:org", "--blocked-on-me can't match your teams"},
+	{"read:project", "project", "project columns and `project move` won't work"},
+	{"gist", "", "`config push` and `config pull` won't work"},
+}
+
+// cmdDoctor checks the config, token, API hosts and local files, printing a
+// fix for each problem. It exits 1 if any check failed.
+func cmdDoctor(cfg *Config, hc *http.Client, loadErr error) int {
+	var ds []diagnosis
+	add := func(d diagnosis) { ds = append(ds, d) }
+
+	add(checkConfigFile(cfg, loadErr))
+	token, d := checkToken(cfg)
+	add(d)
+	gh := &GitHubClient{http: hc, token: token, baseURL: strings.TrimRight(firstNonEmpty(cfg.GitHub.APIURL, githubAPI), "/")}
+	// talk to the hosts directly: a cached answer proves nothing
+	direct := directClient(cfg)
+	user, d := checkAPI(direct, gh)
+	add(d)
+	if user != nil {
+		add(checkScopes(user))
+		if token != "" {
+			add(checkGraphQL(direct, gh))
+		}
+		add(checkRateLimit(direct, gh))
+	}
+	if cfg.Tracing.Endpoint != "" {
+		add(checkHost(direct, "tracing", cfg.Tracing.Endpoint))
+	}
+	if cfg.Storage.Backend == "sqlite" {
+		add(checkSQLite(cfg.Storage))
+	}
+	ds = append(ds, checkStore()...)
+	ds = append(ds, checkState(cfg)...)
+
+	failed := false
+	for _, d := range ds {
+		fmt.Printf("%-5s %-10s %s\n", d.status, d.name, d.detail)
+		if d.fix != "" && d.status != "ok" {
+			fmt.Printf("%-5s %-10s fix: %s\n", "", "", d.fix)
+		}
+		failed = failed || d.status == "fail"
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// checkConfigFile re-reads the config strictly, so misspelled keys that
+// LoadConfig silently ignores show up, and validates enumerated settings.
+func checkConfigFile(cfg *Config, loadErr error) diagnosis {
+	d := diagnosis{name: "config"}
+	path, err := configPath()
+	if err != nil {
+		return diagnosis{"fail", "config", err.Error(), "make sure $HOME is set and writable"}
+	}
+	if loadErr != nil {
+		d.status, d.detail = "fail", loadErr.Error()
+		d.fix = "fix the JSON in " + path + " or move it aside to start from the defaults"
+		return d
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return diagnosis{status: "ok", name: "config", detail: path + " not found, using defaults"}
+	}
+	if err != nil {
+		return diagnosis{"fail", "config", err.Error(), "check the permissions of " + path}
+	}
+	if _, err := decodeConfigStrict(data); err != nil {
+		return diagnosis{"warn", "config", fmt.Sprintf("%s: %v", path, err), "fix it with `pr-view config edit`, or `pr-view config unset` the key; the README lists every setting"}
+	}
+	return diagnosis{status: "ok", name: "config", detail: path}
+}
+
+func checkToken(cfg *Config) (string, diagnosis) {
+	token, err := githubToken(cfg)
+	if err != nil {
+		fix := "check the auth settings in the config"
+		switch cfg.Auth.TokenSource {
+		case "op":
+			fix = "sign in with `op signin` and check auth.op_ref"
+		case "vault":
+			fix = "check VAULT_ADDR/auth.vault.addr, your Vault login and auth.vault.path"
+		}
+		return "", diagnosis{"fail", "token", err.Error(), fix}
+	}
+	if token == "" {
+		return "", diagnosis{"warn", "token", "no token: only public repos, 60 requests per hour, no GraphQL batching",
+			"export " + firstNonEmpty(cfg.Auth.EnvVar, "GITHUB_TOKEN") + "=<token>, e.g. from `gh auth token`"}
+	}
+	src := firstNonEmpty(cfg.Auth.TokenSource, "env")
+	return token, diagnosis{status: "ok", name: "token", detail: "found (" + src + ")"}
+}
+
+// doctorUser is /user plus the headers doctor cares about.
+type doctorUser struct {
+	login  string
+	scopes []string
+	// classic reports whether the token lists its scopes; fine-grained
+	// tokens and apps don't.
+	classic bool
+}
+
+// checkAPI fetches /user (or the API root without a token) to prove the
+// REST host is reachable and the token works.
+func checkAPI(direct *http.Client, gh *GitHubClient) (*doctorUser, diagnosis) {
+	d := diagnosis{name: "api"}
+	path := "/user"
+	if gh.token == "" {
+		path = "/"
+	}
+	resp, err := doctorGet(direct, gh, path)
+	if err != nil {
+		d.status, d.detail = "fail", fmt.Sprintf("%s: %v", gh.baseURL, err)
+		d.fix = connectFix(err)
+		return nil, d
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		d.status, d.detail = "fail", gh.baseURL+": token rejected (401)"
+		d.fix = "the token is invalid or expired, create a new one"
+		return nil, d
+	case resp.StatusCode == http.StatusNotFound:
+		d.status, d.detail = "fail", gh.baseURL+": not a GitHub API (404)"
+		d.fix = "for GitHub Enterprise Server set github.api_url to https://HOST/api/v3"
+		return nil, d
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		d.status, d.detail = "fail", fmt.Sprintf("%s: %s", gh.baseURL, resp.Status)
+		return nil, d
+	}
+	u := &doctorUser{}
+	if gh.token != "" {
+		var me User
+		if err := json.NewDecoder(resp.Body).Decode(&me); err != nil {
+			return nil, diagnosis{"fail", "api", "decoding /user: " + err.Error(), ""}
+		}
+		u.login = me.Login
+	}
+	if h, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		u.classic = true
+		for _, s := range strings.Split(strings.Join(h, ","), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				u.scopes = append(u.scopes, s)
+			}
+		}
+	}
+	d.status, d.detail = "ok", gh.baseURL+" reachable"
+	if u.login != "" {
+		d.detail += ", signed in as " + u.login
+	}
+	return u, d
+}
+
+func checkScopes(u *doctorUser) diagnosis {
+	d := diagnosis{name: "scopes"}
+	if u.login == "" {
+		return diagnosis{status: "ok", name: "scopes", detail: "no token"}
+	}
+	if !u.classic {
+		d.status, d.detail = "ok", "fine-grained token or app, scopes aren't listed; grant read access to pull requests, contents and members"
+		return d
+	}
+	var missing, broken []string
+	for _, s := range tokenScopes {
+		if slices.Contains(u.scopes, s.scope) || slices.ContainsFunc(strings.Fields(s.implied), func(i string) bool { return slices.Contains(u.scopes, i) }) {
+			continue
+		}
+		missing = append(missing, s.scope)
+		broken = append(broken, s.feature)
+	}
+	if len(missing) == 0 {
+		d.status, d.detail = "ok", strings.Join(u.scopes, ", ")
+		return d
+	}
+	d.status = "warn"
+	d.detail = "missing " + strings.Join(missing, ", ") + ": " + strings.Join(broken, "; ")
+	d.fix = "add the scopes to the token, e.g. `gh auth refresh -s " + strings.Join(missing, ",") + "`"
+	return d
+}
+
+func checkGraphQL(direct *http.Client, gh *GitHubClient) diagnosis {
+	d := diagnosis{name: "graphql"}
+	body, _ := json.Marshal(map[string]string{"query": "{ viewer { login } }"})
+	req, err := http.NewRequest("POST", gh.graphqlURL(), bytes.NewReader(body))
+	if err != nil {
+		return diagnosis{"fail", "graphql", err.Error(), ""}
+	}
+	req.Header.Set("Authorization", "token "+gh.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := direct.Do(req)
+	if err != nil {
+		return diagnosis{"fail", "graphql", fmt.Sprintf("%s: %v", gh.graphqlURL(), err), connectFix(err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		d.status, d.detail = "warn", fmt.Sprintf("%s: %s", gh.graphqlURL(), resp.Status)
+		d.fix = "list falls back to one REST request per repo; check github.api_url"
+		return d
+	}
+	d.status, d.detail = "ok", gh.graphqlURL()+" reachable"
+	return d
+}
+
+func checkRateLimit(direct *http.Client, gh *GitHubClient) diagnosis {
+	resp, err := doctorGet(direct, gh, "/rate_limit")
+	if err != nil {
+		return diagnosis{"fail", "ratelimit", err.Error(), connectFix(err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// GitHub Enterprise Server without rate limiting
+		return diagnosis{status: "ok", name: "ratelimit", detail: "not enabled on this host"}
+	}
+	var rl rateLimits
+	if err := json.NewDecoder(resp.Body).Decode(&rl); err != nil {
+		return diagnosis{"fail", "ratelimit", "decoding /rate_limit: " + err.Error(), ""}
+	}
+	d := diagnosis{status: "ok", name: "ratelimit"}
+	var parts []string
+	for _, name := range []string{"core", "graphql"} {
+		r, ok := rl.Resources[name]
+		if !ok {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %d/%d", name, r.Remaining, r.Limit))
+		if r.Limit > 0 && r.Remaining*10 < r.Limit {
+			d.status = "warn"
+			d.fix = fmt.Sprintf("%s quota is nearly used up, it resets at %s", name, r.resetAt().Local().Format("15:04"))
+		}
+	}
+	d.detail = strings.Join(parts, ", ")
+	return d
+}
+
+// checkHost reports whether an auxiliary endpoint answers at all.
+func checkHost(direct *http.Client, name, endpoint string) diagnosis {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return diagnosis{"fail", name, fmt.Sprintf("invalid endpoint %q", endpoint), "use a full URL like http://localhost:4318/v1/traces"}
+	}
+	resp, err := direct.Get(endpoint)
+	if err != nil {
+		return diagnosis{"fail", name, fmt.Sprintf("%s: %v", u.Host, err), connectFix(err)}
+	}
+	resp.Body.Close()
+	return diagnosis{status: "ok", name: name, detail: u.Host + " reachable"}
+}
+
+// checkSQLite looks for the sqlite3 tool the sqlite backend drives.
+func checkSQLite(cfg StorageConfig) diagnosis {
+	binary := firstNonEmpty(cfg.SQLiteBinary, "sqlite3")
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return diagnosis{"fail", "sqlite", err.Error(), "install the sqlite3 command line tool, point storage.sqlite_binary at it, or go back to the json backend"}
+	}
+	out, err := exec.Command(path, "-version").Output()
+	if err != nil {
+		return diagnosis{"fail", "sqlite", fmt.Sprintf("%s -version: %v", path, err), "check that " + path + " is a working sqlite3"}
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	return diagnosis{status: "ok", name: "sqlite", detail: fmt.Sprintf("%s %s, for the repo list; state stays in state.json", path, version)}
+}
+
+// checkStore loads the tracked entries through the configured backend and
+// looks for entries that can't be fetched.
+func checkStore() []diagnosis {
+	store, err := NewRepoStore()
+	if err != nil {
+		return []diagnosis{{"fail", "repos", err.Error(), "check the storage settings in the config"}}
+	}
+	repos, err := store.Load()
+	if err != nil {
+		return []diagnosis{{"fail", "repos", err.Error(), "restore the repo store from a backup or `config pull`, or move it aside and re-add your repos"}}
+	}
+	var ds []diagnosis
+	seen := map[string]bool{}
+	for _, r := range repos {
+		if _, err := normalizeEntry(r); err != nil {
+			ds = append(ds, diagnosis{"warn", "repos", fmt.Sprintf("%q: %v", r, err), "pr-view remove '" + r + "'"})
+			continue
+		}
+		key := strings.ToLower(r)
+		if seen[key] {
+			ds = append(ds, diagnosis{"warn", "repos", r + " is tracked twice", "pr-view remove " + r + " && pr-view add " + r})
+		}
+		seen[key] = true
+	}
+	if len(ds) == 0 {
+		ds = append(ds, diagnosis{status: "ok", name: "repos", detail: fmt.Sprintf("%d tracked", len(repos))})
+	}
+	return ds
+}
+
+// checkState loads state.json and reports entries the circuit breaker is
+// skipping, and that the cache directory is writable.
+func checkState(cfg *Config) []diagnosis {
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return []diagnosis{{"fail", "state", err.Error(), ""}}
+	}
+	st, err := states.Load()
+	if err != nil {
+		return []diagnosis{{"fail", "state", err.Error(), "move " + states.path + " aside; it only holds pins, read marks, archive and breaker state"}}
+	}
+	var ds []diagnosis
+	brk := newBreaker(cfg.Breaker)
+	for repo := range st.Breakers {
+		var open *breakerOpenError
+		if errors.As(brk.check(st, repo), &open) {
+			ds = append(ds, diagnosis{"warn", "breaker", repo + " " + open.Error(), "check access to it, or remove it if it's gone; it's retried automatically after the cooldown"})
+		}
+	}
+	slices.SortFunc(ds, func(a, b diagnosis) int { return strings.Compare(a.detail, b.detail) })
+	ds = append([]diagnosis{{status: "ok", name: "state", detail: states.path}}, ds...)
+	if cfg.Cache.Enabled {
+		dir, err := cacheDir()
+		if err == nil {
+			var f *os.File
+			if f, err = os.CreateTemp(dir, "doctor"); err == nil {
+				f.Close()
+				os.Remove(f.Name())
+			}
+		}
+		if err != nil {
+			ds = append(ds, diagnosis{"warn", "cache", err.Error(), "fix the permissions, or turn the cache off with: pr-view config unset cache.enabled"})
+		} else {
+			ds = append(ds, diagnosis{status: "ok", name: "cache", detail: dir})
+		}
+	}
+	return ds
+}
+
+// directClient skips the cache and retries, for checks that need the
+// host's own answer and headers.
+func directClient(cfg *Config) *http.Client {
+	timeout := time.Duration(cfg.Network.Timeout)
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &http.Client{Transport: baseTransport, Timeout: timeout}
+}
+
+func doctorGet(direct *http.Client, gh *GitHubClient, path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", gh.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "pr-view")
+	if gh.token != "" {
+		req.Header.Set("Authorization", "token "+gh.token)
+	}
+	return direct.Do(req)
+}
+
+// connectFix suggests a fix for a failed connection.
+func connectFix(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "certificate"):
+		return "if a proxy intercepts TLS, add its CA with network.ca_bundle"
+	case strings.Contains(msg, "proxyconnect"):
+		return "check network.proxy or HTTPS_PROXY"
+	case strings.Contains(msg, "no such host"):
+		return "check github.api_url and your DNS"
+	case strings.Contains(msg, "Client.Timeout"), strings.Contains(msg, "deadline"):
+		return "the host is slow or unreachable; check your network or raise network.timeout"
+	}
+	return "check your network, proxy and github.api_url"
+}
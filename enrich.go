@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// enrichConcurrency bounds the per-PR requests in flight.
+const enrichConcurrency = 8
+
+// enricher fills in PR fields that the list endpoints don't return. It gets
+// the owner/repo the PR belongs to.
+type enricher struct {
+	name string
+	run  func(c *GitHubClient, repo string, pr *PullRequest) error
+}
+
+// enricherOrder is the order enrichers run in for each PR; enrichDetail
+// replaces the whole PR and so has to come first.
+var enricherOrder = []*enricher{enrichDetail, enrichReviews, enrichCI, enrichFiles, enrichOwners, enrichRequiredChecks, enrichBehind, enrichProjects, enrichTimeline, enrichDeployments, enrichMergeQueue, enrichCommits, enrichTemplateCheck, enrichNotifications}
+
+// enrichDetail fetches the single-PR endpoint, which adds mergeability.
+var enrichDetail = &enricher{name: "detail", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	var full PullRequest
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/pulls/%d", repo, pr.Number), nil, &full); err != nil {
+		return err
+	}
+	*pr = full
+	return nil
+}}
+
+// review decisions computed by enrichReviews, named like GraphQL's
+// reviewDecision.
+const (
+	reviewApproved         = "approved"
+	reviewChangesRequested = "changes_requested"
+	reviewRequired         = "review_required"
+)
+
+type review struct {
+	User        User   `json:"user"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+}
+
+// enrichReviews derives the review decision from each reviewer's latest
+// approving or change-requesting review. With a token, GraphQL's
+// reviewDecision takes its place where the base branch requires reviews,
+// as it also counts the required approvals and code owners.
+var enrichReviews = &enricher{name: "reviews", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	var reviews []review
+	next := fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100", repo, pr.Number)
+	for next != "" {
+		var page []review
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			return err
+		}
+		reviews = append(reviews, page...)
+	}
+	pr.Reviews = reviews
+	pr.ReviewDecision = reviewDecision(reviews)
+	if c.token == "" {
+		return nil
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	const query = `query($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) { pullRequest(number: $number) { reviewDecision } }
+}`
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewDecision string `json:"reviewDecision"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	if err := c.graphql(query, map[string]any{"owner": owner, "name": name, "number": pr.Number}, &data); err != nil {
+		return err
+	}
+	// null when the branch doesn't require reviews
+	if d := data.Repository.PullRequest.ReviewDecision; d != "" {
+		pr.ReviewDecision = strings.ToLower(d)
+	}
+	return nil
+}}
+
+func reviewDecision(reviews []review) string {
+	latest := map[string]string{}
+	for _, r := range reviews {
+		// comments and pending reviews don't change a reviewer's verdict
+		if r.State == "APPROVED" || r.State == "CHANGES_REQUESTED" || r.State == "DISMISSED" {
+			latest[r.User.Login] = r.State
+		}
+	}
+	decision := reviewRequired
+	for _, s := range latest {
+		switch s {
+		case "CHANGES_REQUESTED":
+			return reviewChangesRequested
+		case "APPROVED":
+			decision = reviewApproved
+		}
+	}
+	return decision
+}
+
+// enrichCI sets the aggregated CI state of the PR head.
+var enrichCI = &enricher{name: "ci", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	state, err := ciState(c, repo, pr.Head.SHA)
+	if err != nil {
+		return err
+	}
+	pr.CIState = state
+	return nil
+}}
+
+// enrichFiles sets the paths of the files the PR changes. GitHub lists at
+// most 3000 files per PR.
+var enrichFiles = &enricher{name: "files", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	pr.Files = nil
+	next := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=100", repo, pr.Number)
+	for next != "" {
+		var page []struct {
+			Filename string `json:"filename"`
+		}
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			return err
+		}
+		for _, f := range page {
+			pr.Files = append(pr.Files, f.Filename)
+		}
+	}
+	return nil
+}}
+
+// enrichBehind sets how many commits the base branch has that the PR head
+// doesn't, using the compare API against the current base branch.
+var enrichBehind = &enricher{name: "compare", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	var cmp struct {
+		AheadBy  int `json:"ahead_by"`
+		BehindBy int `json:"behind_by"`
+	}
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/compare/%s...%s", repo, pr.Base.Ref, pr.Head.SHA), nil, &cmp); err != nil {
+		return err
+	}
+	pr.BehindBy = cmp.BehindBy
+	return nil
+}}
+
+// onceCache computes each key's value once per process, for data shared by
+// all PRs of a repo such as CODEOWNERS or branch protection.
+type onceCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*onceEntry[T]
+}
+
+type onceEntry[T any] struct {
+	once sync.Once
+	val  T
+	err  error
+}
+
+func (oc *onceCache[T]) get(key string, fn func() (T, error)) (T, error) {
+	oc.mu.Lock()
+	if oc.entries == nil {
+		oc.entries = map[string]*onceEntry[T]{}
+	}
+	e, ok := oc.entries[key]
+	if !ok {
+		e = &onceEntry[T]{}
+		oc.entries[key] = e
+	}
+	oc.mu.Unlock()
+	e.once.Do(func() { e.val, e.err = fn() })
+	return e.val, e.err
+}
+
+// enrich runs the needed enrichers on every PR. A PR that can't be enriched
+// fails its whole result, since filters can't be evaluated without the data.
+func enrich(c *GitHubClient, results []PRResult, needs map[*enricher]bool) {
+	var run []*enricher
+	for _, e := range enricherOrder {
+		if needs[e] {
+			run = append(run, e)
+		}
+	}
+	if len(run) == 0 {
+		return
+	}
+	sem := make(chan struct{}, enrichConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := range results {
+		res := &results[i]
+		if res.Err != nil {
+			continue
+		}
+		repo := repoName(res.Repo)
+		for j := range res.PRs {
+			wg.Add(1)
+			go func(pr *PullRequest) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				for _, e := range run {
+					if err := e.run(c, repo, pr); err != nil {
+						mu.Lock()
+						if res.Err == nil {
+							res.Err = fmt.Errorf("fetching %s for #%d: %w", e.name, pr.Number, err)
+						}
+						mu.Unlock()
+						return
+					}
+				}
+			}(&res.PRs[j])
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const bundleVersion = 1
+
+// bundle is everything pr-view keeps locally, in one file written by
+// `export`. Encrypted files go in decrypted, without the key.
+type bundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// PRView is the version of pr-view that wrote it.
+	PRView string `json:"pr_view"`
+	// Config is the config file, flag overrides left out, and the secrets
+	// withoutSecrets strips too unless export --include-secrets.
+	Config    json.RawMessage `json:"config,omitempty"`
+	Repos     []string        `json:"repos"`
+	State     *State          `json:"state"`
+	Snapshots []*Snapshot     `json:"snapshots,omitempty"`
+	// Cache holds the cached API responses by file name.
+	Cache map[string]cacheEntry `json:"cache,omitempty"`
+}
+
+// collectBundle gathers the local config, repos, state and snapshots, and
+// with withCache the HTTP cache. The config's secrets are left out unless
+// withSecrets is set.
+func collectBundle(cfg *Config, withCache, withSecrets bool) (*bundle, error) {
+	v, _, _ := buildInfo()
+	b := &bundle{Version: bundleVersion, ExportedAt: time.Now(), PRView: v}
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("%s isn't valid JSON", path)
+		}
+		if !withSecrets {
+			var c Config
+			if err := json.Unmarshal(data, &c); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if data, err = json.Marshal(withoutSecrets(&c)); err != nil {
+				return nil, err
+			}
+		}
+		b.Config = data
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		return nil, err
+	}
+	if b.Repos, err = store.Load(); err != nil {
+		return nil, fmt.Errorf("loading repos: %w", err)
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if b.State, err = states.Load(); err != nil {
+		return nil, fmt.Errorf("loading state: %w", err)
+	}
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	snaps, _ := filepath.Glob(filepath.Join(dir, "snapshots", "*.json"))
+	for _, p := range snaps {
+		s, err := loadSnapshot(cfg, strings.TrimSuffix(filepath.Base(p), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("loading snapshot: %w", err)
+		}
+		b.Snapshots = append(b.Snapshots, s)
+	}
+	if !withCache {
+		return b, nil
+	}
+	cdir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, _ := filepath.Glob(filepath.Join(cdir, "*.json"))
+	t := &cacheTransport{dir: cdir, security: cfg.Security}
+	b.Cache = map[string]cacheEntry{}
+	for _, p := range entries {
+		// unreadable entries are refetched anyway, leave them out
+		if e, ok := t.load(p); ok {
+			b.Cache[filepath.Base(p)] = e
+		}
+	}
+	return b, nil
+}
+
+const exportUsage = "usage: pr-view export [--out state.json] [--no-cache] [--include-secrets]"
+
+// cmdExport writes the config, tracked repos, local state, snapshots and
+// HTTP cache into one JSON file, for backups, moving to another machine
+// with `import`, or attaching to a bug report.
+func cmdExport(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("out", "-", "file to write, - for stdout")
+	noCache := fs.Bool("no-cache", false, "leave out the cached API responses")
+	withSecrets := fs.Bool("include-secrets", false, "keep webhook.secret, auth.vault.secret_id and tracing.headers in the config, for a backup")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		fmt.Println(exportUsage)
+		return 2
+	}
+	b, err := collectBundle(cfg, !*noCache, *withSecrets)
+	if err != nil {
+		slog.Error("exporting", "err", err)
+		return 1
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		slog.Error("encoding export", "err", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	// PR content and maybe credentials in the config, so only for the user
+	if err := writeFileAtomic(*out, data, 0o600); err != nil {
+		slog.Error("writing export", "err", err)
+		return 1
+	}
+	fmt.Printf("exported to %s: config, state, tracked: %d, snapshots: %d, cached responses: %d\n", *out, len(b.Repos), len(b.Snapshots), len(b.Cache))
+	if cfg.Security.EncryptCache {
+		fmt.Println("the cache and state are encrypted locally, but not in the export")
+	}
+	return 0
+}
+
+// mergeJSON returns base with the keys of extra it lacks, objects merged
+// recursively; base wins where both set a key.
+func mergeJSON(base, extra any) any {
+	b, ok1 := base.(map[string]any)
+	e, ok2 := extra.(map[string]any)
+	if !ok1 || !ok2 {
+		return base
+	}
+	for k, v := range e {
+		if old, ok := b[k]; ok {
+			b[k] = mergeJSON(old, v)
+		} else {
+			b[k] = v
+		}
+	}
+	return b
+}
+
+// importedConfig returns the config to save: the bundle's, or with merge
+// the local one with the bundle's settings it lacks. Local secrets the
+// bundle left out are kept. It is validated like `config edit` does.
+func importedConfig(b *bundle, merge bool) (*Config, error) {
+	data := []byte(b.Config)
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+	if merge {
+		path, err := configPath()
+		if err != nil {
+			return nil, err
+		}
+		local, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		var base, extra any = map[string]any{}, nil
+		if len(local) > 0 {
+			if err := json.Unmarshal(local, &base); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if err := json.Unmarshal(data, &extra); err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(mergeJSON(base, extra)); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := decodeConfigStrict(data)
+	if err != nil {
+		return nil, err
+	}
+	local, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	keepSecrets(cfg, local)
+	return cfg, nil
+}
+
+// mergeImportedState adds the pins, read markers, archived and stale-labeled PRs of
+// in to st, keeping st's where both have one. The rest is about this
+// machine's last runs and stays as is.
+func mergeImportedState(st, in *State) (added int) {
+	for _, p := range in.Pins {
+		if !containsFold(st.Pins, p) {
+			st.Pins = append(st.Pins, p)
+			added++
+		}
+	}
+	if st.Seen == nil {
+		st.Seen = map[string]time.Time{}
+	}
+	for k, t := range in.Seen {
+		// the later read marker wins
+		if mine, ok := st.Seen[k]; !ok || t.After(mine) {
+			st.Seen[k] = t
+			added++
+		}
+	}
+	for _, m := range []struct {
+		dst *map[string]time.Time
+		src map[string]time.Time
+	}{
+		{&st.Archived, in.Archived}, {&st.StaleLabeled, in.StaleLabeled},
+	} {
+		for k, t := range m.src {
+			if *m.dst == nil {
+				*m.dst = map[string]time.Time{}
+			}
+			if _, ok := (*m.dst)[k]; !ok {
+				(*m.dst)[k] = t
+				added++
+			}
+		}
+	}
+	return added
+}
+
+const importUsage = "usage: pr-view import <file> [--replace] [--no-cache] [--dry-run]"
+
+// cmdImport restores a file written by `export`. By default it merges:
+// local settings, repos, state and snapshots win, and only what's missing
+// is added. --replace swaps them for the file's. Everything is validated
+// before anything is written.
+func cmdImport(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	replace := fs.Bool("replace", false, "replace the local config, repos, state and same-named snapshots instead of merging")
+	noCache := fs.Bool("no-cache", false, "don't restore the cached API responses")
+	dryRun := fs.Bool("dry-run", false, "check the file and show what would change without writing anything")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 1 {
+		fmt.Println(importUsage)
+		return 2
+	}
+	var data []byte
+	if positional[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(positional[0])
+	}
+	if err != nil {
+		slog.Error("reading export", "err", err)
+		return 1
+	}
+	b := &bundle{}
+	if err := json.Unmarshal(data, b); err != nil {
+		fmt.Printf("%s isn't a pr-view export: %v\n", positional[0], err)
+		return 1
+	}
+	if b.Version < 1 || b.Version > bundleVersion || b.State == nil {
+		fmt.Printf("%s isn't a pr-view export this version can read (version %d)\n", positional[0], b.Version)
+		return 1
+	}
+
+	// check everything first, so a bad file changes nothing
+	newCfg, err := importedConfig(b, !*replace)
+	if err != nil {
+		fmt.Printf("config in %s: %v\n", positional[0], err)
+		return 1
+	}
+	var repos []string
+	for _, r := range b.Repos {
+		n, err := normalizeEntry(r)
+		if err != nil {
+			fmt.Printf("repo %q in %s: %v\n", r, positional[0], err)
+			return 1
+		}
+		repos = append(repos, n)
+	}
+	for _, s := range b.Snapshots {
+		if _, err := snapshotPath(s.Name); err != nil {
+			fmt.Printf("snapshot in %s: %v\n", positional[0], err)
+			return 1
+		}
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		slog.Error("initializing store", "err", err)
+		return 1
+	}
+	local, err := store.Load()
+	if err != nil {
+		slog.Error("loading repos", "err", err)
+		return 1
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	st, err := states.Load()
+	if err != nil {
+		slog.Error("loading state", "err", err)
+		return 1
+	}
+
+	mode := "merged, local settings win"
+	newRepos, addedRepos := repos, len(repos)
+	stateChanges := 0
+	if *replace {
+		mode = "replaced"
+	} else {
+		newRepos, addedRepos = local, 0
+		for _, r := range repos {
+			if !containsFold(newRepos, r) {
+				newRepos = append(newRepos, r)
+				addedRepos++
+			}
+		}
+		stateChanges = mergeImportedState(st, b.State)
+	}
+	var snaps []*Snapshot
+	for _, s := range b.Snapshots {
+		if _, err := loadSnapshot(cfg, s.Name); err == nil && !*replace {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	cdir, err := cacheDir()
+	if err != nil {
+		slog.Error("locating cache", "err", err)
+		return 1
+	}
+	cache := map[string]cacheEntry{}
+	for name, e := range b.Cache {
+		if *noCache || filepath.Base(name) != name || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(cdir, name)); err == nil && !*replace {
+			continue
+		}
+		cache[name] = e
+	}
+
+	verb := "imported"
+	if *dryRun {
+		verb = "would import"
+	}
+	fmt.Printf("%s %s, written by pr-view %s %s\n", verb, positional[0], b.PRView, fmtTime(cfg, b.ExportedAt))
+	fmt.Printf("  config: %s\n", mode)
+	fmt.Printf("  tracked: %d new, %d in all\n", addedRepos, len(newRepos))
+	if *replace {
+		fmt.Println("  state: replaced")
+	} else {
+		fmt.Printf("  state: %d pins, read markers and archived PRs added\n", stateChanges)
+	}
+	fmt.Printf("  snapshots: %d of %d\n", len(snaps), len(b.Snapshots))
+	fmt.Printf("  cached responses: %d\n", len(cache))
+	if *dryRun {
+		return 0
+	}
+
+	// the config first, so the repos land in the backend it selects
+	if err := SaveConfig(newCfg); err != nil {
+		slog.Error("saving config", "err", err)
+		return 1
+	}
+	if store, err = NewRepoStore(); err == nil {
+		err = store.Save(newRepos)
+	}
+	if err != nil {
+		slog.Error("saving repos", "err", err)
+		return 1
+	}
+	err = states.Update(func(cur *State) error {
+		if *replace {
+			*cur = *b.State
+		} else {
+			mergeImportedState(cur, b.State)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("saving state", "err", err)
+		return 1
+	}
+	for _, s := range snaps {
+		if err := saveSnapshot(newCfg, s); err != nil {
+			slog.Error("saving snapshot", "name", s.Name, "err", err)
+			return 1
+		}
+	}
+	t := &cacheTransport{dir: cdir, security: newCfg.Security}
+	for name, e := range cache {
+		// a missing cache entry only costs a full request later
+		if err := t.store(filepath.Join(cdir, name), e); err != nil {
+			slog.Warn("restoring cached response", "err", err)
+		}
+	}
+	return 0
+}
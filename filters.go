@@ -0,0 +1,195 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// prFilter keeps the PRs it returns true for. needs lists the enrichers
+// that provide the fields it looks at, and prepare, when set, runs once
+// before filtering so lookups it depends on can fail the command early.
+// prOnly marks filters on PR fields that issues don't have, for filters
+// without needs.
+type prFilter struct {
+	name    string
+	needs   []*enricher
+	prepare func(c *GitHubClient) error
+	prOnly  bool
+	keep    func(c *GitHubClient, repo string, pr PullRequest) bool
+}
+
+// readyFilter keeps PRs that can be merged right now: approved, green CI,
+// no conflicts and not a draft.
+var readyFilter = prFilter{
+	name:  "ready",
+	needs: []*enricher{enrichDetail, enrichReviews, enrichCI},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return !pr.Draft &&
+			pr.ReviewDecision == reviewApproved &&
+			pr.CIState == ciSuccess &&
+			pr.Mergeable != nil && *pr.Mergeable
+	},
+}
+
+// needsRebaseFilter keeps PRs whose base branch moved on since they were
+// last updated.
+var needsRebaseFilter = prFilter{
+	name:  "needs-rebase",
+	needs: []*enricher{enrichBehind},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return pr.BehindBy > 0
+	},
+}
+
+// autoMergeFilter keeps PRs with auto-merge enabled, the ones queued to
+// land once their checks and reviews pass.
+var autoMergeFilter = prFilter{
+	name:   "auto-merge",
+	prOnly: true,
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return pr.AutoMerge != nil
+	},
+}
+
+// externalFilter keeps PRs from forks, usually community contributions.
+var externalFilter = prFilter{
+	name:   "external-only",
+	prOnly: true,
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return pr.headFork() != ""
+	},
+}
+
+// internalFilter keeps PRs from branches of the repo itself.
+var internalFilter = prFilter{
+	name:   "internal-only",
+	prOnly: true,
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return pr.headFork() == ""
+	},
+}
+
+// missingSignOffFilter keeps PRs with commits that lack their author's
+// Signed-off-by, which a DCO check would fail.
+var missingSignOffFilter = prFilter{
+	name:  "missing-signoff",
+	needs: []*enricher{enrichCommits},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return len(missingSignOff(pr.Commits)) > 0
+	},
+}
+
+// unverifiedFilter keeps PRs with commits whose signature GitHub couldn't
+// verify, for repos that require signed commits.
+var unverifiedFilter = prFilter{
+	name:  "unverified-only",
+	needs: []*enricher{enrichCommits},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return len(unverified(pr.Commits)) > 0
+	},
+}
+
+// headFilter keeps PRs whose head branch matches one of the glob patterns,
+// e.g. release/* or renovate/*.
+func headFilter(patterns []string) prFilter {
+	return prFilter{
+		name:   "head",
+		prOnly: true,
+		keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+			for _, p := range patterns {
+				if ok, _ := path.Match(p, pr.Head.Ref); ok {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// touchesFilter keeps PRs changing a file that matches one of the patterns,
+// which follow CODEOWNERS (gitignore) syntax: services/payments/** or *.proto.
+func touchesFilter(patterns []string) prFilter {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		res = append(res, codeownersPattern(p))
+	}
+	return prFilter{
+		name:  "touches",
+		needs: []*enricher{enrichFiles},
+		keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+			for _, f := range pr.Files {
+				for _, re := range res {
+					if re.MatchString(f) {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+// blockedOnMeFilter keeps other people's PRs that touch files you or one of
+// your teams own in CODEOWNERS, and that you haven't reviewed yet.
+var blockedOnMeFilter = prFilter{
+	name:  "blocked-on-me",
+	needs: []*enricher{enrichReviews, enrichFiles, enrichOwners},
+	prepare: func(c *GitHubClient) error {
+		_, err := c.viewer()
+		return err
+	},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		me, _ := c.viewer()
+		if strings.EqualFold(pr.User.Login, me.Login) {
+			return false
+		}
+		for _, r := range pr.Reviews {
+			if strings.EqualFold(r.User.Login, me.Login) && r.State != "PENDING" {
+				return false
+			}
+		}
+		handles := me.handles()
+		for _, o := range pr.Owners {
+			if slices.Contains(handles, strings.ToLower(o)) {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// applyFilters drops PRs rejected by any filter. Results left without PRs
+// are dropped too, so the listing only shows repos with matches; failed
+// results are kept so errors stay visible.
+func applyFilters(c *GitHubClient, results []PRResult, filters []prFilter) []PRResult {
+	if len(filters) == 0 {
+		return results
+	}
+	var kept []PRResult
+	for _, res := range results {
+		if res.Err != nil {
+			kept = append(kept, res)
+			continue
+		}
+		var prs []PullRequest
+		for _, pr := range res.PRs {
+			ok := true
+			for _, f := range filters {
+				if !f.keep(c, repoName(res.Repo), pr) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				prs = append(prs, pr)
+			}
+		}
+		if len(prs) > 0 {
+			res.PRs = prs
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const githubAPI = "https://api.github.com"
+
+// baseTransport carries the proxy and TLS settings. Token sources that talk
+// to other services use it directly so their secrets skip the cache.
+var baseTransport http.RoundTripper = http.DefaultTransport
+
+// newHTTPClient builds the single http.Client shared by every GitHub
+// request: the tuned base transport, retries, the secondary rate limit
+// throttle, the response cache, and deduplication on top.
+func newHTTPClient(cfg *Config) (*http.Client, error) {
+	base, err := newBaseTransport(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	// all requests go to one host, so keep enough idle connections around
+	// for the concurrent per-repo fetches to reuse them. Compression stays
+	// on: the transport asks for gzip and decodes it transparently, which
+	// an explicit Accept-Encoding header would disable.
+	base.ForceAttemptHTTP2 = true
+	base.MaxIdleConns = 100
+	base.MaxIdleConnsPerHost = 32
+	base.IdleConnTimeout = 90 * time.Second
+	base.DisableCompression = false
+	baseTransport = base
+	var rt http.RoundTripper = &traceTransport{next: newThrottleTransport(newRetryTransport(base, cfg.Network), cfg.Network)}
+	if cfg.Cache.Enabled {
+		dir, err := cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		rt = &cacheTransport{next: rt, dir: dir, security: cfg.Security, fresh: warmFreshness()}
+	}
+	return &http.Client{Transport: &logTransport{next: &dedupTransport{next: rt}}}, nil
+}
+
+// GitHubClient is injected into everything that talks to the GitHub API. It
+// pairs the shared http.Client with the token and API base URL.
+type GitHubClient struct {
+	http    *http.Client
+	token   string
+	baseURL string
+	// ctx carries the current trace span into requests, see withContext.
+	ctx context.Context
+
+	me *viewerState
+	// pool holds the tokens reads rotate over, nil with a single token.
+	pool *tokenPool
+	// app mints a token per account when authenticated as a GitHub App.
+	app *appTokenSource
+	// notes is the current run's listing of your notifications, see
+	// notifyReasons.
+	notes *notificationsState
+}
+
+// newGitHubClient resolves the token from the configured source and returns
+// a client for the configured API endpoint.
+func newGitHubClient(cfg *Config, hc *http.Client) (*GitHubClient, error) {
+	token, src, err := githubTokenSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	app, _ := src.(*appTokenSource)
+	var pool *tokenPool
+	if app == nil {
+		if pool, err = newTokenPool(cfg.Auth, token); err != nil {
+			return nil, err
+		}
+	}
+	if token == "" && pool != nil {
+		// the extra tokens alone still make an authenticated client
+		token = pool.tokens[0].value
+	}
+	baseURL := strings.TrimRight(cfg.GitHub.APIURL, "/")
+	if baseURL == "" {
+		baseURL = githubAPI
+	}
+	return &GitHubClient{http: hc, token: token, baseURL: baseURL, ctx: context.Background(), me: &viewerState{}, pool: pool, app: app}, nil
+}
+
+// withContext returns a client whose requests run under ctx, sharing
+// everything else with c.
+func (c *GitHubClient) withContext(ctx context.Context) *GitHubClient {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
+
+// withToken returns a client that sends every request with token, sharing
+// everything else with c.
+func (c *GitHubClient) withToken(token string) *GitHubClient {
+	cp := *c
+	cp.token, cp.pool, cp.app = token, nil, nil
+	return &cp
+}
+
+// apiError is a non-2xx response from the GitHub API.
+type apiError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	// SSOOrg is set when the org enforces SAML SSO and the token isn't
+	// authorized for it; SSOURL is where to authorize it, when GitHub says.
+	SSOOrg, SSOURL string
+}
+
+func (e *apiError) Error() string {
+	if e.SSOOrg != "" {
+		where := "under Settings > Developer settings > Personal access tokens > Configure SSO"
+		if e.SSOURL != "" {
+			where = "at " + e.SSOURL
+		}
+		return fmt.Sprintf("the token isn't authorized for the %s org's SAML SSO: authorize it %s", e.SSOOrg, where)
+	}
+	return fmt.Sprintf("github API error: %s: %s", e.Status, e.Body)
+}
+
+// ssoURL matches the org in the authorization URL of an X-GitHub-SSO
+// header, required; url=https://github.com/orgs/ORG/sso?authorization_request=...
+var ssoURL = regexp.MustCompile(`url=(\S*/orgs/([^/]+)/sso\S*)`)
+
+func newAPIError(resp *http.Response) *apiError {
+	b, _ := io.ReadAll(resp.Body)
+	e := &apiError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(b))}
+	if m := ssoURL.FindStringSubmatch(resp.Header.Get("X-GitHub-SSO")); m != nil {
+		e.SSOURL, e.SSOOrg = m[1], m[2]
+	}
+	return e
+}
+
+// url resolves an API path against the base URL. Absolute URLs, as found in
+// API responses, are used unchanged.
+func (c *GitHubClient) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.baseURL + path
+}
+
+// do sends a JSON request to the GitHub API and decodes the response into
+// out (when non-nil). in, when non-nil, is encoded as the request body.
+func (c *GitHubClient) do(method, path string, in, out any) error {
+	_, err := c.doPage(method, path, in, out)
+	return err
+}
+
+// linkNext matches the rel="next" entry of a Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// doPage is like do but also returns the URL of the next page of a
+// paginated listing, or "" on the last page.
+func (c *GitHubClient) doPage(method, path string, in, out any) (string, error) {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return "", err
+		}
+	}
+	token, resource := c.token, rateLimitResource(path)
+	var from *poolToken
+	if c.pool != nil && isRead(method, in) && !asViewer(strings.TrimPrefix(c.url(path), c.baseURL), in) {
+		if from = c.pool.pick(resource, nil); from != nil {
+			token = from.value
+		}
+	}
+	if c.app != nil {
+		t, err := c.app.tokenFor(requestOwner(path, in))
+		if err != nil {
+			return "", err
+		}
+		token = t
+	}
+	for {
+		req, err := http.NewRequestWithContext(c.ctx, method, c.url(path), bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("User-Agent", "pr-view")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return "", err
+		}
+		if from == nil {
+			return c.finish(resp, out)
+		}
+		c.pool.observe(from, resource, resp.Header)
+		if !rateLimited(resp) {
+			return c.finish(resp, out)
+		}
+		// refused requests did nothing, so another token can send it again
+		next := c.pool.pick(resource, from)
+		if next == nil {
+			return c.finish(resp, out)
+		}
+		resp.Body.Close()
+		logTrace("token out of quota, switching", "resource", resource, "from", from.label, "to", next.label)
+		from, token = next, next.value
+	}
+}
+
+// finish decodes resp like doPage documents and closes it.
+func (c *GitHubClient) finish(resp *http.Response, out any) (string, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", newAPIError(resp)
+	}
+	var next string
+	if m := linkNext.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		next = m[1]
+	}
+	if out == nil {
+		return next, nil
+	}
+	// a *[]byte takes the body as is, for endpoints that don't return JSON
+	if raw, ok := out.(*[]byte); ok {
+		var err error
+		*raw, err = io.ReadAll(resp.Body)
+		return next, err
+	}
+	return next, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// viewer is the authenticated user and the teams they belong to.
+type viewer struct {
+	Login string
+	// Teams are in CODEOWNERS form, @org/team-slug.
+	Teams []string
+}
+
+// handles returns the @-names that refer to the viewer, lowercased.
+func (v *viewer) handles() []string {
+	hs := []string{"@" + strings.ToLower(v.Login)}
+	for _, t := range v.Teams {
+		hs = append(hs, strings.ToLower(t))
+	}
+	return hs
+}
+
+// viewerState is shared by the copies withContext makes of a client.
+type viewerState struct {
+	once sync.Once
+	v    *viewer
+	err  error
+}
+
+// viewer returns the authenticated user, fetched once per client.
+func (c *GitHubClient) viewer() (*viewer, error) {
+	c.me.once.Do(func() {
+		if c.token == "" {
+			c.me.err = fmt.Errorf("a token is required to know who you are")
+			return
+		}
+		if c.app != nil {
+			// an app has no teams, and /user is for user tokens only
+			login, err := c.app.login()
+			if err != nil {
+				c.me.err = err
+				return
+			}
+			c.me.v = &viewer{Login: login}
+			return
+		}
+		// "you" is the primary token's account, whichever token reads
+		primary := c.withToken(c.token)
+		var u User
+		if err := primary.do("GET", "/user", nil, &u); err != nil {
+			c.me.err = err
+			return
+		}
+		v := &viewer{Login: u.Login}
+		var teams []struct {
+			Slug         string `json:"slug"`
+			Organization struct {
+				Login string `json:"login"`
+			} `json:"organization"`
+		}
+		if err := primary.do("GET", "/user/teams?per_page=100", nil, &teams); err != nil {
+			c.me.err = fmt.Errorf("listing your teams (needs the read:org scope): %w", err)
+			return
+		}
+		for _, t := range teams {
+			v.Teams = append(v.Teams, "@"+t.Organization.Login+"/"+t.Slug)
+		}
+		c.me.v = v
+	})
+	return c.me.v, c.me.err
+}
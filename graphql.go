@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// graphqlURL returns the GraphQL endpoint for the configured API root.
+// GitHub Enterprise Server serves REST under /api/v3 and GraphQL under
+// /api/graphql.
+func (c *GitHubClient) graphqlURL() string {
+	if base, ok := strings.CutSuffix(c.baseURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return c.baseURL + "/graphql"
+}
+
+// varFlag collects repeated name=value flags.
+type varFlag struct {
+	vars map[string]any
+	json bool
+}
+
+func (v *varFlag) String() string { return "" }
+
+func (v *varFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	if !v.json {
+		v.vars[name] = value
+		return nil
+	}
+	var typed any
+	if err := json.Unmarshal([]byte(value), &typed); err != nil {
+		return fmt.Errorf("%s: invalid JSON value: %w", name, err)
+	}
+	v.vars[name] = typed
+	return nil
+}
+
+func cmdAPI(gh *GitHubClient, args []string) int {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	queryFile := fs.String("graphql", "", "file with the GraphQL query to run (- for stdin)")
+	vars := map[string]any{}
+	fs.Var(&varFlag{vars: vars}, "var", "string variable as name=value (repeatable)")
+	fs.Var(&varFlag{vars: vars, json: true}, "var-json", "JSON-typed variable as name=value, e.g. first=10 (repeatable)")
+	query := fs.String("query", "", "filter the response with a jq expression")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var q jqFilter
+	if *query != "" {
+		var err error
+		if q, err = parseQuery(*query); err != nil {
+			fmt.Println(err)
+			return 2
+		}
+	}
+	if *queryFile == "" {
+		fmt.Println("usage: pr-view api --graphql query.graphql [--var name=value ...] [--query expr]")
+		return 2
+	}
+	var body []byte
+	var err error
+	if *queryFile == "-" {
+		body, err = io.ReadAll(os.Stdin)
+	} else {
+		body, err = os.ReadFile(*queryFile)
+	}
+	if err != nil {
+		slog.Error("reading query", "err", err)
+		return 1
+	}
+	// print the whole response, errors included, like the API returns it
+	var resp map[string]any
+	if err := gh.do("POST", gh.graphqlURL(), map[string]any{"query": string(body), "variables": vars}, &resp); err != nil {
+		slog.Error("running query", "err", err)
+		return 1
+	}
+	if errs, failed := resp["errors"]; failed && q != nil {
+		// GraphQL errors usually leave nothing for the query to select
+		data, _ := json.Marshal(errs)
+		slog.Error("running query", "errors", string(data))
+		return 1
+	}
+	if q != nil {
+		outs, err := runQuery(q, resp)
+		if err == nil {
+			err = printQueryResults(outs)
+		}
+		if err != nil {
+			slog.Error("applying --query", "err", err)
+			return 1
+		}
+		return 0
+	}
+	out, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		slog.Error("encoding response", "err", err)
+		return 1
+	}
+	fmt.Println(string(out))
+	if _, failed := resp["errors"]; failed {
+		return 1
+	}
+	return 0
+}
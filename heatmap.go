@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// heatmapShades are the cells of the heatmap from no activity to the
+// busiest day.
+var heatmapShades = []string{"·", "░", "▒", "▓", "█"}
+
+// prActivity is when a PR was opened and, if it was, merged.
+type prActivity struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	MergedAt  *time.Time `json:"mergedAt"`
+}
+
+// repoActivity returns the PRs of repo updated since since, which covers
+// every PR opened or merged since then. The search API stops at 1000
+// results per query.
+func repoActivity(c *GitHubClient, repo string, since time.Time) ([]prActivity, error) {
+	const query = `query($q: String!, $after: String) {
+	search(query: $q, type: ISSUE, first: 100, after: $after) {
+		pageInfo { hasNextPage endCursor }
+		nodes { ... on PullRequest { createdAt mergedAt } }
+	}
+}`
+	vars := map[string]any{"q": fmt.Sprintf("repo:%s is:pr updated:>=%s", repo, since.UTC().Format("2006-01-02"))}
+	var prs []prActivity
+	for {
+		var data struct {
+			Search struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []prActivity `json:"nodes"`
+			} `json:"search"`
+		}
+		if err := c.graphql(query, vars, &data); err != nil {
+			return nil, err
+		}
+		prs = append(prs, data.Search.Nodes...)
+		if !data.Search.PageInfo.HasNextPage {
+			return prs, nil
+		}
+		vars["after"] = data.Search.PageInfo.EndCursor
+	}
+}
+
+// heatmap counts events per day from start, a Monday, over weeks weeks.
+type heatmap struct {
+	start  time.Time
+	weeks  int
+	counts []int
+}
+
+func newHeatmap(start time.Time, weeks int) *heatmap {
+	return &heatmap{start: start, weeks: weeks, counts: make([]int, weeks*7)}
+}
+
+// add counts t on its day in the heatmap's time zone, if it's in range.
+func (h *heatmap) add(t time.Time) {
+	t = t.In(h.start.Location())
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	// days, not hours: DST days are 23 or 25 hours long
+	i := int((day.Sub(h.start) + 12*time.Hour) / (24 * time.Hour))
+	if i >= 0 && i < len(h.counts) {
+		h.counts[i]++
+	}
+}
+
+func (h *heatmap) total() int {
+	n := 0
+	for _, c := range h.counts {
+		n += c
+	}
+	return n
+}
+
+// print draws the heatmap with a column per week and a row per weekday,
+// shaded relative to the busiest day. Days after today stay blank.
+func (h *heatmap) print(title string, today time.Time) {
+	busiest := 0
+	for _, c := range h.counts {
+		busiest = max(busiest, c)
+	}
+	fmt.Println(title)
+	// month names above the week their first day falls in, and above the
+	// first week unless its month is almost over
+	months := []byte(strings.Repeat(" ", h.weeks*2+8))
+	end := 0
+	for w := 0; w < h.weeks; w++ {
+		for d := 0; d < 7; d++ {
+			day := h.start.AddDate(0, 0, w*7+d)
+			if (day.Day() == 1 || w == 0 && d == 0 && day.Day() <= 17) && 4+w*2 > end {
+				end = 4 + w*2 + copy(months[4+w*2:], day.Format("Jan"))
+			}
+		}
+	}
+	fmt.Println(strings.TrimRight(string(months), " "))
+	for d := 0; d < 7; d++ {
+		var b strings.Builder
+		b.WriteString(h.start.AddDate(0, 0, d).Format("Mon") + " ")
+		for w := 0; w < h.weeks; w++ {
+			day := h.start.AddDate(0, 0, w*7+d)
+			if day.After(today) {
+				break
+			}
+			b.WriteString(heatmapCell(h.counts[w*7+d], busiest) + " ")
+		}
+		fmt.Println(strings.TrimRight(b.String(), " "))
+	}
+}
+
+// heatmapCell shades a day's count in up to four steps of the busiest day.
+func heatmapCell(n, busiest int) string {
+	if n == 0 {
+		return heatmapShades[0]
+	}
+	level := (4*n + busiest - 1) / busiest
+	return paint(ansiGreen, heatmapShades[level])
+}
+
+const heatmapUsage = "usage: pr-view heatmap [--weeks 12] [owner/repo ...]"
+
+// cmdHeatmap draws calendars of the PRs opened and merged per day across the
+// tracked repos, or the ones given, for the last --weeks weeks. Days are
+// counted in time.timezone.
+func cmdHeatmap(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	weeks := fs.Int("weeks", 12, "number of weeks to show, up to 53")
+	repos, err := parseInterleaved(fs, args)
+	if err != nil || *weeks < 1 || *weeks > 53 {
+		fmt.Println(heatmapUsage)
+		return 2
+	}
+	if len(repos) == 0 {
+		store, err := NewRepoStore()
+		if err != nil {
+			slog.Error("initializing store", "err", err)
+			return 1
+		}
+		if repos, err = store.Repos(); err != nil {
+			slog.Error("loading repos", "err", err)
+			return 1
+		}
+		if len(repos) == 0 {
+			fmt.Println("no repos tracked, add one with pr-view add owner/repo")
+			return 0
+		}
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	loc, err := timeLocation(cfg.Time.Timezone)
+	if err != nil {
+		slog.Error("loading time zone", "err", err)
+		return 1
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	start := monday.AddDate(0, 0, -7*(*weeks-1))
+	opened, merged := newHeatmap(start, *weeks), newHeatmap(start, *weeks)
+	code, counted := 0, 0
+	for _, repo := range repos {
+		prs, err := repoActivity(gh, repo, start)
+		if err != nil {
+			slog.Error("fetching activity", "repo", repo, "err", err)
+			code = 1
+			continue
+		}
+		counted++
+		for _, pr := range prs {
+			opened.add(pr.CreatedAt)
+			if pr.MergedAt != nil {
+				merged.add(*pr.MergedAt)
+			}
+		}
+	}
+	across := fmt.Sprintf("in the last %d %s across %d %s", *weeks, plural(*weeks, "week"), counted, plural(counted, "repo"))
+	opened.print(fmt.Sprintf("Opened: %d %s %s", opened.total(), plural(opened.total(), "PR"), across), today)
+	fmt.Println()
+	merged.print(fmt.Sprintf("Merged: %d %s %s", merged.total(), plural(merged.total(), "PR"), across), today)
+	fmt.Println()
+	fmt.Println("less " + strings.Join(heatmapShades, " ") + " more")
+	return code
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events the daemon runs hooks for.
+const (
+	eventNewPR           = "new_pr"
+	eventCIFailed        = "ci_failed"
+	eventReviewRequested = "review_requested"
+)
+
+var hookEvents = []string{eventNewPR, eventCIFailed, eventReviewRequested}
+
+const defaultHookTimeout = time.Minute
+
+// hookPayload is written as JSON to a hook's stdin.
+type hookPayload struct {
+	Event string      `json:"event"`
+	Repo  string      `json:"repo"`
+	PR    PullRequest `json:"pr"`
+}
+
+// prSnapshot is what the daemon remembers about a PR between polls to tell
+// when something happened to it.
+type prSnapshot struct {
+	ci        string
+	requested bool
+}
+
+// hooks returns the configured hooks, those given with the daemon.on_*
+// shorthands last.
+func (cfg *Config) hooks() []HookConfig {
+	hooks := slices.Clone(cfg.Hooks)
+	for event, command := range map[string]string{
+		eventNewPR:           cfg.Daemon.OnNewPR,
+		eventCIFailed:        cfg.Daemon.OnCIFailed,
+		eventReviewRequested: cfg.Daemon.OnReviewRequested,
+	} {
+		if strings.TrimSpace(command) != "" {
+			hooks = append(hooks, HookConfig{Event: event, Command: command})
+		}
+	}
+	return hooks
+}
+
+func hasHook(cfg *Config, event string) bool {
+	return slices.ContainsFunc(cfg.hooks(), func(h HookConfig) bool { return h.Event == event })
+}
+
+// reviewRequestedFrom reports whether pr is waiting for a review from v or
+// one of v's teams.
+func reviewRequestedFrom(pr PullRequest, repo string, v *viewer) bool {
+	for _, u := range pr.RequestedReviewers {
+		if strings.EqualFold(u.Login, v.Login) {
+			return true
+		}
+	}
+	owner, _, _ := strings.Cut(repo, "/")
+	for _, t := range pr.RequestedTeams {
+		if containsFold(v.Teams, "@"+owner+"/"+t.Slug) {
+			return true
+		}
+	}
+	return false
+}
+
+// prEvents returns the events that happened to a PR between the snapshots
+// prev, nil for PRs the last poll didn't see, and cur.
+func prEvents(prev *prSnapshot, cur prSnapshot) []string {
+	var events []string
+	if prev == nil {
+		events = append(events, eventNewPR)
+		prev = &prSnapshot{}
+	}
+	if cur.ci == ciFailure && prev.ci != ciFailure {
+		events = append(events, eventCIFailed)
+	}
+	if cur.requested && !prev.requested {
+		events = append(events, eventReviewRequested)
+	}
+	return events
+}
+
+// runHooks runs every hook configured for p.Event, one after the other.
+// Failures are logged; they don't stop the other hooks.
+func runHooks(cfg *Config, p hookPayload) {
+	for _, h := range cfg.hooks() {
+		if h.Event != p.Event {
+			continue
+		}
+		start := time.Now()
+		out, err := runHook(h, p)
+		attrs := []any{"event", p.Event, "pr", prKey(p.Repo, p.PR.Number), "command", h.Command, "duration", time.Since(start).Round(time.Millisecond)}
+		if len(out) > 0 {
+			attrs = append(attrs, "output", strings.TrimSpace(string(out)))
+		}
+		if err != nil {
+			slog.Warn("hook failed", append(attrs, "err", err)...)
+			continue
+		}
+		slog.Info("hook ran", attrs...)
+	}
+}
+
+// hookEnv describes p in PR_* variables, for hooks that don't read the
+// JSON on stdin.
+func hookEnv(p hookPayload) []string {
+	return []string{
+		"PR_EVENT=" + p.Event,
+		"PR_REPO=" + p.Repo,
+		"PR_NUMBER=" + strconv.Itoa(p.PR.Number),
+		"PR_TITLE=" + p.PR.Title,
+		"PR_URL=" + p.PR.HTMLURL,
+		"PR_AUTHOR=" + p.PR.User.Login,
+	}
+}
+
+// runHook runs h's command through the shell with p on stdin and in the
+// environment, and returns its combined output.
+func runHook(h HookConfig, p hookPayload) ([]byte, error) {
+	in, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(h.Timeout)
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+	}
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Env = append(os.Environ(), hookEnv(p)...)
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return out, fmt.Errorf("timed out after %s", timeout)
+	}
+	return out, err
+}
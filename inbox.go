@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notification is a GitHub notification thread.
+type notification struct {
+	ID        string    `json:"id"`
+	Unread    bool      `json:"unread"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Subject   struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Type  string `json:"type"`
+	} `json:"subject"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// number is the PR number from the subject's API URL, or 0 for threads
+// that aren't about a PR.
+func (n notification) number() int {
+	if n.Subject.Type != "PullRequest" {
+		return 0
+	}
+	_, num, ok := strings.Cut(n.Subject.URL, "/pulls/")
+	if !ok {
+		return 0
+	}
+	i, _ := strconv.Atoi(num)
+	return i
+}
+
+// fetchNotifications lists the PR notifications of the authenticated user,
+// only unread ones unless all is set. They are the primary token's, like
+// the threads inbox marks.
+func fetchNotifications(c *GitHubClient, all bool) ([]notification, error) {
+	if c.app != nil {
+		return nil, errors.New("notifications need a user token: a GitHub App's installation tokens can't read them (auth.token_source is app)")
+	}
+	next := "/notifications?per_page=50"
+	if all {
+		next += "&all=true"
+	}
+	var out []notification
+	for next != "" {
+		var page []notification
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, n := range page {
+			if n.number() > 0 {
+				out = append(out, n)
+			}
+		}
+	}
+	return out, nil
+}
+
+// notificationsState is the listing of your notifications the PRs of one
+// run are enriched from.
+type notificationsState struct {
+	once    sync.Once
+	reasons map[string]string
+	err     error
+}
+
+// notifyReasons returns why you were notified about each PR with unread
+// notifications, by prKey. The listing is fetched once per collectPRs run,
+// and on every call outside one.
+func (c *GitHubClient) notifyReasons() (map[string]string, error) {
+	s := c.notes
+	if s == nil {
+		s = &notificationsState{}
+	}
+	s.once.Do(func() {
+		var list []notification
+		if list, s.err = fetchNotifications(c, false); s.err != nil {
+			return
+		}
+		s.reasons = map[string]string{}
+		for _, n := range list {
+			s.reasons[prKey(n.Repository.FullName, n.number())] = n.Reason
+		}
+	})
+	return s.reasons, s.err
+}
+
+// enrichNotifications sets why you were notified about the PR, looked up in
+// the run's one listing of your notifications.
+var enrichNotifications = &enricher{name: "notifications", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	reasons, err := c.notifyReasons()
+	if err != nil {
+		return err
+	}
+	pr.NotifyReason = reasons[prKey(repo, pr.Number)]
+	return nil
+}}
+
+// notifyReasonLabel shows a notification reason the way GitHub's inbox
+// does, e.g. review requested.
+func notifyReasonLabel(reason string) string {
+	if reason == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(reason, "_", " ")
+}
+
+const inboxUsage = "usage: pr-view inbox [--all] [--json]\n       pr-view inbox read|done owner/repo#number|<PR_URL>... | --all"
+
+// cmdInbox lists your GitHub notifications about tracked PRs, or marks
+// them read or done.
+func cmdInbox(cfg *Config, hc *http.Client, args []string) int {
+	action := ""
+	if len(args) > 0 && (args[0] == "read" || args[0] == "done") {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("inbox", flag.ContinueOnError)
+	all := fs.Bool("all", false, "include notifications already read; with read or done, every listed one")
+	asJSON := fs.Bool("json", false, "print the notifications as a JSON array")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (action != "" && ((fs.NArg() == 0) == !*all || *asJSON)) || (action == "" && fs.NArg() > 0) {
+		fmt.Println(inboxUsage)
+		return 2
+	}
+	var keys []string
+	if action != "" {
+		var ok bool
+		if keys, ok = prKeys(fs.Args()); !ok {
+			return 2
+		}
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	// a warm cache would still show threads just marked read or done
+	gh = gh.withContext(withRevalidate(gh.ctx))
+	tracked, err := trackedPRs(cfg)
+	if err != nil {
+		slog.Error("loading tracked entries", "err", err)
+		return 1
+	}
+	list, err := fetchNotifications(gh, action == "done" || *all && action == "")
+	if err != nil {
+		slog.Error("fetching notifications", "err", err)
+		return 1
+	}
+	list = slices.DeleteFunc(list, func(n notification) bool {
+		return !tracked(n.Repository.FullName, n.number())
+	})
+	if action != "" {
+		return inboxMark(gh, list, keys, action == "done")
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if list == nil {
+			list = []notification{}
+		}
+		if err := enc.Encode(list); err != nil {
+			slog.Error("writing JSON", "err", err)
+			return 1
+		}
+		return 0
+	}
+	if len(list) == 0 {
+		fmt.Println("no notifications about tracked PRs")
+		return 0
+	}
+	width, rwidth := 0, 0
+	for _, n := range list {
+		width = max(width, len(prKey(n.Repository.FullName, n.number())))
+		rwidth = max(rwidth, len(notifyReasonLabel(n.Reason)))
+	}
+	for _, n := range list {
+		title := n.Subject.Title
+		if n.Unread {
+			title = "* " + title
+		}
+		fmt.Printf("%-*s  %-*s  %s (%s)\n", width, prKey(n.Repository.FullName, n.number()), rwidth, notifyReasonLabel(n.Reason), title, fmtTime(cfg, n.UpdatedAt))
+	}
+	return 0
+}
+
+// inboxMark marks the threads of the PRs in keys read, or done, which also
+// takes them out of the inbox; no keys means every thread in list.
+func inboxMark(gh *GitHubClient, list []notification, keys []string, done bool) int {
+	method, verb := "PATCH", "marked read"
+	if done {
+		method, verb = "DELETE", "marked done"
+	}
+	n, failed := 0, false
+	for _, t := range list {
+		key := prKey(t.Repository.FullName, t.number())
+		if len(keys) > 0 && !slices.Contains(keys, key) {
+			continue
+		}
+		if err := gh.do(method, "/notifications/threads/"+t.ID, nil, nil); err != nil {
+			slog.Error("marking notification", "pr", key, "err", err)
+			failed = true
+			continue
+		}
+		n++
+	}
+	fmt.Printf("%s %d %s\n", verb, n, plural(n, "notification"))
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syncedRepo is a repo's open PRs as of the last incremental fetch. Cursor
+// is the newest updatedAt seen: the next fetch asks only for PRs updated
+// since, open or not, and merges them in.
+type syncedRepo struct {
+	Cursor time.Time     `json:"cursor"`
+	Full   time.Time     `json:"full"`
+	PRs    []PullRequest `json:"prs"`
+}
+
+// syncMaxAge is how long a synced set is merged into before it is listed
+// in full again, which catches what the cursor can't, like PRs whose repo
+// was transferred or that were deleted by GitHub support.
+const syncMaxAge = 24 * time.Hour
+
+// syncPageSize is the page size of incremental fetches; a poll usually
+// finds a handful of updated PRs, and a full listing takes the maximum.
+const syncPageSize = 25
+
+const syncQuery = `query($owner: String!, $name: String!, $first: Int!, $after: String, $states: [PullRequestState!]) {
+	repository(owner: $owner, name: $name) {
+		pullRequests(first: $first, after: $after, states: $states, orderBy: {field: UPDATED_AT, direction: DESC}) {
+			pageInfo { hasNextPage endCursor }
+			nodes { ...prFields }
+		}
+	}
+}
+`
+
+func syncDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	// next to the HTTP cache, like the warm marker
+	dir = filepath.Join(filepath.Dir(dir), "incremental")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func syncPath(repo string) (string, error) {
+	dir, err := syncDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, strings.ToLower(strings.ReplaceAll(repo, "/", "__"))+".json"), nil
+}
+
+func loadSynced(repo string) (*syncedRepo, error) {
+	path, err := syncPath(repo)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &syncedRepo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s syncedRepo
+	if err := json.Unmarshal(data, &s); err != nil {
+		// a corrupt set is refetched rather than failing the repo
+		slog.Warn("discarding synced PRs", "repo", repo, "err", err)
+		return &syncedRepo{}, nil
+	}
+	return &s, nil
+}
+
+func saveSynced(repo string, s *syncedRepo) error {
+	path, err := syncPath(repo)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// canSync reports whether an entry fetched with q can be kept in sync
+// incrementally. Like the batch it needs GraphQL, and the synced set can
+// only be ordered by the times it holds.
+func canSync(c *GitHubClient, repo string, q prQuery) bool {
+	return c.token != "" && !strings.Contains(repo, "#") && !q.Issues &&
+		(q.Sort == "created" || q.Sort == "updated")
+}
+
+// syncPRs brings repo's synced set up to date and returns its open PRs in
+// q's order. The first fetch, and one a day after, lists every open PR;
+// the others page through PRs by update time until they reach the cursor.
+func syncPRs(c *GitHubClient, repo string, q prQuery) ([]PullRequest, error) {
+	s, err := loadSynced(repo)
+	if err != nil {
+		return nil, err
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	full := s.Cursor.IsZero() || time.Since(s.Full) > syncMaxAge
+	vars := map[string]any{"owner": owner, "name": name, "first": syncPageSize}
+	if full {
+		vars["first"] = 100
+		vars["states"] = []string{"OPEN"}
+	}
+	byNumber := map[int]PullRequest{}
+	if !full {
+		for _, pr := range s.PRs {
+			byNumber[pr.Number] = pr
+		}
+	}
+	cursor, pages := s.Cursor, 0
+	for {
+		var resp struct {
+			Repository *struct {
+				PullRequests struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []gqlPullRequest `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+		if err := c.graphql(syncQuery+prFragment, vars, &resp); err != nil {
+			var ge graphqlErrors
+			if errors.As(err, &ge) && len(ge) > 0 {
+				return nil, graphqlRepoError(ge[0])
+			}
+			return nil, err
+		}
+		if resp.Repository == nil {
+			return nil, &apiError{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: "repository not found"}
+		}
+		pages++
+		reached := false
+		for _, n := range resp.Repository.PullRequests.Nodes {
+			// ties with the cursor are merged again, harmlessly
+			if !full && n.UpdatedAt.Before(s.Cursor) {
+				reached = true
+				break
+			}
+			pr := n.toPullRequest()
+			if pr.State == "open" {
+				byNumber[pr.Number] = pr
+			} else {
+				delete(byNumber, pr.Number)
+			}
+			if pr.UpdatedAt.After(cursor) {
+				cursor = pr.UpdatedAt
+			}
+		}
+		info := resp.Repository.PullRequests.PageInfo
+		if reached || !info.HasNextPage {
+			break
+		}
+		vars["after"] = info.EndCursor
+	}
+	s.Cursor = cursor
+	if full {
+		s.Full = time.Now()
+	}
+	s.PRs = s.PRs[:0]
+	for _, pr := range byNumber {
+		s.PRs = append(s.PRs, pr)
+	}
+	slices.SortFunc(s.PRs, func(a, b PullRequest) int { return cmp.Compare(b.Number, a.Number) })
+	slog.Debug("repo synced", "repo", repo, "full", full, "pages", pages, "prs", len(s.PRs))
+	if err := saveSynced(repo, s); err != nil {
+		slog.Warn("saving synced PRs", "repo", repo, "err", err)
+	}
+	return orderSynced(s.PRs, q), nil
+}
+
+// orderSynced sorts a synced set as the pulls endpoint would for q and cuts
+// it to the limit.
+func orderSynced(prs []PullRequest, q prQuery) []PullRequest {
+	prs = slices.Clone(prs)
+	key := func(pr PullRequest) time.Time { return pr.UpdatedAt }
+	if q.Sort == "created" {
+		key = func(pr PullRequest) time.Time { return pr.CreatedAt }
+	}
+	slices.SortStableFunc(prs, func(a, b PullRequest) int {
+		if q.Direction == "asc" {
+			return key(a).Compare(key(b))
+		}
+		return key(b).Compare(key(a))
+	})
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 30 // the REST default
+	}
+	if len(prs) > limit {
+		prs = prs[:limit]
+	}
+	return prs
+}
+
+// fetchIncremental is fetchAll for list --incremental: whole-repo entries
+// are synced one query each, usually a single small page, and the rest go
+// through fetchAll.
+func fetchIncremental(gh *GitHubClient, repos []string, queryFor func(repo string) prQuery, skip func(repo string) error) []PRResult {
+	results := make([]PRResult, len(repos))
+	var rest []string
+	var restIdx, synced []int
+	for i, r := range repos {
+		if canSync(gh, r, queryFor(r)) {
+			synced = append(synced, i)
+		} else {
+			rest = append(rest, r)
+			restIdx = append(restIdx, i)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, i := range synced {
+		if err := skip(repos[i]); err != nil {
+			results[i] = PRResult{Repo: repos[i], Err: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			ctx, s := startSpan(gh.ctx, "sync repo", "repo", repos[i])
+			prs, err := syncPRs(gh.withContext(ctx), repos[i], queryFor(repos[i]))
+			s.set("prs", len(prs))
+			s.finish(err)
+			slog.Debug("repo fetched", "repo", repos[i], "prs", len(prs), "duration", time.Since(start).Round(time.Millisecond))
+			results[i] = PRResult{Repo: repos[i], PRs: prs, Err: err}
+		}(i)
+	}
+	if len(rest) > 0 {
+		for j, res := range fetchAll(gh, rest, queryFor, skip) {
+			results[restIdx[j]] = res
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// mergeSynced applies one PR as GitHub sent it, e.g. in a webhook, to its
+// repo's synced set. Repos that aren't synced yet are left alone, and the
+// cursor stays: the next fetch still asks for everything since.
+func mergeSynced(repo string, pr PullRequest) error {
+	s, err := loadSynced(repo)
+	if err != nil || s.Cursor.IsZero() {
+		return err
+	}
+	i := slices.IndexFunc(s.PRs, func(p PullRequest) bool { return p.Number == pr.Number })
+	switch {
+	case i >= 0 && pr.UpdatedAt.Before(s.PRs[i].UpdatedAt):
+		return nil // a late delivery
+	case pr.State != "open" && i >= 0:
+		s.PRs = slices.Delete(s.PRs, i, i+1)
+	case pr.State != "open":
+		return nil
+	case i >= 0:
+		s.PRs[i] = pr
+	default:
+		s.PRs = append(s.PRs, pr)
+		slices.SortFunc(s.PRs, func(a, b PullRequest) int { return cmp.Compare(b.Number, a.Number) })
+	}
+	return saveSynced(repo, s)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// issueSorts maps list sorts onto the issues endpoint, which has no
+// long-running order and calls popularity comments.
+var issueSorts = map[string]string{"created": "created", "updated": "updated", "popularity": "comments"}
+
+// fetchIssues returns the open issues of owner/repo. The issues endpoint
+// lists PRs too, those are dropped, so pages are fetched until q.Limit real
+// issues are found.
+func fetchIssues(c *GitHubClient, repo string, q prQuery) ([]PullRequest, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || strings.Contains(repo, "#") {
+		return nil, fmt.Errorf("invalid repo: %s", repo)
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues?state=open", owner, name)
+	if s := issueSorts[q.Sort]; s != "" {
+		path += "&sort=" + s
+	}
+	if q.Direction != "" {
+		path += "&direction=" + q.Direction
+	}
+	limit := q.Limit
+	if limit > 0 {
+		path += fmt.Sprintf("&per_page=%d", min(limit, 100))
+	}
+	var issues []PullRequest
+	for path != "" {
+		// issues sharing the PR shape decode into PullRequest, the
+		// pull_request key tells PRs apart
+		var page []struct {
+			PullRequest
+			PR *struct{} `json:"pull_request"`
+		}
+		next, err := c.doPage("GET", path, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range page {
+			if it.PR == nil {
+				issues = append(issues, it.PullRequest)
+			}
+		}
+		if limit <= 0 || len(issues) >= limit {
+			break
+		}
+		path = next
+	}
+	if limit > 0 && len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+// issueColumns drops the columns issues can't fill, those needing PR
+// details from an enricher. Asking for one explicitly is an error.
+func issueColumns(cols []column, explicit bool) ([]column, error) {
+	var kept []column
+	for _, c := range cols {
+		if len(c.needs) == 0 {
+			kept = append(kept, c)
+		} else if explicit {
+			return nil, fmt.Errorf("column %q isn't available for issues", c.name)
+		}
+	}
+	return kept, nil
+}
+
+// checkIssueOptions rejects the list flags that only make sense for PRs.
+func checkIssueOptions(opts *listOptions) error {
+	for _, f := range opts.filters {
+		if len(f.needs) > 0 || f.prepare != nil || f.prOnly {
+			return fmt.Errorf("--%s doesn't apply to issues", f.name)
+		}
+	}
+	if opts.groupBy == "owner" {
+		return errors.New("--group-by owner doesn't apply to issues")
+	}
+	if _, ok := issueSorts[opts.sort]; !ok && slices.Contains(listSorts, opts.sort) {
+		return errors.New("--sort " + opts.sort + " doesn't apply to issues")
+	}
+	return nil
+}
+
+// cmdIssues is `pr-view issues`, short for `pr-view list --issues`.
+func cmdIssues(cfg *Config, hc *http.Client, args []string) int {
+	return cmdList(cfg, hc, append([]string{"--issues"}, args...))
+}
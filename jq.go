@@ -0,0 +1,1325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// jqFilter is a compiled --query expression. It implements the part of jq
+// that extracting fields needs: paths, iteration, pipes, comma, object and
+// array construction, comparisons, and/or, // and the common builtins.
+// Like jq, a filter turns one input into any number of outputs.
+type jqFilter func(in any) ([]any, error)
+
+// parseQuery compiles a jq expression.
+func parseQuery(src string) (jqFilter, error) {
+	toks, err := lexQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &jqParser{toks: toks}
+	f, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != jqEOF {
+		return nil, fmt.Errorf("query: unexpected %q", t.text)
+	}
+	return f, nil
+}
+
+// runQuery applies q to v, converting v to plain JSON values first.
+func runQuery(q jqFilter, v any) ([]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var in any
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	return q(in)
+}
+
+// printQueryResults prints each output on its own line, strings unquoted
+// as gh --jq does.
+func printQueryResults(outs []any) error {
+	for _, o := range outs {
+		if s, ok := o.(string); ok {
+			fmt.Println(s)
+			continue
+		}
+		data, err := json.MarshalIndent(o, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+type jqTokenKind int
+
+const (
+	jqEOF jqTokenKind = iota
+	jqField
+	jqIdent
+	jqString
+	jqNumber
+	jqPunct
+)
+
+type jqToken struct {
+	kind jqTokenKind
+	text string
+	num  float64
+}
+
+var jqOperators = []string{"==", "!=", "<=", ">=", "//", "<", ">", "+", "-", ".", "[", "]", "{", "}", "(", ")", "|", ",", ":", ";"}
+
+func lexQuery(src string) ([]jqToken, error) {
+	var toks []jqToken
+	rs := []rune(src)
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			j, depth := i+1, 0
+			// skip over interpolations, which may contain strings
+			for j < len(rs) && (rs[j] != '"' || depth > 0) {
+				switch {
+				case rs[j] == '\\' && j+1 < len(rs) && rs[j+1] == '(':
+					depth++
+					j++
+				case rs[j] == '\\':
+					j++
+				case depth > 0 && rs[j] == '(':
+					depth++
+				case depth > 0 && rs[j] == ')':
+					depth--
+				}
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("query: unterminated string")
+			}
+			toks = append(toks, jqToken{kind: jqString, text: string(rs[i+1 : j])})
+			i = j + 1
+		case r == '.' && i+1 < len(rs) && (rs[i+1] == '_' || unicode.IsLetter(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && isIdent(rs[j]) {
+				j++
+			}
+			toks = append(toks, jqToken{kind: jqField, text: string(rs[i+1 : j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(string(rs[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("query: invalid number %s", string(rs[i:j]))
+			}
+			toks = append(toks, jqToken{kind: jqNumber, text: string(rs[i:j]), num: n})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(rs) && isIdent(rs[j]) {
+				j++
+			}
+			toks = append(toks, jqToken{kind: jqIdent, text: string(rs[i:j])})
+			i = j
+		default:
+			op := ""
+			for _, o := range jqOperators {
+				if strings.HasPrefix(string(rs[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("query: unexpected %q", r)
+			}
+			toks = append(toks, jqToken{kind: jqPunct, text: op})
+			i += len([]rune(op))
+		}
+	}
+	return append(toks, jqToken{kind: jqEOF}), nil
+}
+
+type jqParser struct {
+	toks []jqToken
+	pos  int
+}
+
+func (p *jqParser) peek() jqToken { return p.toks[p.pos] }
+
+func (p *jqParser) next() jqToken {
+	t := p.toks[p.pos]
+	if t.kind != jqEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the punctuation or keyword s if it comes next.
+func (p *jqParser) accept(s string) bool {
+	if t := p.peek(); (t.kind == jqPunct || t.kind == jqIdent) && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jqParser) expect(s string) error {
+	if !p.accept(s) {
+		return fmt.Errorf("query: expected %q, got %q", s, p.peek().text)
+	}
+	return nil
+}
+
+// pipe := comma ('|' comma)*
+func (p *jqParser) pipe() (jqFilter, error) {
+	left, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.comma()
+		if err != nil {
+			return nil, err
+		}
+		left = jqPipe(left, right)
+	}
+	return left, nil
+}
+
+func jqPipe(left, right jqFilter) jqFilter {
+	return func(in any) ([]any, error) {
+		ls, err := left(in)
+		if err != nil {
+			return nil, err
+		}
+		var outs []any
+		for _, l := range ls {
+			rs, err := right(l)
+			if err != nil {
+				return nil, err
+			}
+			outs = append(outs, rs...)
+		}
+		return outs, nil
+	}
+}
+
+// comma := alt (',' alt)*
+func (p *jqParser) comma() (jqFilter, error) {
+	left, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(",") {
+		right, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(in any) ([]any, error) {
+			a, err := l(in)
+			if err != nil {
+				return nil, err
+			}
+			b, err := right(in)
+			return append(a, b...), err
+		}
+	}
+	return left, nil
+}
+
+// alt := or ('//' or)*, where a // b yields a's truthy outputs, or b.
+func (p *jqParser) alt() (jqFilter, error) {
+	left, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("//") {
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(in any) ([]any, error) {
+			a, err := l(in)
+			var keep []any
+			for _, v := range a {
+				if jqTruthy(v) {
+					keep = append(keep, v)
+				}
+			}
+			if err == nil && len(keep) > 0 {
+				return keep, nil
+			}
+			return right(in)
+		}
+	}
+	return left, nil
+}
+
+func (p *jqParser) or() (jqFilter, error) {
+	return p.logical("or", p.and, func(a, b bool) bool { return a || b })
+}
+
+func (p *jqParser) and() (jqFilter, error) {
+	return p.logical("and", p.compare, func(a, b bool) bool { return a && b })
+}
+
+func (p *jqParser) logical(kw string, operand func() (jqFilter, error), op func(a, b bool) bool) (jqFilter, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(kw) {
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = jqBinary(left, right, func(a, b any) (any, error) { return op(jqTruthy(a), jqTruthy(b)), nil })
+	}
+	return left, nil
+}
+
+// compare := additive (op additive)?
+func (p *jqParser) compare() (jqFilter, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return jqBinary(left, right, func(a, b any) (any, error) {
+			c := jqCompare(a, b)
+			switch op {
+			case "==":
+				return c == 0, nil
+			case "!=":
+				return c != 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">=":
+				return c >= 0, nil
+			case "<":
+				return c < 0, nil
+			}
+			return c > 0, nil
+		}), nil
+	}
+	return left, nil
+}
+
+func (p *jqParser) additive() (jqFilter, error) {
+	left, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("+"):
+			right, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			left = jqBinary(left, right, jqAdd)
+		case p.accept("-"):
+			right, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			left = jqBinary(left, right, jqSub)
+		default:
+			return left, nil
+		}
+	}
+}
+
+// jqBinary applies op to every combination of left's and right's outputs
+// for the same input.
+func jqBinary(left, right jqFilter, op func(a, b any) (any, error)) jqFilter {
+	return func(in any) ([]any, error) {
+		ls, err := left(in)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := right(in)
+		if err != nil {
+			return nil, err
+		}
+		var outs []any
+		for _, l := range ls {
+			for _, r := range rs {
+				v, err := op(l, r)
+				if err != nil {
+					return nil, err
+				}
+				outs = append(outs, v)
+			}
+		}
+		return outs, nil
+	}
+}
+
+// postfix := primary ('.name' | '."name"' | '[' ']' | '[' pipe ']')*
+func (p *jqParser) postfix() (jqFilter, error) {
+	f, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == jqField:
+			p.next()
+			f = jqPipe(f, jqIndexConst(t.text))
+		case t.kind == jqPunct && t.text == "." && p.toks[p.pos+1].kind == jqString:
+			p.next()
+			key, err := jqInterpolate(p.next().text)
+			if err != nil {
+				return nil, err
+			}
+			f = jqPipe(f, jqIndexBy(key))
+		case t.kind == jqPunct && t.text == "[":
+			p.next()
+			sub, err := p.index()
+			if err != nil {
+				return nil, err
+			}
+			f = jqPipe(f, sub)
+		case t.kind == jqPunct && t.text == "." && p.toks[p.pos+1].text == "[":
+			p.next() // .foo.[0] is .foo[0]
+		default:
+			return f, nil
+		}
+	}
+}
+
+// index parses the part after '[' of .[], .[n] and .["key"].
+func (p *jqParser) index() (jqFilter, error) {
+	if p.accept("]") {
+		return jqIterate, nil
+	}
+	var from, to jqFilter
+	if !p.accept(":") {
+		key, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept("]") {
+			return jqIndexBy(key), nil
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		from = key
+	}
+	if !p.accept("]") {
+		end, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		to = end
+	}
+	return jqSlice(from, to), nil
+}
+
+// jqIndexBy indexes the input by every output of key.
+func jqIndexBy(key jqFilter) jqFilter {
+	return func(in any) ([]any, error) {
+		ks, err := key(in)
+		if err != nil {
+			return nil, err
+		}
+		var outs []any
+		for _, k := range ks {
+			v, err := jqIndex(in, k)
+			if err != nil {
+				return nil, err
+			}
+			outs = append(outs, v)
+		}
+		return outs, nil
+	}
+}
+
+// jqSlice implements .[from:to] on arrays and strings; a nil bound is the
+// start or end.
+func jqSlice(from, to jqFilter) jqFilter {
+	bound := func(f jqFilter, in any, def int) (int, error) {
+		if f == nil {
+			return def, nil
+		}
+		vs, err := f(in)
+		if err != nil {
+			return 0, err
+		}
+		if len(vs) != 1 {
+			return 0, fmt.Errorf("slice bounds must be single numbers")
+		}
+		n, ok := vs[0].(float64)
+		if !ok {
+			return 0, fmt.Errorf("slice bounds must be numbers, not %s", jqType(vs[0]))
+		}
+		return int(n), nil
+	}
+	return func(in any) ([]any, error) {
+		var n int
+		switch v := in.(type) {
+		case nil:
+			return []any{nil}, nil
+		case []any:
+			n = len(v)
+		case string:
+			n = len([]rune(v))
+		default:
+			return nil, fmt.Errorf("cannot slice %s", jqType(in))
+		}
+		i, err := bound(from, in, 0)
+		if err != nil {
+			return nil, err
+		}
+		j, err := bound(to, in, n)
+		if err != nil {
+			return nil, err
+		}
+		clamp := func(x int) int {
+			if x < 0 {
+				x += n
+			}
+			return max(0, min(x, n))
+		}
+		i, j = clamp(i), max(clamp(i), clamp(j))
+		if a, ok := in.([]any); ok {
+			return []any{slices.Clone(a[i:j])}, nil
+		}
+		return []any{string([]rune(in.(string))[i:j])}, nil
+	}
+}
+
+// jqInterpolate compiles the body of a string literal, which may embed
+// expressions as \(expr).
+func jqInterpolate(raw string) (jqFilter, error) {
+	var parts []jqFilter
+	rs := []rune(raw)
+	lit := 0
+	flush := func(end int) error {
+		if end == lit {
+			return nil
+		}
+		s, err := strconv.Unquote(`"` + string(rs[lit:end]) + `"`)
+		if err != nil {
+			return fmt.Errorf("query: invalid string \"%s\"", raw)
+		}
+		parts = append(parts, jqConst(s))
+		return nil
+	}
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '\\' {
+			continue
+		}
+		if i+1 >= len(rs) || rs[i+1] != '(' {
+			i++
+			continue
+		}
+		if err := flush(i); err != nil {
+			return nil, err
+		}
+		j, depth := i+2, 1
+		for ; j < len(rs) && depth > 0; j++ {
+			switch rs[j] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return nil, fmt.Errorf("query: unterminated \\( in string")
+		}
+		f, err := parseQuery(string(rs[i+2 : j-1]))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, jqPipe(f, func(in any) ([]any, error) {
+			v, err := jqBuiltins0["tostring"](in)
+			return []any{v}, err
+		}))
+		i, lit = j-1, j
+	}
+	if err := flush(len(rs)); err != nil {
+		return nil, err
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	acc := jqConst("")
+	for _, part := range parts {
+		acc = jqBinary(acc, part, jqAdd)
+	}
+	return acc, nil
+}
+
+func (p *jqParser) primary() (jqFilter, error) {
+	t := p.next()
+	switch t.kind {
+	case jqField:
+		return jqIndexConst(t.text), nil
+	case jqString:
+		return jqInterpolate(t.text)
+	case jqNumber:
+		return jqConst(t.num), nil
+	case jqIdent:
+		return p.call(t.text)
+	case jqPunct:
+		switch t.text {
+		case ".":
+			if p.peek().kind == jqString {
+				key, err := jqInterpolate(p.next().text)
+				if err != nil {
+					return nil, err
+				}
+				return jqIndexBy(key), nil
+			}
+			return func(in any) ([]any, error) { return []any{in}, nil }, nil
+		case "-":
+			f, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			return jqBinary(jqConst(0.0), f, jqSub), nil
+		case "(":
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return f, p.expect(")")
+		case "[":
+			if p.accept("]") {
+				return jqConst([]any{}), nil
+			}
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return func(in any) ([]any, error) {
+				outs, err := f(in)
+				if outs == nil {
+					outs = []any{}
+				}
+				return []any{outs}, err
+			}, nil
+		case "{":
+			return p.object()
+		}
+	}
+	if t.kind == jqEOF {
+		return nil, fmt.Errorf("query: unexpected end")
+	}
+	return nil, fmt.Errorf("query: unexpected %q", t.text)
+}
+
+// object parses {a, b: .c, "d": 1, (.k): .v} after the opening brace.
+func (p *jqParser) object() (jqFilter, error) {
+	type entry struct{ key, value jqFilter }
+	var entries []entry
+	for !p.accept("}") {
+		if len(entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		var e entry
+		t := p.next()
+		switch {
+		case t.kind == jqIdent:
+			e.key = jqConst(t.text)
+			e.value = jqIndexConst(t.text)
+		case t.kind == jqString:
+			k, err := jqInterpolate(t.text)
+			if err != nil {
+				return nil, err
+			}
+			e.key, e.value = k, jqIndexBy(k)
+		case t.kind == jqPunct && t.text == "(":
+			k, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			e.key = k
+		default:
+			return nil, fmt.Errorf("query: unexpected %q in object", t.text)
+		}
+		if p.accept(":") {
+			v, err := p.alt()
+			if err != nil {
+				return nil, err
+			}
+			e.value = v
+		} else if e.value == nil {
+			return nil, fmt.Errorf("query: computed object key needs a value")
+		}
+		entries = append(entries, e)
+	}
+	return func(in any) ([]any, error) {
+		objs := []map[string]any{{}}
+		for _, e := range entries {
+			ks, err := e.key(in)
+			if err != nil {
+				return nil, err
+			}
+			vs, err := e.value(in)
+			if err != nil {
+				return nil, err
+			}
+			var next []map[string]any
+			for _, o := range objs {
+				for _, k := range ks {
+					ks, ok := k.(string)
+					if !ok {
+						return nil, fmt.Errorf("object keys must be strings, not %s", jqType(k))
+					}
+					for _, v := range vs {
+						c := make(map[string]any, len(o)+1)
+						for ok, ov := range o {
+							c[ok] = ov
+						}
+						c[ks] = v
+						next = append(next, c)
+					}
+				}
+			}
+			objs = next
+		}
+		outs := make([]any, len(objs))
+		for i, o := range objs {
+			outs[i] = o
+		}
+		return outs, nil
+	}, nil
+}
+
+// call parses a literal keyword or a builtin with its arguments.
+func (p *jqParser) call(name string) (jqFilter, error) {
+	switch name {
+	case "true", "false":
+		return jqConst(name == "true"), nil
+	case "null":
+		return jqConst(nil), nil
+	}
+	var args []jqFilter
+	if p.accept("(") {
+		for {
+			a, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if !p.accept(";") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if f, ok := jqBuiltins0[name]; ok && len(args) == 0 {
+		return func(in any) ([]any, error) {
+			v, err := f(in)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if v == jqEmpty {
+				return nil, nil
+			}
+			return []any{v}, nil
+		}, nil
+	}
+	if f, ok := jqBuiltins1[name]; ok && len(args) == 1 {
+		arg := args[0]
+		return func(in any) ([]any, error) {
+			outs, err := f(in, arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return outs, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("query: unknown function %s/%d", name, len(args))
+}
+
+// jqEmpty is returned by zero-argument builtins that produce no output.
+var jqEmpty = &struct{}{}
+
+var jqBuiltins0 map[string]func(in any) (any, error)
+var jqBuiltins1 map[string]func(in any, arg jqFilter) ([]any, error)
+
+func init() {
+	jqBuiltins0 = map[string]func(in any) (any, error){
+		"empty": func(any) (any, error) { return jqEmpty, nil },
+		"not":   func(in any) (any, error) { return !jqTruthy(in), nil },
+		"type":  func(in any) (any, error) { return jqType(in), nil },
+		"length": func(in any) (any, error) {
+			switch v := in.(type) {
+			case nil:
+				return 0.0, nil
+			case string:
+				return float64(len([]rune(v))), nil
+			case []any:
+				return float64(len(v)), nil
+			case map[string]any:
+				return float64(len(v)), nil
+			case float64:
+				return math.Abs(v), nil
+			}
+			return nil, fmt.Errorf("%s has no length", jqType(in))
+		},
+		"keys": func(in any) (any, error) {
+			switch v := in.(type) {
+			case map[string]any:
+				keys := make([]any, 0, len(v))
+				for _, k := range jqSortedKeys(v) {
+					keys = append(keys, k)
+				}
+				return keys, nil
+			case []any:
+				keys := make([]any, len(v))
+				for i := range v {
+					keys[i] = float64(i)
+				}
+				return keys, nil
+			}
+			return nil, fmt.Errorf("%s has no keys", jqType(in))
+		},
+		"first": func(in any) (any, error) { return jqIndex(in, 0.0) },
+		"last":  func(in any) (any, error) { return jqIndex(in, -1.0) },
+		"sort": func(in any) (any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			a = slices.Clone(a)
+			slices.SortStableFunc(a, jqCompare)
+			return a, nil
+		},
+		"unique": func(in any) (any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			a = slices.Clone(a)
+			slices.SortStableFunc(a, jqCompare)
+			return slices.CompactFunc(a, func(x, y any) bool { return jqCompare(x, y) == 0 }), nil
+		},
+		"reverse": func(in any) (any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			a = slices.Clone(a)
+			slices.Reverse(a)
+			return a, nil
+		},
+		"add": func(in any) (any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			var sum any
+			for _, v := range a {
+				if sum, err = jqAdd(sum, v); err != nil {
+					return nil, err
+				}
+			}
+			return sum, nil
+		},
+		"min": func(in any) (any, error) { return jqExtreme(in, -1) },
+		"max": func(in any) (any, error) { return jqExtreme(in, 1) },
+		"tostring": func(in any) (any, error) {
+			if s, ok := in.(string); ok {
+				return s, nil
+			}
+			data, err := json.Marshal(in)
+			return string(data), err
+		},
+		"tonumber": func(in any) (any, error) {
+			switch v := in.(type) {
+			case float64:
+				return v, nil
+			case string:
+				return strconv.ParseFloat(strings.TrimSpace(v), 64)
+			}
+			return nil, fmt.Errorf("can't convert %s to a number", jqType(in))
+		},
+		"ascii_downcase": jqStringFunc(strings.ToLower),
+		"ascii_upcase":   jqStringFunc(strings.ToUpper),
+		"to_entries": func(in any) (any, error) {
+			m, ok := in.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s has no entries", jqType(in))
+			}
+			var out []any
+			for _, k := range jqSortedKeys(m) {
+				out = append(out, map[string]any{"key": k, "value": m[k]})
+			}
+			return out, nil
+		},
+	}
+	jqBuiltins1 = map[string]func(in any, arg jqFilter) ([]any, error){
+		"select": func(in any, f jqFilter) ([]any, error) {
+			conds, err := f(in)
+			if err != nil {
+				return nil, err
+			}
+			var outs []any
+			for _, c := range conds {
+				if jqTruthy(c) {
+					outs = append(outs, in)
+				}
+			}
+			return outs, nil
+		},
+		"map": func(in any, f jqFilter) ([]any, error) {
+			elems, err := jqIterate(in)
+			if err != nil {
+				return nil, err
+			}
+			out := []any{}
+			for _, e := range elems {
+				vs, err := f(e)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, vs...)
+			}
+			return []any{out}, nil
+		},
+		"sort_by": func(in any, f jqFilter) ([]any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			keys := make([]any, len(a))
+			for i, e := range a {
+				k, err := f(e)
+				if err != nil {
+					return nil, err
+				}
+				keys[i] = k
+			}
+			idx := make([]int, len(a))
+			for i := range idx {
+				idx[i] = i
+			}
+			slices.SortStableFunc(idx, func(i, j int) int { return jqCompare(keys[i], keys[j]) })
+			out := make([]any, len(a))
+			for i, j := range idx {
+				out[i] = a[j]
+			}
+			return []any{out}, nil
+		},
+		"join": jqWithArg(func(in, sep any) (any, error) {
+			a, err := jqArray(in)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := sep.(string)
+			if !ok {
+				return nil, fmt.Errorf("separator must be a string")
+			}
+			parts := make([]string, len(a))
+			for i, v := range a {
+				switch x := v.(type) {
+				case nil:
+				case string:
+					parts[i] = x
+				default:
+					data, _ := json.Marshal(x)
+					parts[i] = string(data)
+				}
+			}
+			return strings.Join(parts, s), nil
+		}),
+		"has": jqWithArg(func(in, key any) (any, error) {
+			switch v := in.(type) {
+			case map[string]any:
+				k, ok := key.(string)
+				_, found := v[k]
+				return ok && found, nil
+			case []any:
+				n, ok := key.(float64)
+				return ok && n >= 0 && int(n) < len(v), nil
+			}
+			return nil, fmt.Errorf("can't check whether %s has a key", jqType(in))
+		}),
+		"contains": jqWithArg(func(in, x any) (any, error) { return jqContains(in, x), nil }),
+		"test": jqWithArg(func(in, re any) (any, error) {
+			s, ok1 := in.(string)
+			r, ok2 := re.(string)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("needs a string input and pattern")
+			}
+			m, err := regexp.MatchString(r, s)
+			return m, err
+		}),
+		"startswith": jqStringArg(strings.HasPrefix),
+		"endswith":   jqStringArg(strings.HasSuffix),
+		"split": jqWithArg(func(in, sep any) (any, error) {
+			s, ok1 := in.(string)
+			d, ok2 := sep.(string)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("needs a string input and separator")
+			}
+			var out []any
+			for _, part := range strings.Split(s, d) {
+				out = append(out, part)
+			}
+			return out, nil
+		}),
+		"any": func(in any, f jqFilter) ([]any, error) { return jqQuantify(in, f, true) },
+		"all": func(in any, f jqFilter) ([]any, error) { return jqQuantify(in, f, false) },
+	}
+}
+
+// jqWithArg adapts a function of the input and one argument value to run
+// once per output of the argument filter.
+func jqWithArg(fn func(in, arg any) (any, error)) func(in any, arg jqFilter) ([]any, error) {
+	return func(in any, arg jqFilter) ([]any, error) {
+		args, err := arg(in)
+		if err != nil {
+			return nil, err
+		}
+		var outs []any
+		for _, a := range args {
+			v, err := fn(in, a)
+			if err != nil {
+				return nil, err
+			}
+			outs = append(outs, v)
+		}
+		return outs, nil
+	}
+}
+
+func jqStringArg(fn func(s, arg string) bool) func(in any, arg jqFilter) ([]any, error) {
+	return jqWithArg(func(in, a any) (any, error) {
+		s, ok1 := in.(string)
+		x, ok2 := a.(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("needs a string input and argument")
+		}
+		return fn(s, x), nil
+	})
+}
+
+func jqStringFunc(fn func(string) string) func(in any) (any, error) {
+	return func(in any) (any, error) {
+		s, ok := in.(string)
+		if !ok {
+			return nil, fmt.Errorf("needs a string, not %s", jqType(in))
+		}
+		return fn(s), nil
+	}
+}
+
+func jqQuantify(in any, f jqFilter, anyOf bool) ([]any, error) {
+	elems, err := jqIterate(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		vs, err := f(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			if jqTruthy(v) == anyOf {
+				return []any{anyOf}, nil
+			}
+		}
+	}
+	return []any{!anyOf}, nil
+}
+
+func jqConst(v any) jqFilter {
+	return func(any) ([]any, error) { return []any{v}, nil }
+}
+
+func jqIndexConst(key string) jqFilter {
+	return func(in any) ([]any, error) {
+		v, err := jqIndex(in, key)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// jqIndex implements .[key]: object fields, array elements (negative
+// counts from the end) and null for anything missing.
+func jqIndex(in, key any) (any, error) {
+	switch v := in.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		if k, ok := key.(string); ok {
+			return v[k], nil
+		}
+	case []any:
+		if n, ok := key.(float64); ok {
+			i := int(n)
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return nil, nil
+			}
+			return v[i], nil
+		}
+	}
+	if k, ok := key.(string); ok {
+		return nil, fmt.Errorf("cannot index %s with %q", jqType(in), k)
+	}
+	return nil, fmt.Errorf("cannot index %s with %s", jqType(in), jqType(key))
+}
+
+// jqIterate implements .[]; object values come in key order.
+func jqIterate(in any) ([]any, error) {
+	switch v := in.(type) {
+	case []any:
+		return v, nil
+	case map[string]any:
+		var outs []any
+		for _, k := range jqSortedKeys(v) {
+			outs = append(outs, v[k])
+		}
+		return outs, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", jqType(in))
+}
+
+func jqArray(in any) ([]any, error) {
+	a, ok := in.([]any)
+	if !ok {
+		return nil, fmt.Errorf("needs an array, not %s", jqType(in))
+	}
+	return a, nil
+}
+
+func jqExtreme(in any, sign int) (any, error) {
+	a, err := jqArray(in)
+	if err != nil || len(a) == 0 {
+		return nil, err
+	}
+	best := a[0]
+	for _, v := range a[1:] {
+		if jqCompare(v, best)*sign > 0 {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+func jqSortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func jqTruthy(v any) bool {
+	return v != nil && v != false
+}
+
+func jqType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// jqCompare orders values like jq: null < false < true < numbers <
+// strings < arrays < objects.
+func jqCompare(a, b any) int {
+	rank := func(v any) int {
+		switch x := v.(type) {
+		case nil:
+			return 0
+		case bool:
+			if x {
+				return 2
+			}
+			return 1
+		case float64:
+			return 3
+		case string:
+			return 4
+		case []any:
+			return 5
+		}
+		return 6
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(x, b.(string))
+	case []any:
+		y := b.([]any)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := jqCompare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(x) - len(y)
+	case map[string]any:
+		if reflect.DeepEqual(a, b) {
+			return 0
+		}
+		da, _ := json.Marshal(a)
+		db, _ := json.Marshal(b)
+		return strings.Compare(string(da), string(db))
+	}
+	return 0
+}
+
+func jqAdd(a, b any) (any, error) {
+	switch x := a.(type) {
+	case nil:
+		return b, nil
+	case float64:
+		if y, ok := b.(float64); ok {
+			return x + y, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return x + y, nil
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			return append(slices.Clone(x), y...), nil
+		}
+	case map[string]any:
+		if y, ok := b.(map[string]any); ok {
+			out := make(map[string]any, len(x)+len(y))
+			for k, v := range x {
+				out[k] = v
+			}
+			for k, v := range y {
+				out[k] = v
+			}
+			return out, nil
+		}
+	}
+	if b == nil {
+		return a, nil
+	}
+	return nil, fmt.Errorf("cannot add %s and %s", jqType(a), jqType(b))
+}
+
+func jqSub(a, b any) (any, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return x - y, nil
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			var out []any
+			for _, v := range x {
+				if !slices.ContainsFunc(y, func(w any) bool { return jqCompare(v, w) == 0 }) {
+					out = append(out, v)
+				}
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot subtract %s from %s", jqType(b), jqType(a))
+}
+
+// jqContains is jq's contains: substrings, subsets of arrays and objects.
+func jqContains(a, b any) bool {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && strings.Contains(x, y)
+	case []any:
+		y, ok := b.([]any)
+		if !ok {
+			return false
+		}
+		for _, w := range y {
+			if !slices.ContainsFunc(x, func(v any) bool { return jqContains(v, w) }) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, w := range y {
+			if v, ok := x[k]; !ok || !jqContains(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return jqCompare(a, b) == 0
+}
@@ -0,0 +1,683 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var (
+	listSorts      = []string{"created", "updated", "popularity", "long-running"}
+	listDirections = []string{"asc", "desc"}
+	groupBys       = []string{"repo", "author", "label", "milestone", "owner"}
+)
+
+// Exit codes of `pr-view list` requested with --fail-on, on top of the usual
+// 0 for success, 1 for errors and 2 for usage errors.
+const (
+	exitPRs     = 3
+	exitPartial = 4
+)
+
+var failOnValues = []string{"prs", "errors"}
+
+// listOptions are the parsed flags of `pr-view list`, with defaults taken
+// from the config file.
+type listOptions struct {
+	cfg       *Config
+	limit     int
+	limitSet  bool
+	maxTotal  int
+	sort      string
+	direction string
+	showBots  bool
+	filters   []prFilter
+	columns   []column
+	needs     map[*enricher]bool
+	failOn    []string
+	quiet     bool
+	summary   bool
+	json      bool
+	plain     bool
+	issues    bool
+	groupBy   string
+	tree      bool
+	// preflight checks the token's scopes and SSO before fetching.
+	preflight bool
+	// incremental keeps each repo's open PRs on disk and fetches only the
+	// ones updated since.
+	incremental bool
+	// due, when set, picks the entries to fetch; the others are reported
+	// with errNotDue. The daemon leaves out repos that deliveries keep
+	// up to date.
+	due func(entry string) bool
+	// query is the compiled --query, applied to the --json output.
+	query    jqFilter
+	template *template.Template
+	// source fetches the PRs to list instead of the tracked entries, for
+	// dashboards that bypass the store.
+	source func(gh *GitHubClient, opts *listOptions) ([]PRResult, error)
+}
+
+// noun is what the listing counts, for messages.
+func (o *listOptions) noun() string {
+	if o.issues {
+		return "issue"
+	}
+	return "PR"
+}
+
+func (o *listOptions) addFilter(f prFilter) {
+	o.filters = append(o.filters, f)
+	for _, e := range f.needs {
+		o.needs[e] = true
+	}
+}
+
+// patternsFlag collects a repeatable flag's glob patterns, checking each
+// with valid.
+type patternsFlag struct {
+	patterns []string
+	valid    func(p string) error
+}
+
+func (f *patternsFlag) String() string { return strings.Join(f.patterns, ",") }
+
+func (f *patternsFlag) Set(s string) error {
+	if err := f.valid(s); err != nil {
+		return err
+	}
+	f.patterns = append(f.patterns, s)
+	return nil
+}
+
+// parseListFlags defines the list flags on fs, which may already carry a
+// command's own flags, and parses args.
+func parseListFlags(cfg *Config, fs *flag.FlagSet, args []string) (*listOptions, error) {
+	opts := &listOptions{cfg: cfg, needs: map[*enricher]bool{}}
+	fs.IntVar(&opts.limit, "limit", cfg.List.Limit, "maximum PRs per repo (default: GitHub's page size of 30)")
+	fs.IntVar(&opts.maxTotal, "max-total", cfg.List.MaxTotal, "maximum PRs shown across all repos")
+	fs.StringVar(&opts.sort, "sort", firstNonEmpty(cfg.List.Sort, "updated"), "sort by "+strings.Join(listSorts, "|"))
+	fs.StringVar(&opts.direction, "direction", firstNonEmpty(cfg.List.Direction, "desc"), "sort direction, asc|desc")
+	fs.BoolVar(&opts.showBots, "show-bots", cfg.List.ShowBots, "list dependency bot PRs individually instead of one summary row per repo")
+	cols := defaultColumns
+	if slaConfigured(cfg) {
+		cols = append(slices.Clip(cols), "sla")
+	}
+	if titleChecked(cfg) {
+		cols = append(slices.Clip(cols), "title-check")
+	}
+	columnNames := fs.String("columns", strings.Join(firstNonEmptySlice(cfg.List.Columns, cols), ","), "comma-separated columns: "+strings.Join(columnNames(), ", "))
+	ready := fs.Bool("ready", false, "only PRs that are approved, have green CI, no conflicts and aren't drafts")
+	blocked := fs.Bool("blocked-on-me", false, "only PRs where you or your team is a required code owner and you haven't reviewed")
+	slaBreach := fs.Bool("sla-breach", false, "only PRs breaking a review SLA")
+	head := &patternsFlag{valid: func(p string) error {
+		_, err := path.Match(p, "")
+		return err
+	}}
+	fs.Var(head, "head", "only PRs whose head branch matches this glob, e.g. 'release/*' (repeatable)")
+	touches := &patternsFlag{valid: func(string) error { return nil }}
+	fs.Var(touches, "touches", "only PRs changing files that match this CODEOWNERS-style pattern, e.g. 'services/payments/**' (repeatable)")
+	unfilled := fs.Bool("unfilled-template", false, "only PRs with an empty description or an unfilled PR template")
+	badTitle := fs.Bool("bad-title", false, "only PRs whose title matches none of the title_patterns")
+	fs.BoolVar(&opts.quiet, "quiet", false, "print only the number of PRs per repo")
+	fs.BoolVar(&opts.summary, "summary", false, "print a one-line total")
+	fs.BoolVar(&opts.issues, "issues", false, "list open issues of the tracked repos instead of PRs")
+	fs.StringVar(&opts.groupBy, "group-by", "", "print the table in sections by "+strings.Join(groupBys, "|"))
+	fs.BoolVar(&opts.tree, "tree", false, "print PRs nested by org and repo")
+	fs.BoolVar(&opts.preflight, "preflight", cfg.Auth.Preflight, "check the token's scopes and SAML SSO authorization per org before fetching")
+	fs.BoolVar(&opts.incremental, "incremental", cfg.List.Incremental, "fetch only PRs updated since the last run and merge them into the ones kept from it")
+	fs.BoolVar(&opts.plain, "plain", false, "print tab-separated rows without header, truncation or colors")
+	fs.BoolVar(&opts.json, "json", false, "print the PRs as a JSON array")
+	query := fs.String("query", "", "filter the JSON output with a jq expression, implies --json")
+	tmpl := fs.String("template", "", "format the PRs with a Go template, or @file to read one")
+	failOn := fs.String("fail-on", "", "exit 3 if PRs match (prs), 4 if some repos failed (errors); comma-separated")
+	unread := fs.Bool("unread", false, "only PRs with changes since you last marked them read")
+	rebase := fs.Bool("needs-rebase", false, "only PRs that are behind their base branch")
+	autoMerge := fs.Bool("auto-merge", false, "only PRs with auto-merge enabled")
+	missingSignOff := fs.Bool("missing-signoff", false, "only PRs with commits lacking their author's Signed-off-by")
+	unverifiedOnly := fs.Bool("unverified-only", false, "only PRs with commits lacking a verified signature")
+	// the last of the two wins, so a flag can override a view's
+	var origin *prFilter
+	fs.BoolFunc("external-only", "only PRs from forks", func(string) error { origin = &externalFilter; return nil })
+	fs.BoolFunc("internal-only", "only PRs from branches of the repo itself", func(string) error { origin = &internalFilter; return nil })
+	maxSize := fs.String("max-size", "", "only PRs up to this size: XS, S, M, L or XL")
+	// expanded before parsing, the flag is only defined for the usage text
+	fs.String("view", "", "apply a saved view from the config; other flags override it")
+	args, err := expandView(cfg, args)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if cfg.List.Format == "json" {
+		// list.format only sets the default, any output flag overrides it
+		explicit := false
+		fs.Visit(func(f *flag.Flag) {
+			explicit = explicit || slices.Contains([]string{"json", "template", "plain", "tree", "group-by", "quiet", "summary"}, f.Name)
+		})
+		opts.json = opts.json || !explicit
+	}
+	parsed, err := parseColumns(*columnNames)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if opts.issues {
+		explicit := false
+		fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "columns" })
+		if parsed, err = issueColumns(parsed, explicit); err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+	}
+	opts.columns = parsed
+	if *query != "" {
+		q, err := parseQuery(*query)
+		if err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+		opts.query, opts.json = q, true
+	}
+	if *tmpl != "" {
+		if opts.json {
+			err := errors.New("--template can't be combined with --json or --query")
+			fmt.Println(err)
+			return nil, err
+		}
+		t, err := parseTemplate(cfg, *tmpl)
+		if err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+		opts.template = t
+		// fetch what the template's column calls need, as if they were shown
+		if !opts.issues {
+			parsed = append(parsed, templateColumns(t)...)
+		}
+	}
+	if !opts.quiet && !opts.summary {
+		// counts don't render columns, so skip fetching what they need
+		for _, c := range parsed {
+			for _, e := range c.needs {
+				opts.needs[e] = true
+			}
+		}
+	}
+	if *ready {
+		opts.addFilter(readyFilter)
+	}
+	if *blocked {
+		opts.addFilter(blockedOnMeFilter)
+	}
+	if *rebase {
+		opts.addFilter(needsRebaseFilter)
+	}
+	if *autoMerge {
+		opts.addFilter(autoMergeFilter)
+	}
+	if *missingSignOff {
+		opts.addFilter(missingSignOffFilter)
+	}
+	if *unverifiedOnly {
+		opts.addFilter(unverifiedFilter)
+	}
+	if origin != nil {
+		opts.addFilter(*origin)
+	}
+	for _, v := range strings.Split(*failOn, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if !slices.Contains(failOnValues, v) {
+			err := fmt.Errorf("invalid --fail-on %q, expected prs, errors or both", v)
+			fmt.Println(err)
+			return nil, err
+		}
+		opts.failOn = append(opts.failOn, v)
+	}
+	if *unread {
+		opts.addFilter(unreadFilter)
+	}
+	if *slaBreach {
+		opts.addFilter(slaBreachFilter(cfg))
+	}
+	if len(head.patterns) > 0 {
+		opts.addFilter(headFilter(head.patterns))
+	}
+	if len(touches.patterns) > 0 {
+		opts.addFilter(touchesFilter(touches.patterns))
+	}
+	if *unfilled {
+		opts.addFilter(unfilledTemplateFilter)
+	}
+	if *badTitle {
+		opts.addFilter(badTitleFilter(cfg))
+	}
+	if *maxSize != "" {
+		size, err := parseSize(*maxSize)
+		if err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+		opts.addFilter(maxSizeFilter(size))
+	}
+	if opts.issues {
+		if err := checkIssueOptions(opts); err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+	}
+	if opts.tree && (opts.groupBy != "" || opts.json || opts.plain || opts.template != nil) {
+		err := errors.New("--tree can't be combined with --group-by, --json, --plain or --template")
+		fmt.Println(err)
+		return nil, err
+	}
+	if opts.groupBy != "" {
+		if !slices.Contains(groupBys, opts.groupBy) {
+			err := fmt.Errorf("invalid --group-by %q, expected one of %s", opts.groupBy, strings.Join(groupBys, ", "))
+			fmt.Println(err)
+			return nil, err
+		}
+		if opts.json || opts.plain || opts.template != nil {
+			err := errors.New("--group-by only applies to the table, not to --json, --plain or --template")
+			fmt.Println(err)
+			return nil, err
+		}
+		if opts.groupBy == "owner" {
+			opts.needs[enrichFiles], opts.needs[enrichOwners] = true, true
+		}
+	}
+	if !slices.Contains(listSorts, opts.sort) {
+		err := fmt.Errorf("invalid --sort %q, expected one of %s", opts.sort, strings.Join(listSorts, ", "))
+		fmt.Println(err)
+		return nil, err
+	}
+	if !slices.Contains(listDirections, opts.direction) {
+		err := fmt.Errorf("invalid --direction %q, expected asc or desc", opts.direction)
+		fmt.Println(err)
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "limit" {
+			opts.limitSet = true
+		}
+	})
+	return opts, nil
+}
+
+// queryFor returns the API query for one tracked entry.
+func (o *listOptions) queryFor(repo string) prQuery {
+	return prQuery{Limit: o.limitFor(repo), Sort: o.sort, Direction: o.direction, Issues: o.issues}
+}
+
+// limitFor returns the per-repo PR limit for a tracked entry: an explicit
+// --limit wins, then the repo's own setting, then list.limit.
+func (o *listOptions) limitFor(repo string) int {
+	if o.limitSet {
+		return o.limit
+	}
+	name, _, _ := strings.Cut(repo, "#")
+	for k, rs := range o.cfg.RepoSettings {
+		if strings.EqualFold(k, name) && rs.Limit > 0 {
+			return rs.Limit
+		}
+	}
+	return o.limit
+}
+
+// fetchAll fetches every tracked entry in parallel, returning results in
+// the order of repos. skip may veto an entry with the error to report for
+// it. Whole-repo entries that share an owner are collapsed into one GraphQL
+// query per owner, cutting the request count to the number of owners.
+func fetchAll(gh *GitHubClient, repos []string, queryFor func(repo string) prQuery, skip func(repo string) error) []PRResult {
+	results := make([]PRResult, len(repos))
+	byOwner := map[string][]int{}
+	var owners []string
+	var single []int
+	for i, r := range repos {
+		if err := skip(r); err != nil {
+			results[i] = PRResult{Repo: r, Err: err}
+			continue
+		}
+		if !canBatch(gh, r, queryFor(r)) {
+			single = append(single, i)
+			continue
+		}
+		owner := strings.ToLower(strings.SplitN(r, "/", 2)[0])
+		if _, ok := byOwner[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		byOwner[owner] = append(byOwner[owner], i)
+	}
+	var wg sync.WaitGroup
+	for _, owner := range owners {
+		idxs := byOwner[owner]
+		if len(idxs) < 2 {
+			single = append(single, idxs...)
+			continue
+		}
+		for len(idxs) > 0 {
+			chunk := idxs[:min(batchSize, len(idxs))]
+			idxs = idxs[len(chunk):]
+			wg.Add(1)
+			go func(chunk []int) {
+				defer wg.Done()
+				names := make([]string, len(chunk))
+				queries := make([]prQuery, len(chunk))
+				for j, i := range chunk {
+					names[j] = repos[i]
+					queries[j] = queryFor(repos[i])
+				}
+				start := time.Now()
+				ctx, s := startSpan(gh.ctx, "fetch batch", "repos", strings.Join(names, ","))
+				prs, errs := fetchBatch(gh.withContext(ctx), names, queries)
+				s.finish(errors.Join(errs...))
+				slog.Debug("batch fetched", "repos", names, "duration", time.Since(start).Round(time.Millisecond))
+				for j, i := range chunk {
+					results[i] = PRResult{Repo: repos[i], PRs: prs[j], Err: errs[j]}
+				}
+			}(chunk)
+		}
+	}
+	for _, i := range single {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			ctx, s := startSpan(gh.ctx, "fetch repo", "repo", repos[i])
+			prs, err := fetchPRs(gh.withContext(ctx), repos[i], queryFor(repos[i]))
+			s.set("prs", len(prs))
+			s.finish(err)
+			slog.Debug("repo fetched", "repo", repos[i], "prs", len(prs), "duration", time.Since(start).Round(time.Millisecond))
+			results[i] = PRResult{Repo: repos[i], PRs: prs, Err: err}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// capTotal trims results so at most max PRs remain in total, dropping repos
+// whose PRs were all cut, and returns the number of PRs dropped. max <= 0
+// means no cap.
+func capTotal(results []PRResult, max int) ([]PRResult, int) {
+	if max <= 0 {
+		return results, 0
+	}
+	var kept []PRResult
+	shown, hidden := 0, 0
+	for _, res := range results {
+		if keep := max - shown; len(res.PRs) > keep {
+			hidden += len(res.PRs) - keep
+			if keep == 0 {
+				continue
+			}
+			res.PRs = res.PRs[:keep]
+		}
+		shown += len(res.PRs)
+		kept = append(kept, res)
+	}
+	return kept, hidden
+}
+
+// exitCode maps the listing onto the --fail-on exit codes. Failed fetches
+// take precedence over matching PRs, since the PR count is incomplete then.
+func (o *listOptions) exitCode(results []PRResult, hidden int) int {
+	if slices.Contains(o.failOn, "errors") {
+		for _, res := range results {
+			if res.Err != nil {
+				return exitPartial
+			}
+		}
+	}
+	if slices.Contains(o.failOn, "prs") {
+		if hidden > 0 {
+			return exitPRs
+		}
+		for _, res := range results {
+			if len(res.PRs) > 0 || len(res.Bots) > 0 {
+				return exitPRs
+			}
+		}
+	}
+	return 0
+}
+
+// printCounts is the --quiet output: one line per repo with its PR count or
+// why it has none.
+func printCounts(results []PRResult) {
+	width := 0
+	for _, res := range results {
+		width = max(width, len(res.Repo))
+	}
+	for _, res := range results {
+		var open *breakerOpenError
+		switch {
+		case errors.As(res.Err, &open):
+			fmt.Printf("%-*s  (%s)\n", width, res.Repo, redact(res.Err))
+		case res.Err != nil:
+			fmt.Printf("%-*s  (error: %s)\n", width, res.Repo, redact(res.Err))
+		default:
+			fmt.Printf("%-*s  %d\n", width, res.Repo, len(res.PRs)+len(res.Bots))
+		}
+	}
+}
+
+// jsonPR is one element of the --json output.
+type jsonPR struct {
+	Repo   string `json:"repo"`
+	Pinned bool   `json:"pinned,omitempty"`
+	PullRequest
+}
+
+// listedPRs flattens results for --json and --template: every listed PR,
+// collapsed bot PRs included. Failed repos have no place in the list and
+// are logged instead.
+func listedPRs(results []PRResult) []jsonPR {
+	prs := []jsonPR{}
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Warn("fetching repo", "repo", res.Repo, "err", redact(res.Err))
+			continue
+		}
+		// single-PR entries are owner/repo#number
+		repo, _, _ := strings.Cut(res.Repo, "#")
+		for _, pr := range slices.Concat(res.PRs, res.Bots) {
+			prs = append(prs, jsonPR{Repo: repo, Pinned: res.Pinned, PullRequest: pr})
+		}
+	}
+	return prs
+}
+
+// printJSON is the --json output, optionally filtered by q.
+func printJSON(results []PRResult, q jqFilter) error {
+	prs := listedPRs(results)
+	if q == nil {
+		out, err := json.MarshalIndent(prs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	outs, err := runQuery(q, prs)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	return printQueryResults(outs)
+}
+
+// summaryLine is the --summary output, e.g. "7 PRs in 3 repos (1 failed)".
+func summaryLine(results []PRResult, noun string) string {
+	prs, repos, failed := 0, 0, 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+			continue
+		}
+		if n := len(res.PRs) + len(res.Bots); n > 0 {
+			prs += n
+			repos++
+		}
+	}
+	s := fmt.Sprintf("%d %s in %d %s", prs, plural(prs, noun), repos, plural(repos, "repo"))
+	if failed > 0 {
+		s += fmt.Sprintf(" (%d failed)", failed)
+	}
+	return s
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// errNoRepos is returned by collectPRs when nothing is tracked yet.
+var errNoRepos = errors.New("no repos configured. add one with: pr-view add owner/repo[#number]")
+
+// collectPRs runs the list pipeline shared by list and daemon: fetch the
+// tracked and pinned entries (or opts.source's), update breaker state, drop
+// closed single-PR entries and archived PRs, then enrich, filter and
+// collapse bots.
+func collectPRs(cfg *Config, gh *GitHubClient, opts *listOptions) (_ []PRResult, err error) {
+	ctx, s := startSpan(gh.ctx, "collect")
+	defer func() { s.finish(err) }()
+	// collecting only reads, so each request is needed once
+	ctx = withRunMemo(ctx)
+	gh = gh.withContext(ctx)
+	gh.notes = &notificationsState{}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("initializing state: %w", err)
+	}
+	st, err := states.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading state: %w", err)
+	}
+	var alive []PRResult
+	if opts.source != nil {
+		if alive, err = opts.source(gh, opts); err != nil {
+			return nil, err
+		}
+	} else if alive, err = fetchTracked(cfg, gh, opts, states, st); err != nil {
+		return nil, err
+	}
+	for _, f := range opts.filters {
+		if f.prepare == nil {
+			continue
+		}
+		if err := f.prepare(gh); err != nil {
+			return nil, fmt.Errorf("preparing --%s: %w", f.name, err)
+		}
+	}
+	dropArchived(alive, st.Archived)
+	ectx, es := startSpan(ctx, "enrich")
+	enrich(gh.withContext(ectx), alive, opts.needs)
+	es.finish(nil)
+	markUnread(alive, st.Seen)
+	alive = applyFilters(gh, alive, opts.filters)
+	if !opts.showBots {
+		collapseBots(alive)
+	}
+	return alive, nil
+}
+
+// fetchTracked fetches the tracked and pinned entries, updates breaker state
+// and drops single-PR entries that were closed.
+func fetchTracked(cfg *Config, gh *GitHubClient, opts *listOptions, states *StateStore, st *State) ([]PRResult, error) {
+	store, err := NewRepoStore()
+	if err != nil {
+		return nil, fmt.Errorf("initializing store: %w", err)
+	}
+	repos, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading repos: %w", err)
+	}
+	if len(repos) == 0 {
+		if r := actionsRepo(); r != "" {
+			repos = []string{r}
+		} else {
+			return nil, errNoRepos
+		}
+	}
+	brk := newBreaker(cfg.Breaker)
+	pins := st.Pins
+	if opts.issues {
+		// pins and single-PR entries have no issues to list
+		pins = nil
+		repos = slices.DeleteFunc(repos, func(r string) bool { return strings.Contains(r, "#") })
+	}
+	entries := withPins(repos, pins)
+	var blocked map[string]error
+	if opts.preflight {
+		blocked = preflight(cfg, gh, entries)
+	}
+	fetch := fetchAll
+	if opts.incremental {
+		fetch = fetchIncremental
+	}
+	results := fetch(gh, entries, opts.queryFor, func(repo string) error {
+		owner, _, _ := strings.Cut(repo, "/")
+		if err := blocked[strings.ToLower(owner)]; err != nil {
+			return err
+		}
+		if opts.due != nil && !opts.due(repo) {
+			return errNotDue
+		}
+		return brk.check(st, repo)
+	})
+	markPins(results, len(pins))
+	err = states.Update(func(st *State) error {
+		for _, res := range results {
+			var open *breakerOpenError
+			if !errors.As(res.Err, &open) && !errors.Is(res.Err, errNotDue) {
+				brk.record(st, res.Repo, res.Err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("saving state", "err", err)
+	}
+	// Automatically remove closed PR entries (owner/repo#number) from the store
+	var alive []PRResult
+	for _, res := range results {
+		removed := false
+		if strings.Contains(res.Repo, "#") {
+			if res.Err == nil && len(res.PRs) == 1 {
+				if strings.ToLower(res.PRs[0].State) != "open" && res.Pinned {
+					if err := unpinEntry(states, res.Repo); err == nil {
+						slog.Info("unpinned closed PR", "pr", res.Repo)
+					} else {
+						slog.Error("unpinning closed PR", "repo", res.Repo, "err", err)
+					}
+					removed = true
+				} else if strings.ToLower(res.PRs[0].State) != "open" {
+					if err := store.Remove(res.Repo); err == nil {
+						slog.Info("removed closed PR", "pr", res.Repo)
+					} else {
+						slog.Error("removing closed PR", "repo", res.Repo, "err", err)
+					}
+					removed = true
+				}
+			}
+		}
+		if !removed {
+			alive = append(alive, res)
+		}
+	}
+	return alive, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errNotDue marks entries a poll left out because webhook deliveries keep
+// their repo up to date.
+var errNotDue = errors.New("kept up to date by webhook deliveries")
+
+// liveRepo is when the daemon last heard about a repo, by delivery or by
+// polling it.
+type liveRepo struct {
+	Delivery time.Time `json:"delivery,omitzero"`
+	Poll     time.Time `json:"poll,omitzero"`
+}
+
+// liveStatus is what the daemon writes after every poll and delivery for
+// `daemon status`.
+type liveStatus struct {
+	At        time.Time            `json:"at"`
+	PID       int                  `json:"pid"`
+	Webhook   string               `json:"webhook,omitempty"`
+	Interval  Duration             `json:"interval"`
+	Reconcile Duration             `json:"reconcile,omitempty"`
+	Repos     map[string]*liveRepo `json:"repos"`
+}
+
+func liveStatusPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	// next to the HTTP cache, like the warm marker
+	return filepath.Join(filepath.Dir(dir), "daemon.json"), nil
+}
+
+func newLiveStatus(webhook string, interval, reconcile time.Duration) *liveStatus {
+	s := &liveStatus{PID: os.Getpid(), Webhook: webhook, Interval: Duration(interval), Repos: map[string]*liveRepo{}}
+	if webhook != "" {
+		s.Reconcile = Duration(reconcile)
+	}
+	return s
+}
+
+func (s *liveStatus) repo(name string) *liveRepo {
+	key := strings.ToLower(repoName(name))
+	r := s.Repos[key]
+	if r == nil {
+		r = &liveRepo{}
+		s.Repos[key] = r
+	}
+	return r
+}
+
+// pushed reports whether deliveries are arriving for repo: one came within
+// the reconcile interval. A repo whose deliveries stopped, or never came,
+// is polled every interval again.
+func (s *liveStatus) pushed(r *liveRepo, now time.Time) bool {
+	return s.Webhook != "" && now.Sub(r.Delivery) < time.Duration(s.Reconcile)
+}
+
+// due reports whether the next poll fetches entry: always for polled
+// repos, and once per reconcile interval for pushed ones, to catch
+// deliveries that never arrived. The slack keeps a tick that comes a bit
+// early from skipping a whole interval.
+func (s *liveStatus) due(entry string, now time.Time) bool {
+	r := s.repo(entry)
+	return !s.pushed(r, now) || now.Sub(r.Poll) >= time.Duration(s.Reconcile)-time.Duration(s.Interval)/2
+}
+
+// save writes the status for `daemon status`; failing to only costs the
+// status output.
+func (s *liveStatus) save() {
+	s.At = time.Now()
+	path, err := liveStatusPath()
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(s); err == nil {
+			err = writeFileAtomic(path, data, 0o600)
+		}
+	}
+	if err != nil {
+		slog.Warn("saving daemon status", "err", err)
+	}
+}
+
+// clearLiveStatus removes the status when the daemon stops.
+func clearLiveStatus() {
+	if path, err := liveStatusPath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// cmdDaemonStatus prints how fresh the running daemon's view of each repo
+// is, and whether deliveries or polls keep it so.
+func cmdDaemonStatus(cfg *Config, args []string) int {
+	if len(args) > 0 {
+		fmt.Println("usage: pr-view daemon status")
+		return 2
+	}
+	path, err := liveStatusPath()
+	if err != nil {
+		slog.Error("locating daemon status", "err", err)
+		return 1
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("the daemon isn't running")
+		return 0
+	}
+	if err != nil {
+		slog.Error("reading daemon status", "err", err)
+		return 1
+	}
+	var s liveStatus
+	if err := json.Unmarshal(data, &s); err != nil {
+		slog.Error("reading daemon status", "err", err)
+		return 1
+	}
+	fmt.Printf("daemon pid %d, polling every %s", s.PID, fmtDuration(time.Duration(s.Interval)))
+	if s.Webhook != "" {
+		fmt.Printf(", receiving webhooks on %s (pushed repos reconciled every %s)", s.Webhook, fmtDuration(time.Duration(s.Reconcile)))
+	}
+	fmt.Printf(", updated %s\n", fmtTime(cfg, s.At))
+	if len(s.Repos) == 0 {
+		return 0
+	}
+	repos := make([]string, 0, len(s.Repos))
+	width := len("REPO")
+	for name := range s.Repos {
+		repos = append(repos, name)
+		width = max(width, len(name))
+	}
+	slices.Sort(repos)
+	now := time.Now()
+	fmt.Printf("\n%-*s  %-5s  %-16s  %s\n", width, "REPO", "MODE", "LAST DELIVERY", "LAST POLL")
+	for _, name := range repos {
+		r := s.Repos[name]
+		mode := "poll"
+		if s.pushed(r, now) {
+			mode = "push"
+		}
+		fmt.Printf("%-*s  %-5s  %-16s  %s\n", width, name, mode, fmtTime(cfg, r.Delivery), fmtTime(cfg, r.Poll))
+	}
+	return 0
+}
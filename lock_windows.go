@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// LockFileEx and UnlockFileEx, see fileapi.h.
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = 33
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// tryLockFile locks the first byte of f exclusively, reporting false while
+// another handle holds it.
+func tryLockFile(f *os.File) (bool, error) {
+	var ol syscall.Overlapped
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileFailImmediately|lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r != 0 {
+		return true, nil
+	}
+	if errno, _ := err.(syscall.Errno); errno == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+func unlockFile(f *os.File) {
+	var ol syscall.Overlapped
+	procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+}
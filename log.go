@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// levelTrace is below debug, enabled by -vv for headers and retries.
+const levelTrace = slog.LevelDebug - 4
+
+// logLevel is shared by every handler so -v can lower it after setup.
+var logLevel = new(slog.LevelVar)
+
+// setupLogging installs the default slog logger: diagnostics go to w
+// (stderr), leaving stdout to command output. format is text or json.
+// Text lines carry timestamps only when stamped is set, as for log files.
+func setupLogging(w io.Writer, format string, stamped bool) error {
+	opts := &slog.HandlerOptions{Level: logLevel, ReplaceAttr: replaceLogAttr(format == "json" || stamped)}
+	var h slog.Handler
+	switch format {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (expected text or json)", format)
+	}
+	if inActions() && !stamped && format != "json" {
+		h = &actionsHandler{next: h, w: w}
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// replaceLogAttr redacts secrets from every attribute and names the trace
+// level. Without stamped it drops timestamps, which only clutter a terminal.
+func replaceLogAttr(stamped bool) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		switch {
+		case a.Key == slog.TimeKey && len(groups) == 0 && !stamped:
+			return slog.Attr{}
+		case a.Key == slog.LevelKey && len(groups) == 0:
+			if lvl, ok := a.Value.Any().(slog.Level); ok && lvl <= levelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+		case a.Value.Kind() == slog.KindString:
+			a.Value = slog.StringValue(redact(a.Value.String()))
+		case a.Value.Kind() == slog.KindAny:
+			a.Value = slog.StringValue(redact(a.Value.Any()))
+		}
+		return a
+	}
+}
+
+func logTrace(msg string, args ...any) {
+	slog.Log(context.Background(), levelTrace, msg, args...)
+}
+
+// logTransport logs every API request with its status, timing, cache use
+// and rate-limit headers. It sits on top of the cache and deduplication so
+// hits and shared responses show up.
+type logTransport struct {
+	next http.RoundTripper
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return t.next.RoundTrip(req)
+	}
+	for k, v := range req.Header {
+		logTrace("request header", "url", req.URL.String(), "header", k, "value", strings.Join(v, ", "))
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		slog.Debug("request failed", "method", req.Method, "url", req.URL.String(), "duration", elapsed, "err", err)
+		return nil, err
+	}
+	attrs := []any{"method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "duration", elapsed}
+	switch resp.Header.Get("X-From-Cache") {
+	case "":
+	case "warm":
+		attrs = append(attrs, "cache", "warm")
+	default:
+		attrs = append(attrs, "cache", "hit")
+	}
+	if resp.Header.Get("X-Deduplicated") != "" {
+		attrs = append(attrs, "dedup", "shared")
+	}
+	if rem := resp.Header.Get("X-RateLimit-Remaining"); rem != "" {
+		attrs = append(attrs, "ratelimit_remaining", rem)
+		if limit := resp.Header.Get("X-RateLimit-Limit"); limit != "" {
+			attrs = append(attrs, "ratelimit_limit", limit)
+		}
+		if reset, err := parseUnix(resp.Header.Get("X-RateLimit-Reset")); err == nil {
+			attrs = append(attrs, "ratelimit_reset", reset.Local().Format(time.TimeOnly))
+		}
+	}
+	slog.Debug("request", attrs...)
+	for k, v := range resp.Header {
+		logTrace("response header", "url", req.URL.String(), "header", k, "value", strings.Join(v, ", "))
+	}
+	return resp, nil
+}
+
+func parseUnix(s string) (time.Time, error) {
+	var sec int64
+	if _, err := fmt.Sscan(s, &sec); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogMaxSizeMB = 10
+	defaultLogMaxAge    = 7 * 24 * time.Hour
+	defaultLogBackups   = 5
+)
+
+// rotatingFile is an io.Writer appending to path that moves the file aside
+// to path.1, path.2, ... once it grows past maxSize bytes or gets older than
+// maxAge, keeping at most backups old files.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	backups int
+
+	f       *os.File
+	size    int64
+	started time.Time
+}
+
+func openRotatingFile(path string, maxSize int64, maxAge time.Duration, backups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxAge: maxAge, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, fi.Size()
+	// there's no portable creation time; an existing file's age counts
+	// from its last write, so a restart never rotates a fresh log
+	r.started = time.Now()
+	if fi.Size() > 0 {
+		r.started = fi.ModTime()
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tooBig := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	tooOld := r.maxAge > 0 && r.size > 0 && time.Since(r.started) > r.maxAge
+	if tooBig || tooOld {
+		if err := r.rotate(); err != nil {
+			// keep logging to the current file rather than losing lines
+			fmt.Fprintln(os.Stderr, "rotating log file:", err)
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.backups > 0 {
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return r.open()
+		}
+	} else {
+		os.Remove(r.path)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
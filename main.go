@@ -1,161 +1,151 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
-const repoFileName = "repos.json"
+type PullRequest struct {
+	NodeID    string     `json:"node_id"`
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body,omitempty"`
+	HTMLURL   string     `json:"html_url"`
+	State     string     `json:"state"`
+	User      User       `json:"user"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Labels    []Label    `json:"labels"`
+	Milestone *Milestone `json:"milestone,omitempty"`
+	Head      Ref        `json:"head"`
+	Base      Ref        `json:"base"`
+	Draft     bool       `json:"draft"`
+	// RequestedReviewers and RequestedTeams are the pending review
+	// requests; teams belong to the repo's organization.
+	RequestedReviewers []User `json:"requested_reviewers,omitempty"`
+	RequestedTeams     []Team `json:"requested_teams,omitempty"`
+	// Mergeable, MergeableState and the diffstat are only returned by the
+	// single-PR endpoint; Mergeable is nil while GitHub is still computing it.
+	Mergeable      *bool  `json:"mergeable,omitempty"`
+	MergeableState string `json:"mergeable_state,omitempty"`
+	Additions      int    `json:"additions,omitempty"`
+	Deletions      int    `json:"deletions,omitempty"`
+	ChangedFiles   int    `json:"changed_files,omitempty"`
+	// AutoMerge is set while auto-merge is enabled: the PR merges by itself
+	// once its requirements are met.
+	AutoMerge *AutoMerge `json:"auto_merge,omitempty"`
+
+	// Fields below are filled in by enrichers, not by the pulls endpoints.
+	Reviews        []review        `json:"reviews,omitempty"`
+	ReviewDecision string          `json:"review_decision,omitempty"`
+	CIState        string          `json:"ci_state,omitempty"`
+	Files          []string        `json:"files,omitempty"`
+	Owners         []string        `json:"owners,omitempty"`
+	RequiredChecks []requiredCheck `json:"required_checks,omitempty"`
+	BehindBy       int             `json:"behind_by,omitempty"`
+	Projects       []projectItem   `json:"projects,omitempty"`
+	ReadyAt        time.Time       `json:"ready_at,omitzero"`
+	FirstReviewAt  *time.Time      `json:"first_review_at,omitempty"`
+	Deployments    []deployment    `json:"deployments,omitempty"`
+	MergeQueue     *queueEntry     `json:"merge_queue,omitempty"`
+	Commits        []prCommit      `json:"commits,omitempty"`
+	TemplateIssues []string        `json:"template_issues,omitempty"`
+	NotifyReason   string          `json:"notify_reason,omitempty"`
 
-type RepoStore struct {
-	path string
+	// Unread is set from the local read state: the PR changed since it was
+	// last marked read.
+	Unread bool `json:"unread,omitempty"`
+	// Row is the PR's 1-based row in the listing, for `open N` and `show N`.
+	Row int `json:"-"`
 }
 
-func NewRepoStore() (*RepoStore, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-	dir := filepath.Join(home, ".config", "pr-view")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return nil, err
-	}
-	return &RepoStore{path: filepath.Join(dir, repoFileName)}, nil
+// AutoMerge is who enabled auto-merge on a PR and how it will merge.
+type AutoMerge struct {
+	EnabledBy   User   `json:"enabled_by"`
+	MergeMethod string `json:"merge_method"`
 }
 
-func (s *RepoStore) Load() ([]string, error) {
-	f, err := os.Open(s.path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
-		return nil, err
-	}
-	defer f.Close()
-	var repos []string
-	if err := json.NewDecoder(f).Decode(&repos); err != nil {
-		if errors.Is(err, io.EOF) {
-			return []string{}, nil
-		}
-		return nil, err
-	}
-	return repos, nil
+// Ref is the head or base branch of a pull request.
+type Ref struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+	// Repo is nil when the head's fork was deleted.
+	Repo *RefRepo `json:"repo"`
 }
 
-func (s *RepoStore) Save(repos []string) error {
-	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	return enc.Encode(repos)
+type RefRepo struct {
+	FullName string `json:"full_name"`
 }
 
-func (s *RepoStore) Add(repo string) error {
-	repo = strings.TrimSpace(repo)
-	if repo == "" {
-		return fmt.Errorf("empty repo")
-	}
-	// accept GitHub URLs and normalize them to owner/repo or owner/repo#number
-	if strings.Contains(repo, "github.com/") || strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
-		if u, err := url.Parse(repo); err == nil {
-			path := strings.Trim(u.Path, "/")
-			parts := strings.Split(path, "/")
-			if len(parts) >= 4 && parts[2] == "pull" {
-				// owner/repo/pull/NUMBER[/...]
-				if _, err := strconv.Atoi(parts[3]); err == nil {
-					repo = fmt.Sprintf("%s/%s#%s", parts[0], parts[1], parts[3])
-				}
-			} else if len(parts) >= 2 {
-				// owner/repo or github.com/owner/repo
-				repo = fmt.Sprintf("%s/%s", parts[0], parts[1])
-			}
-		}
-	}
-	// support owner/repo or owner/repo#number
-	repoPart := repo
-	if strings.Contains(repo, "#") {
-		parts := strings.SplitN(repo, "#", 2)
-		repoPart = strings.TrimSpace(parts[0])
-		numStr := strings.TrimSpace(parts[1])
-		if repoPart == "" || numStr == "" {
-			return fmt.Errorf("invalid format, expected owner/repo or owner/repo#number")
-		}
-		if _, err := strconv.Atoi(numStr); err != nil {
-			return fmt.Errorf("invalid pull request number: %s", numStr)
-		}
-	}
-	if !strings.Contains(repoPart, "/") {
-		return fmt.Errorf("repo must be in owner/repo format")
-	}
-	repos, err := s.Load()
-	if err != nil {
-		return err
-	}
-	for _, r := range repos {
-		if strings.EqualFold(r, repo) {
-			return fmt.Errorf("repo already exists")
-		}
+// headFork is the fork a PR comes from: its owner/repo, "(deleted fork)"
+// when the fork is gone, or "" for a branch of the repo itself.
+func (pr PullRequest) headFork() string {
+	switch {
+	case pr.Head.SHA == "": // issues have no head
+		return ""
+	case pr.Head.Repo == nil:
+		return "(deleted fork)"
+	case pr.Base.Repo != nil && strings.EqualFold(pr.Head.Repo.FullName, pr.Base.Repo.FullName):
+		return ""
 	}
-	repos = append(repos, repo)
-	return s.Save(repos)
+	return pr.Head.Repo.FullName
 }
 
-func (s *RepoStore) Remove(repo string) error {
-	repo = strings.TrimSpace(repo)
-	if repo == "" {
-		return fmt.Errorf("empty repo")
-	}
-	repos, err := s.Load()
-	if err != nil {
-		return err
-	}
-	idx := -1
-	for i, r := range repos {
-		if strings.EqualFold(r, repo) || r == repo {
-			idx = i
-			break
-		}
-	}
-	if idx == -1 {
-		return fmt.Errorf("repo not found")
-	}
-	repos = append(repos[:idx], repos[idx+1:]...)
-	return s.Save(repos)
+type Milestone struct {
+	Title string `json:"title"`
 }
 
-type PullRequest struct {
-	Number    int       `json:"number"`
-	Title     string    `json:"title"`
-	HTMLURL   string    `json:"html_url"`
-	State     string    `json:"state"`
-	User      User      `json:"user"`
-	CreatedAt time.Time `json:"created_at"`
+type Team struct {
+	Slug string `json:"slug"`
+}
+
+type Label struct {
+	Name string `json:"name"`
 }
 
 type User struct {
 	Login string `json:"login"`
+	// Type is "User" or "Bot".
+	Type string `json:"type"`
 }
 
 type PRResult struct {
 	Repo string
 	PRs  []PullRequest
-	Err  error
+	// Bots holds dependency PRs collapsed into a summary row.
+	Bots []PullRequest
+	// Pinned results come from `pr-view pin` and are listed first.
+	Pinned bool
+	Err    error
+}
+
+// prQuery controls how open PRs are listed.
+type prQuery struct {
+	// Limit caps the number of PRs, paginating as needed; zero leaves
+	// GitHub's default page size of 30.
+	Limit int
+	// Sort and Direction are passed to the pulls endpoint so the most
+	// relevant PRs come first when Limit cuts the list.
+	Sort      string
+	Direction string
+	// Issues lists the repo's open issues instead of its PRs.
+	Issues bool
 }
 
-func fetchPRs(repo string, token string) ([]PullRequest, error) {
+// fetchPRs returns the open PRs of owner/repo, or the single PR of
+// owner/repo#number.
+func fetchPRs(c *GitHubClient, repo string, q prQuery) ([]PullRequest, error) {
+	if q.Issues {
+		return fetchIssues(c, repo, q)
+	}
 	// repo may be owner/repo or owner/repo#number
 	repoPart := repo
 	var singlePR bool
@@ -176,38 +166,39 @@ func fetchPRs(repo string, token string) ([]PullRequest, error) {
 		return nil, fmt.Errorf("invalid repo: %s", repo)
 	}
 	owner, name := parts[0], parts[1]
-	var url string
 	if singlePR {
-		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", owner, name, prNumber)
-	} else {
-		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls?state=open", owner, name)
+		var pr PullRequest
+		if err := c.do("GET", fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, name, prNumber), nil, &pr); err != nil {
+			return nil, err
+		}
+		return []PullRequest{pr}, nil
 	}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	if token != "" {
-		req.Header.Set("Authorization", "token "+token)
+	path := fmt.Sprintf("/repos/%s/%s/pulls?state=open", owner, name)
+	if q.Sort != "" {
+		path += "&sort=" + q.Sort
 	}
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if q.Direction != "" {
+		path += "&direction=" + q.Direction
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("github API error: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	limit := q.Limit
+	if limit > 0 {
+		path += fmt.Sprintf("&per_page=%d", min(limit, 100))
 	}
-	dec := json.NewDecoder(resp.Body)
-	if singlePR {
-		var pr PullRequest
-		if err := dec.Decode(&pr); err != nil {
+	var prs []PullRequest
+	for path != "" {
+		var page []PullRequest
+		next, err := c.doPage("GET", path, nil, &page)
+		if err != nil {
 			return nil, err
 		}
-		return []PullRequest{pr}, nil
+		prs = append(prs, page...)
+		if limit <= 0 || len(prs) >= limit {
+			break
+		}
+		path = next
 	}
-	var prs []PullRequest
-	if err := dec.Decode(&prs); err != nil {
-		return nil, err
+	if limit > 0 && len(prs) > limit {
+		prs = prs[:limit]
 	}
 	return prs, nil
 }
@@ -219,12 +210,12 @@ func cmdAdd(args []string) int {
 	}
 	store, err := NewRepoStore()
 	if err != nil {
-		fmt.Println("error initializing store:", err)
+		slog.Error("initializing store", "err", err)
 		return 1
 	}
 	repo := args[0]
 	if err := store.Add(repo); err != nil {
-		fmt.Println("error adding repo:", err)
+		slog.Error("adding repo", "err", err)
 		return 1
 	}
 	fmt.Println("added", repo)
@@ -238,72 +229,156 @@ func cmdRemove(args []string) int {
 	}
 	store, err := NewRepoStore()
 	if err != nil {
-		fmt.Println("error initializing store:", err)
+		slog.Error("initializing store", "err", err)
 		return 1
 	}
 	repo := args[0]
 	if err := store.Remove(repo); err != nil {
-		fmt.Println("error removing repo:", err)
+		slog.Error("removing repo", "err", err)
 		return 1
 	}
 	fmt.Println("removed", repo)
 	return 0
 }
 
-func cmdList() int {
-	store, err := NewRepoStore()
+const configUsage = "usage: pr-view config <get [key]|set key value|unset key|edit|push|pull [gist-id]>"
+
+func cmdConfig(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 {
+		fmt.Println(configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "get":
+		return cmdConfigGet(args[1:])
+	case "set":
+		if len(args) != 3 {
+			fmt.Println("usage: pr-view config set key value")
+			return 2
+		}
+		return cmdConfigSet(args[1], &args[2])
+	case "unset":
+		if len(args) != 2 {
+			fmt.Println("usage: pr-view config unset key")
+			return 2
+		}
+		return cmdConfigSet(args[1], nil)
+	case "edit":
+		return cmdConfigEdit()
+	}
+	gh, err := newGitHubClient(cfg, hc)
 	if err != nil {
-		fmt.Println("error initializing store:", err)
+		slog.Error("reading token", "err", err)
 		return 1
 	}
-	repos, err := store.Load()
+	switch args[0] {
+	case "push":
+		g, err := pushConfig(gh)
+		if err != nil {
+			slog.Error("pushing config", "err", err)
+			return 1
+		}
+		fmt.Println("pushed config to", g.HTMLURL)
+	case "pull":
+		var gistID string
+		if len(args) > 1 {
+			gistID = args[1]
+		}
+		g, err := pullConfig(gh, gistID)
+		if err != nil {
+			slog.Error("pulling config", "err", err)
+			return 1
+		}
+		fmt.Println("pulled config from", g.HTMLURL)
+	default:
+		fmt.Println("unknown config command:", args[0])
+		fmt.Println(configUsage)
+		return 2
+	}
+	return 0
+}
+
+func cmdList(cfg *Config, hc *http.Client, args []string) int {
+	opts, err := parseListFlags(cfg, flag.NewFlagSet("list", flag.ContinueOnError), args)
+	if err != nil {
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
 	if err != nil {
-		fmt.Println("error loading repos:", err)
+		slog.Error("reading token", "err", err)
 		return 1
 	}
-	if len(repos) == 0 {
-		fmt.Println("no repos configured. add one with: pr-view add owner/repo[#number]")
+	return runList(cfg, gh, opts)
+}
+
+// runList collects and prints the listing as the options say, for list and
+// the dashboards built on it.
+func runList(cfg *Config, gh *GitHubClient, opts *listOptions) int {
+	ctx, root := startSpan(gh.ctx, "list")
+	defer root.finish(nil)
+	gh = gh.withContext(ctx)
+	alive, err := collectPRs(cfg, gh, opts)
+	if errors.Is(err, errNoRepos) && opts.json {
+		// still an empty array for whatever parses the output
+		fmt.Fprintln(os.Stderr, err)
+		if err := printJSON(nil, opts.query); err != nil {
+			slog.Error("printing JSON", "err", err)
+			return 1
+		}
 		return 0
 	}
-	token := os.Getenv("GITHUB_TOKEN")
-	var wg sync.WaitGroup
-	ch := make(chan PRResult, len(repos))
-	for _, r := range repos {
-		wg.Add(1)
-		go func(repo string) {
-			defer wg.Done()
-			prs, err := fetchPRs(repo, token)
-			ch <- PRResult{Repo: repo, PRs: prs, Err: err}
-		}(r)
-	}
-	wg.Wait()
-	close(ch)
-	var results []PRResult
-	for res := range ch {
-		results = append(results, res)
-	}
-	// Automatically remove closed PR entries (owner/repo#number) from the store
-	var alive []PRResult
-	for _, res := range results {
-		removed := false
-		if strings.Contains(res.Repo, "#") {
-			if res.Err == nil && len(res.PRs) == 1 {
-				if strings.ToLower(res.PRs[0].State) != "open" {
-					if err := store.Remove(res.Repo); err == nil {
-						fmt.Println("removed closed PR", res.Repo)
-					} else {
-						fmt.Println("error removing closed PR", res.Repo+":", err)
-					}
-					removed = true
-				}
-			}
+	if errors.Is(err, errNoRepos) {
+		fmt.Println(err)
+		return 0
+	}
+	if err != nil {
+		slog.Error("listing PRs", "err", err)
+		return 1
+	}
+	if inActions() {
+		actionsReport(cfg, alive, opts)
+	}
+	// counts cover every matching PR, --max-total only limits the table
+	switch {
+	case opts.summary:
+		fmt.Println(summaryLine(alive, opts.noun()))
+		return opts.exitCode(alive, 0)
+	case opts.quiet:
+		printCounts(alive)
+		return opts.exitCode(alive, 0)
+	}
+	alive, hidden := capTotal(alive, opts.maxTotal)
+	if opts.json {
+		if err := printJSON(alive, opts.query); err != nil {
+			slog.Error("printing JSON", "err", err)
+			return 1
 		}
-		if !removed {
-			alive = append(alive, res)
+		return opts.exitCode(alive, hidden)
+	}
+	if opts.template != nil {
+		if err := opts.template.Execute(os.Stdout, listedPRs(alive)); err != nil {
+			slog.Error("executing template", "err", err)
+			return 1
 		}
+		return opts.exitCode(alive, hidden)
 	}
-	printTable(alive)
-	return 0
+	_, s := startSpan(gh.ctx, "render", "columns", len(opts.columns))
+	rows := numberRows(alive)
+	if opts.tree {
+		printTree(cfg, alive, opts)
+	} else {
+		printTable(cfg, alive, opts)
+	}
+	s.finish(nil)
+	saveLastList(cfg, rows)
+	switch {
+	case hidden > 0 && opts.plain:
+		// keep stdout to rows only
+		fmt.Fprintf(os.Stderr, "%d more %ss not shown (--max-total %d)\n", hidden, opts.noun(), opts.maxTotal)
+	case hidden > 0:
+		fmt.Printf("... %d more %ss not shown (--max-total %d)\n", hidden, opts.noun(), opts.maxTotal)
+	}
+	return opts.exitCode(alive, hidden)
 }
 
 func truncate(s string, max int) string {
@@ -321,73 +396,383 @@ func truncate(s string, max int) string {
 	return string(rs[:max-3]) + "..."
 }
 
-func printTable(results []PRResult) {
-	// columns: Repo, PR, Title, Author, URL
-	rows := make([][3]string, 0)
+// prGroups are the --group-by sections a PR is listed in: one per label
+// with label, one per code owner of its changed files with owner, else
+// exactly one.
+func prGroups(groupBy string, res PRResult, pr PullRequest) []string {
+	switch groupBy {
+	case "repo":
+		return []string{repoName(res.Repo)}
+	case "author":
+		return []string{firstNonEmpty(pr.User.Login, "ghost")}
+	case "label":
+		var names []string
+		for _, l := range pr.Labels {
+			names = append(names, l.Name)
+		}
+		if len(names) == 0 {
+			return []string{"(no label)"}
+		}
+		return names
+	case "milestone":
+		if pr.Milestone == nil {
+			return []string{"(no milestone)"}
+		}
+		return []string{pr.Milestone.Title}
+	case "owner":
+		if len(pr.Owners) == 0 {
+			return []string{"(no code owner)"}
+		}
+		return pr.Owners
+	}
+	return []string{""}
+}
+
+// printTable prints the list table, or with --plain just the rows as
+// tab-separated fields without truncation or colors. With --group-by the
+// rows are printed in sections headed by the group and its count.
+func printTable(cfg *Config, results []PRResult, opts *listOptions) {
+	cols, plain := opts.columns, opts.plain
+	// rows that aren't a PR put their message in the title column, or the
+	// last one when title isn't shown
+	msgCol := len(cols) - 1
+	for i, c := range cols {
+		if c.name == "title" {
+			msgCol = i
+		}
+	}
+	message := func(repo, msg string) []string {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			if c.name == "repo" {
+				row[i] = repo
+			}
+		}
+		row[msgCol] = msg
+		return row
+	}
+	// rows by group in order of first appearance; rows that aren't a PR go
+	// in their repo's group, or in a last one without header
+	rows := make([][]string, 0)
+	groups := map[string][][]string{}
+	var order []string
+	counts := map[string]int{}
+	add := func(group string, row []string) {
+		if _, ok := groups[group]; !ok {
+			order = append(order, group)
+		}
+		groups[group] = append(groups[group], row)
+		rows = append(rows, row)
+	}
+	other := func(res PRResult) string {
+		if opts.groupBy == "repo" {
+			return repoName(res.Repo)
+		}
+		return ""
+	}
 	for _, res := range results {
+		var open *breakerOpenError
+		if errors.As(res.Err, &open) {
+			add(other(res), message(res.Repo, "("+redact(res.Err)+")"))
+			continue
+		}
 		if res.Err != nil {
-			rows = append(rows, [3]string{res.Repo, "", "(error: " + res.Err.Error() + ")"})
+			add(other(res), message(res.Repo, "(error: "+redact(res.Err)+")"))
 			continue
 		}
-		if len(res.PRs) == 0 {
-			rows = append(rows, [3]string{res.Repo, "", "(no open PRs)"})
+		if len(res.PRs) == 0 && len(res.Bots) == 0 {
+			if opts.groupBy == "" || opts.groupBy == "repo" {
+				add(other(res), message(res.Repo, "(no open "+opts.noun()+"s)"))
+			}
 			continue
 		}
 		for _, pr := range res.PRs {
-			rows = append(rows, [3]string{res.Repo, pr.HTMLURL, truncate(pr.Title, 60)})
+			row := make([]string, len(cols))
+			for i, c := range cols {
+				row[i] = c.value(cfg, res, pr)
+				if c.maxWidth > 0 && !plain {
+					row[i] = truncate(row[i], c.maxWidth)
+				}
+			}
+			for _, g := range prGroups(opts.groupBy, res, pr) {
+				add(g, row)
+				counts[g]++
+			}
+		}
+		if len(res.Bots) > 0 {
+			add(other(res), message(res.Repo, botSummary(res.Bots)))
+			counts[other(res)] += len(res.Bots)
 		}
 	}
+	if opts.groupBy != "" && opts.groupBy != "repo" {
+		// sections by name, then "(no label)" and the like, then the one
+		// without header
+		rank := func(g string) int {
+			switch {
+			case g == "":
+				return 2
+			case strings.HasPrefix(g, "("):
+				return 1
+			}
+			return 0
+		}
+		slices.SortFunc(order, func(a, b string) int {
+			if rank(a) != rank(b) {
+				return rank(a) - rank(b)
+			}
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+	}
 
-	// compute widths
-	widths := [3]int{4, 3, 5} // initial min widths
+	if plain {
+		fields := strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+		for _, r := range rows {
+			for i := range r {
+				r[i] = fields.Replace(ansiEscape.ReplaceAllString(r[i], ""))
+			}
+			fmt.Println(strings.Join(r, "\t"))
+		}
+		return
+	}
+
+	// compute widths, at least as wide as the header
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = len(c.header)
+	}
 	for _, r := range rows {
-		for i := 0; i < 3; i++ {
-			l := len([]rune(r[i]))
+		for i := range cols {
+			l := visibleLen(r[i])
 			if l > widths[i] {
 				widths[i] = l
 			}
 		}
 	}
 
-	// header
-	hdr := [3]string{"REPO", "URL", "TITLE"}
-	fmtStr := fmt.Sprintf("%%-%dv  %%-%dv  %%-%dv \n", widths[0], widths[1], widths[2])
-	fmt.Printf(fmtStr, hdr[0], hdr[1], hdr[2])
-
-	// separator
-	sep := ""
-	for i := 0; i < 3; i++ {
-		sep += strings.Repeat("-", widths[i])
-		if i < 4 {
-			sep += "  "
+	printRow := func(r []string) {
+		var b strings.Builder
+		for i := range cols {
+			b.WriteString(r[i] + strings.Repeat(" ", widths[i]-visibleLen(r[i])) + "  ")
+		}
+		fmt.Println(strings.TrimSuffix(b.String(), " "))
+	}
+	hdr := make([]string, len(cols))
+	sep := make([]string, len(cols))
+	for i, c := range cols {
+		hdr[i] = c.header
+		sep[i] = strings.Repeat("-", widths[i])
+	}
+	printRow(hdr)
+	fmt.Println(strings.Join(sep, "  ") + "  ")
+	if opts.groupBy == "" {
+		for _, r := range rows {
+			printRow(r)
 		}
+		return
 	}
-	fmt.Println(sep)
-
-	for _, r := range rows {
-		fmt.Printf(fmtStr, r[0], r[1], r[2])
+	for i, g := range order {
+		if i > 0 {
+			fmt.Println()
+		}
+		switch n := counts[g]; {
+		case g == "":
+		case n == 0: // a repo that failed or has none
+			fmt.Println(g)
+		default:
+			fmt.Printf("%s (%d %s)\n", g, n, plural(n, opts.noun()))
+		}
+		for _, r := range groups[g] {
+			printRow(r)
+		}
 	}
 }
 
+const usage = "usage: pr-view [-v|-vv] [--log-format text|json] [--insecure-skip-verify] [--timeout 15s] [--retries 2] [--time-style relative|absolute] [--time-format FORMAT] [--tz UTC] <add|remove|list|issues|org|team|show|open|copy|pin|unpin|mark-read|mark-unread|archive|archived|project|threads|reply|resolve|react|suggest-reviewers|review-load|nudge|bulk|digest|snapshot|checks|queue|heatmap|subscribe|unsubscribe|inbox|config|export|import|api|deps|daemon|webhook|doctor|ratelimit|version>"
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("usage: pr-view <add|remove|list>")
+	global := flag.NewFlagSet("pr-view", flag.ContinueOnError)
+	insecure := global.Bool("insecure-skip-verify", false, "disable TLS certificate verification")
+	timeout := global.Duration("timeout", 0, "timeout per request attempt")
+	retries := global.Int("retries", -1, "retries for failed requests")
+	verbose := global.Bool("verbose", false, "log requests, status codes, timing and cache hits to stderr")
+	global.BoolVar(verbose, "v", false, "shorthand for --verbose")
+	veryVerbose := global.Bool("vv", false, "like --verbose, plus headers and retries")
+	logFormat := global.String("log-format", "", "diagnostics format on stderr, text or json (default: log.format or text)")
+	timeStyle := global.String("time-style", "", "show timestamps relative or absolute (default: time.style or relative)")
+	timeFormat := global.String("time-format", "", "strftime-style format of absolute timestamps (default: time.format)")
+	tz := global.String("tz", "", "time zone of absolute timestamps, e.g. UTC (default: time.timezone or local)")
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if global.NArg() < 1 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	// log with the flag's format until the config is loaded
+	if err := setupLogging(os.Stderr, *logFormat, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cmd := global.Arg(0)
+	args := global.Args()[1:]
+	cfg, err := LoadConfig()
+	loadErr := err
+	if err != nil {
+		if cmd != "doctor" {
+			slog.Error("loading config", "err", err)
+			os.Exit(1)
+		}
+		cfg = &Config{} // doctor reports the broken config itself
+	}
+	actionsDefaults(cfg)
+	// commands that log elsewhere, like the daemon, pick the format up here
+	cfg.Log.Format = firstNonEmpty(*logFormat, cfg.Log.Format)
+	if err := setupLogging(os.Stderr, cfg.Log.Format, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := logLevel.UnmarshalText([]byte(firstNonEmpty(cfg.Log.Level, "info"))); err != nil {
+		slog.Error("invalid log.level", "err", err)
+		os.Exit(1)
+	}
+	switch {
+	case *veryVerbose:
+		logLevel.Set(levelTrace)
+	case *verbose:
+		logLevel.Set(slog.LevelDebug)
+	}
+	if *insecure {
+		cfg.Network.InsecureSkipVerify = true
+	}
+	if *timeout > 0 {
+		cfg.Network.Timeout = Duration(*timeout)
+	}
+	if *retries >= 0 {
+		cfg.Network.Retries = retries
+	}
+	cfg.Time.Style = firstNonEmpty(*timeStyle, cfg.Time.Style)
+	cfg.Time.Format = firstNonEmpty(*timeFormat, cfg.Time.Format)
+	cfg.Time.Timezone = firstNonEmpty(*tz, cfg.Time.Timezone)
+	if err := validateTime(cfg.Time); err != nil {
+		fmt.Println(err)
 		os.Exit(2)
 	}
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	if cfg.Network.InsecureSkipVerify {
+		slog.Warn("TLS certificate verification is disabled")
+	}
+	hc, err := newHTTPClient(cfg)
+	if err != nil {
+		slog.Error("initializing HTTP client", "err", err)
+		os.Exit(1)
+	}
+	setupTracing(cfg.Tracing)
+	code := dispatch(cfg, hc, cmd, args, loadErr, false)
+	if cfg.UpdateCheck && cmd != "version" {
+		backgroundUpdateCheck(cfg)
+	}
+	flushTraces()
+	os.Exit(code)
+}
+
+// dispatch runs command cmd. Unknown commands are looked up in the
+// aliases; aliased is set while running an expansion, so aliases can't
+// recurse.
+func dispatch(cfg *Config, hc *http.Client, cmd string, args []string, loadErr error, aliased bool) int {
 	var code int
 	switch cmd {
 	case "add":
 		code = cmdAdd(args)
 	case "list":
-		code = cmdList()
+		code = cmdList(cfg, hc, args)
+	case "team":
+		return cmdTeam(cfg, hc, args)
+	case "org":
+		return cmdOrg(cfg, hc, args)
+	case "issues":
+		code = cmdIssues(cfg, hc, args)
 	case "remove":
 		code = cmdRemove(args)
+	case "config":
+		code = cmdConfig(cfg, hc, args)
+	case "export":
+		return cmdExport(cfg, args)
+	case "import":
+		return cmdImport(cfg, args)
+	case "show":
+		code = cmdShow(cfg, hc, args)
+	case "mark-read", "mark-unread":
+		code = cmdMarkRead(cfg, hc, args, cmd == "mark-read")
+	case "archive":
+		code = cmdArchive(cfg, args)
+	case "archived":
+		code = cmdArchived(cfg, args)
+	case "pin", "unpin":
+		code = cmdPin(cfg, args, cmd == "pin")
+	case "project":
+		code = cmdProject(cfg, hc, args)
+	case "threads":
+		code = cmdThreads(cfg, hc, args)
+	case "reply":
+		code = cmdReply(cfg, hc, args)
+	case "resolve":
+		code = cmdResolve(cfg, hc, args)
+	case "react":
+		code = cmdReact(cfg, hc, args)
+	case "open":
+		code = cmdOpen(cfg, hc, args)
+	case "copy":
+		code = cmdCopy(cfg, hc, args)
+	case "heatmap":
+		return cmdHeatmap(cfg, hc, args)
+	case "suggest-reviewers":
+		return cmdSuggestReviewers(cfg, hc, args)
+	case "review-load":
+		return cmdReviewLoad(cfg, hc, args)
+	case "nudge":
+		return cmdNudge(cfg, hc, args)
+	case "bulk":
+		return cmdBulk(cfg, hc, args)
+	case "digest":
+		return cmdDigest(cfg, hc, args)
+	case "snapshot":
+		return cmdSnapshot(cfg, hc, args)
+	case "queue":
+		code = cmdQueue(cfg, hc, args)
+	case "checks":
+		code = cmdChecks(cfg, hc, args)
+	case "subscribe", "unsubscribe":
+		code = cmdSubscribe(cfg, hc, args, cmd == "subscribe")
+	case "inbox":
+		code = cmdInbox(cfg, hc, args)
+	case "daemon":
+		code = cmdDaemon(cfg, hc, args)
+	case "webhook":
+		code = cmdWebhook(cfg, hc, args)
+	case "ratelimit":
+		code = cmdRateLimit(cfg, hc, args)
+	case "version":
+		code = cmdVersion(args)
+	case "doctor":
+		code = cmdDoctor(cfg, hc, loadErr)
+	case "deps":
+		code = cmdDeps(cfg, hc, args)
+	case "api":
+		gh, err := newGitHubClient(cfg, hc)
+		if err != nil {
+			slog.Error("reading token", "err", err)
+			return 1
+		}
+		code = cmdAPI(gh, args)
 	default:
+		if expansion, ok := cfg.Aliases[cmd]; ok && !aliased {
+			return runAlias(cfg, hc, cmd, expansion, args, loadErr)
+		}
 		fmt.Println("unknown command:", cmd)
-		fmt.Println("usage: pr-view <add|remove|list>")
+		if _, ok := cfg.Aliases[cmd]; ok {
+			fmt.Println("aliases can't expand to other aliases")
+		}
+		fmt.Println(usage)
 		code = 2
 	}
-	os.Exit(code)
+	return code
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// queueEntry is a PR's place in its base branch's merge queue. State is
+// GraphQL's MergeQueueEntryState, lowercased: queued, awaiting_checks,
+// mergeable, unmergeable or locked.
+type queueEntry struct {
+	Position int    `json:"position"`
+	State    string `json:"state"`
+}
+
+// enrichMergeQueue sets the PR's merge queue entry, nil when it isn't
+// queued or the repo doesn't use a merge queue.
+var enrichMergeQueue = &enricher{name: "merge queue", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	owner, name, _ := strings.Cut(repo, "/")
+	const query = `query($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) { pullRequest(number: $number) { mergeQueueEntry { position state } } }
+}`
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				MergeQueueEntry *queueEntry `json:"mergeQueueEntry"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	if err := c.graphql(query, map[string]any{"owner": owner, "name": name, "number": pr.Number}, &data); err != nil {
+		return err
+	}
+	pr.MergeQueue = data.Repository.PullRequest.MergeQueueEntry
+	if pr.MergeQueue != nil {
+		pr.MergeQueue.State = strings.ToLower(pr.MergeQueue.State)
+	}
+	return nil
+}}
+
+// queueLabel renders a queue entry as e.g. "#2 awaiting checks", red when
+// the PR can't merge, or "-" when it isn't queued.
+func queueLabel(e *queueEntry) string {
+	if e == nil {
+		return "-"
+	}
+	label := fmt.Sprintf("#%d %s", e.Position, strings.ReplaceAll(e.State, "_", " "))
+	if e.State == "unmergeable" {
+		return paint(ansiRed, label)
+	}
+	return label
+}
+
+const queueUsage = "usage: pr-view queue [owner/repo] [--branch name]"
+
+// cmdQueue lists the merge queue of a repo's branch, the default branch
+// unless --branch is given. The repo may be left out when only one is
+// tracked.
+func cmdQueue(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("queue", flag.ContinueOnError)
+	branch := fs.String("branch", "", "base branch of the queue (default: the repo's default branch)")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) > 1 {
+		fmt.Println(queueUsage)
+		return 2
+	}
+	var repo string
+	if len(positional) == 1 {
+		repo = positional[0]
+	} else {
+		store, err := NewRepoStore()
+		if err != nil {
+			slog.Error("initializing store", "err", err)
+			return 1
+		}
+		repos, err := store.Repos()
+		if err != nil {
+			slog.Error("loading repos", "err", err)
+			return 1
+		}
+		if len(repos) != 1 {
+			fmt.Println("name the repo, more than one is tracked:", strings.Join(repos, ", "))
+			fmt.Println(queueUsage)
+			return 2
+		}
+		repo = repos[0]
+	}
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		fmt.Println(queueUsage)
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+
+	const query = `query($owner: String!, $name: String!, $branch: String) {
+	repository(owner: $owner, name: $name) {
+		mergeQueue(branch: $branch) {
+			entries(first: 100) {
+				nodes {
+					position state enqueuedAt
+					pullRequest { number title url author { login } }
+				}
+			}
+		}
+	}
+}`
+	vars := map[string]any{"owner": owner, "name": name, "branch": nil}
+	if *branch != "" {
+		vars["branch"] = *branch
+	}
+	var data struct {
+		Repository struct {
+			MergeQueue *struct {
+				Entries struct {
+					Nodes []struct {
+						queueEntry
+						EnqueuedAt  time.Time `json:"enqueuedAt"`
+						PullRequest struct {
+							Number int    `json:"number"`
+							Title  string `json:"title"`
+							URL    string `json:"url"`
+							Author *struct {
+								Login string `json:"login"`
+							} `json:"author"`
+						} `json:"pullRequest"`
+					} `json:"nodes"`
+				} `json:"entries"`
+			} `json:"mergeQueue"`
+		} `json:"repository"`
+	}
+	if err := gh.graphql(query, vars, &data); err != nil {
+		slog.Error("fetching merge queue", "err", err)
+		return 1
+	}
+	where := repo
+	if *branch != "" {
+		where += " " + *branch
+	}
+	mq := data.Repository.MergeQueue
+	if mq == nil {
+		fmt.Println("no merge queue on", where)
+		return 0
+	}
+	if len(mq.Entries.Nodes) == 0 {
+		fmt.Println("merge queue of", where, "is empty")
+		return 0
+	}
+	labels, width := make([]string, len(mq.Entries.Nodes)), 0
+	for i, n := range mq.Entries.Nodes {
+		n.State = strings.ToLower(n.State)
+		labels[i] = queueLabel(&n.queueEntry)
+		width = max(width, displayWidth(labels[i]))
+	}
+	for i, n := range mq.Entries.Nodes {
+		author := "ghost" // deleted users
+		if n.PullRequest.Author != nil {
+			author = n.PullRequest.Author.Login
+		}
+		fmt.Printf("%s  %s  %s (%s, queued %s)\n", padWidth(labels[i], width, false), n.PullRequest.URL,
+			truncate(n.PullRequest.Title, 60), author, fmtTime(cfg, n.EnqueuedAt))
+	}
+	return 0
+}
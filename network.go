@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// newBaseTransport builds the transport underneath the response cache from
+// the network settings. Without configuration it behaves like
+// http.DefaultTransport, which already honors HTTPS_PROXY and NO_PROXY.
+func newBaseTransport(cfg NetworkConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("network.proxy: %w", err)
+		}
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Hostname(), cfg.NoProxy) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+	if cfg.CABundle == "" && !cfg.InsecureSkipVerify {
+		return t, nil
+	}
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("network.ca_bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("network.ca_bundle: no certificates found in %s", cfg.CABundle)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	t.TLSClientConfig = tlsCfg
+	return t, nil
+}
+
+// bypassProxy reports whether host matches a no_proxy entry. Entries match
+// the host itself and its subdomains; "*" matches everything.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	for _, p := range noProxy {
+		p = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(p), "."))
+		if p == "" {
+			continue
+		}
+		if p == "*" || host == p || strings.HasSuffix(host, "."+p) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultTimeout = 15 * time.Second
+	defaultRetries = 2
+)
+
+var defaultRetryOn = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// retryTransport applies a timeout to each attempt and retries idempotent
+// requests that fail with a network error or a retryable status, backing
+// off exponentially between attempts.
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	retryOn []int
+}
+
+func newRetryTransport(next http.RoundTripper, cfg NetworkConfig) *retryTransport {
+	t := &retryTransport{next: next, timeout: defaultTimeout, retries: defaultRetries, retryOn: defaultRetryOn}
+	if cfg.Timeout > 0 {
+		t.timeout = time.Duration(cfg.Timeout)
+	}
+	if cfg.Retries != nil {
+		t.retries = *cfg.Retries
+	}
+	if cfg.RetryOn != nil {
+		t.retryOn = cfg.RetryOn
+	}
+	return t
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+		resp, err := t.next.RoundTrip(req.WithContext(ctx))
+		retry := idempotent && attempt < t.retries && req.Context().Err() == nil &&
+			(err != nil || slices.Contains(t.retryOn, resp.StatusCode))
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// the attempt's deadline must keep running while the caller
+			// reads the body
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+		backoff := min(500*time.Millisecond<<attempt, 8*time.Second)
+		if err != nil {
+			logTrace("retrying request", "method", req.Method, "url", req.URL.String(), "backoff", backoff, "err", err)
+		} else {
+			logTrace("retrying request", "method", req.Method, "url", req.URL.String(), "backoff", backoff, "status", resp.StatusCode)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultNudgeMessage = "Friendly reminder: this PR has had no activity for {age}. {reviewers}, could you take a look?"
+
+// nudgeMentions are who a reminder is for: the pending reviewers and
+// teams, or the author when no review is pending.
+func nudgeMentions(repo string, pr PullRequest) string {
+	owner, _, _ := strings.Cut(repo, "/")
+	var mentions []string
+	for _, u := range pr.RequestedReviewers {
+		mentions = append(mentions, "@"+u.Login)
+	}
+	for _, t := range pr.RequestedTeams {
+		mentions = append(mentions, "@"+owner+"/"+t.Slug)
+	}
+	if len(mentions) == 0 {
+		return "@" + pr.User.Login
+	}
+	return strings.Join(mentions, " ")
+}
+
+// nudgeMessage fills in the placeholders of a reminder.
+func nudgeMessage(msg, repo string, pr PullRequest, idle time.Duration) string {
+	return strings.NewReplacer(
+		"{author}", "@"+pr.User.Login,
+		"{reviewers}", nudgeMentions(repo, pr),
+		"{age}", fmtDuration(idle),
+		"{repo}", repo,
+		"{number}", strconv.Itoa(pr.Number),
+	).Replace(msg)
+}
+
+// commentOnPR posts a comment on a PR's conversation.
+func commentOnPR(c *GitHubClient, repo string, number int, body string) error {
+	return c.do("POST", fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, nil)
+}
+
+// reRequestReviews requests the pending reviews of a PR again, which
+// notifies the reviewers anew.
+func reRequestReviews(c *GitHubClient, repo string, pr PullRequest) error {
+	body := map[string][]string{"reviewers": {}, "team_reviewers": {}}
+	for _, u := range pr.RequestedReviewers {
+		body["reviewers"] = append(body["reviewers"], u.Login)
+	}
+	for _, t := range pr.RequestedTeams {
+		body["team_reviewers"] = append(body["team_reviewers"], t.Slug)
+	}
+	return c.do("POST", fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repo, pr.Number), body, nil)
+}
+
+const nudgeUsage = "usage: pr-view nudge [--older-than 7d] [--message text] [--re-request] [--dry-run] [list filters]"
+
+// cmdNudge reminds the reviewers of stale PRs, those without activity for
+// --older-than, with a comment or by re-requesting their reviews. The list
+// filters narrow the sweep; drafts are left alone.
+func cmdNudge(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("nudge", flag.ContinueOnError)
+	olderThan := time.Duration(cfg.Nudge.OlderThan)
+	if olderThan <= 0 {
+		olderThan = 7 * 24 * time.Hour
+	}
+	fs.Func("older-than", "nudge PRs without activity for this long, e.g. 7d or 36h (default: nudge.older_than or 7d)", func(s string) error {
+		d, err := parseDuration(s)
+		if err == nil && d <= 0 {
+			err = errors.New("must be positive")
+		}
+		olderThan = d
+		return err
+	})
+	message := fs.String("message", firstNonEmpty(cfg.Nudge.Message, defaultNudgeMessage), "reminder comment; {author}, {reviewers}, {age}, {repo} and {number} are replaced")
+	reRequest := fs.Bool("re-request", cfg.Nudge.ReRequest, "re-request the pending reviews instead of commenting, where there are any")
+	dryRun := fs.Bool("dry-run", false, "show what would be done without doing it")
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil || fs.NArg() > 0 {
+		fmt.Println(nudgeUsage)
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("nudge works on PRs, --issues doesn't apply")
+		return 2
+	}
+	// least recently updated first, so --limit can't cut off the stale ones
+	opts.sort, opts.direction = "updated", "asc"
+	if !opts.limitSet {
+		opts.limit = 100
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	results, err := collectPRs(cfg, gh, opts)
+	if errors.Is(err, errNoRepos) {
+		fmt.Println(err)
+		return 0
+	}
+	if err != nil {
+		slog.Error("listing PRs", "err", err)
+		return 1
+	}
+
+	code, nudged := 0, 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("listing", "repo", res.Repo, "err", redact(res.Err))
+			code = 1
+			continue
+		}
+		repo := repoName(res.Repo)
+		for _, pr := range res.PRs {
+			idle := time.Since(pr.UpdatedAt)
+			if pr.Draft || strings.ToLower(pr.State) != "open" || idle < olderThan {
+				continue
+			}
+			ref := fmt.Sprintf("%s#%d", repo, pr.Number)
+			pending := len(pr.RequestedReviewers)+len(pr.RequestedTeams) > 0
+			action, text := "comment on", nudgeMessage(*message, repo, pr, idle)
+			if *reRequest && pending {
+				action, text = "re-request reviews on", nudgeMentions(repo, pr)
+			}
+			desc := fmt.Sprintf("%s (idle %s) %s", ref, fmtDuration(idle), truncate(pr.Title, 50))
+			if *dryRun {
+				fmt.Printf("would %s %s: %s\n", action, desc, text)
+				continue
+			}
+			if *reRequest && pending {
+				err = reRequestReviews(gh, repo, pr)
+			} else {
+				err = commentOnPR(gh, repo, pr.Number, text)
+			}
+			if err != nil {
+				slog.Error("trying to "+action, "pr", ref, "err", err)
+				code = 1
+				continue
+			}
+			nudged++
+			fmt.Printf("nudged %s: %s\n", desc, text)
+		}
+	}
+	if !*dryRun {
+		fmt.Printf("%d stale %s nudged\n", nudged, plural(nudged, "PR"))
+	}
+	return code
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// orgRepos returns the unarchived repos of an org that have open PRs, or
+// open issues with issues set, following every page. Repos without any are
+// left out so the table doesn't list hundreds of empty ones.
+func orgRepos(c *GitHubClient, org string, issues bool) ([]string, error) {
+	const query = `query($org: String!, $after: String) {
+	organization(login: $org) {
+		repositories(first: 100, after: $after, orderBy: {field: NAME, direction: ASC}) {
+			pageInfo { hasNextPage endCursor }
+			nodes {
+				nameWithOwner
+				isArchived
+				pullRequests(states: OPEN) { totalCount }
+				issues(states: OPEN) { totalCount }
+			}
+		}
+	}
+}`
+	vars := map[string]any{"org": org}
+	var repos []string
+	for {
+		var data struct {
+			Organization *struct {
+				Repositories struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						NameWithOwner string `json:"nameWithOwner"`
+						IsArchived    bool   `json:"isArchived"`
+						PullRequests  struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"pullRequests"`
+						Issues struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"issues"`
+					} `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+		if err := c.graphql(query, vars, &data); err != nil {
+			return nil, err
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("organization %s not found", org)
+		}
+		for _, n := range data.Organization.Repositories.Nodes {
+			open := n.PullRequests.TotalCount
+			if issues {
+				open = n.Issues.TotalCount
+			}
+			if !n.IsArchived && open > 0 {
+				repos = append(repos, n.NameWithOwner)
+			}
+		}
+		if !data.Organization.Repositories.PageInfo.HasNextPage {
+			return repos, nil
+		}
+		vars["after"] = data.Organization.Repositories.PageInfo.EndCursor
+	}
+}
+
+const orgUsage = "usage: pr-view org <org> [list flags]"
+
+// cmdOrg lists the open PRs of every repo in an org, without tracking them,
+// using the filters, columns and output options of list.
+func cmdOrg(cfg *Config, hc *http.Client, args []string) int {
+	var org string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		org, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("org", flag.ContinueOnError)
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil {
+		return 2
+	}
+	if org == "" && fs.NArg() == 1 {
+		org = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		org = ""
+	}
+	if org == "" || strings.Contains(org, "/") {
+		fmt.Println(orgUsage)
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	opts.source = func(gh *GitHubClient, opts *listOptions) ([]PRResult, error) {
+		repos, err := orgRepos(gh, org, opts.issues)
+		if err != nil {
+			return nil, fmt.Errorf("listing repos of %s: %w", org, err)
+		}
+		slog.Debug("org repos with open items", "org", org, "repos", len(repos))
+		return fetchAll(gh, repos, opts.queryFor, func(string) error { return nil }), nil
+	}
+	return runList(cfg, gh, opts)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// cmdPin pins or unpins PRs so `list` always shows them first; with no
+// arguments it lists the pins.
+func cmdPin(cfg *Config, args []string, pin bool) int {
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	if len(args) == 0 && pin {
+		st, err := states.Load()
+		if err != nil {
+			slog.Error("loading state", "err", err)
+			return 1
+		}
+		for _, p := range st.Pins {
+			fmt.Println(p)
+		}
+		return 0
+	}
+	if len(args) != 1 {
+		fmt.Println("usage: pr-view pin|unpin owner/repo#number|<PR_URL>")
+		return 2
+	}
+	entry, err := normalizeEntry(args[0])
+	if err != nil || !strings.Contains(entry, "#") {
+		fmt.Println("expected a PR: owner/repo#number or a PR URL")
+		return 2
+	}
+	err = states.Update(func(st *State) error {
+		i := slices.IndexFunc(st.Pins, func(p string) bool { return strings.EqualFold(p, entry) })
+		switch {
+		case pin && i >= 0:
+			return fmt.Errorf("%s is already pinned", entry)
+		case pin:
+			st.Pins = append(st.Pins, entry)
+		case i < 0:
+			return fmt.Errorf("%s is not pinned", entry)
+		default:
+			st.Pins = slices.Delete(st.Pins, i, i+1)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("updating pins", "err", err)
+		return 1
+	}
+	if pin {
+		fmt.Println("pinned", entry)
+	} else {
+		fmt.Println("unpinned", entry)
+	}
+	return 0
+}
+
+// unpinEntry removes a pin, used when the pinned PR is closed.
+func unpinEntry(states *StateStore, entry string) error {
+	return states.Update(func(st *State) error {
+		st.Pins = slices.DeleteFunc(st.Pins, func(p string) bool { return strings.EqualFold(p, entry) })
+		return nil
+	})
+}
+
+// withPins puts the pinned PRs in front of the tracked entries, dropping
+// tracked owner/repo#number entries that are pinned as well.
+func withPins(repos, pins []string) []string {
+	out := slices.Clone(pins)
+	for _, r := range repos {
+		if !containsFold(pins, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// markPins flags the first n results as pinned and removes their PRs from
+// the repo listings further down, so each PR shows up once.
+func markPins(results []PRResult, n int) {
+	pinned := map[string]bool{}
+	for i := range results[:n] {
+		results[i].Pinned = true
+		pinned[strings.ToLower(results[i].Repo)] = true
+	}
+	for i := n; i < len(results); i++ {
+		res := &results[i]
+		res.PRs = slices.DeleteFunc(res.PRs, func(pr PullRequest) bool {
+			return pinned[strings.ToLower(fmt.Sprintf("%s#%d", res.Repo, pr.Number))]
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// preflight checks the token before a fetch: that it has the scopes pr-view
+// relies on, and for each org of entries that it is authorized for the
+// org's SAML SSO. It returns the SSO errors by lowercased owner, so those
+// entries are skipped rather than each failing with the same 403.
+func preflight(cfg *Config, gh *GitHubClient, entries []string) map[string]error {
+	if gh.token == "" {
+		return nil
+	}
+	direct := directClient(cfg)
+	// apps have no scopes or /user, only what the installation grants
+	if gh.app == nil {
+		resp, err := doctorGet(direct, gh, "/user")
+		if err != nil {
+			slog.Warn("checking token scopes", "err", err)
+		} else {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			u := &doctorUser{login: "token"}
+			if h, ok := resp.Header["X-Oauth-Scopes"]; ok {
+				u.classic = true
+				for _, sc := range strings.Split(strings.Join(h, ","), ",") {
+					if sc = strings.TrimSpace(sc); sc != "" {
+						u.scopes = append(u.scopes, sc)
+					}
+				}
+			}
+			if d := checkScopes(u); resp.StatusCode == http.StatusOK && d.status != "ok" {
+				slog.Warn("token is "+d.detail, "fix", d.fix)
+			}
+		}
+	}
+	blocked := map[string]error{}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		repo, _, _ := strings.Cut(e, "#")
+		owner, _, _ := strings.Cut(repo, "/")
+		if seen[strings.ToLower(owner)] {
+			continue
+		}
+		seen[strings.ToLower(owner)] = true
+		// any of the org's repos tells; SSO applies to all of them
+		resp, err := doctorGet(direct, gh, "/repos/"+repo)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			if ae := newAPIError(resp); ae.SSOOrg != "" {
+				slog.Error("skipping org", "org", owner, "err", ae)
+				blocked[strings.ToLower(owner)] = ae
+			}
+		}
+		resp.Body.Close()
+	}
+	return blocked
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultStatusField is the single-select field boards use for their
+// columns.
+const defaultStatusField = "Status"
+
+// projectItem is a PR's card on a Projects (v2) board.
+type projectItem struct {
+	ItemID        string `json:"item_id"`
+	ProjectID     string `json:"project_id"`
+	ProjectNumber int    `json:"project_number"`
+	ProjectTitle  string `json:"project_title"`
+	Status        string `json:"status,omitempty"`
+
+	fieldID string
+	options []projectOption
+}
+
+type projectOption struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const projectItemsQuery = `query($owner: String!, $name: String!, $number: Int!, $field: String!) {
+	repository(owner: $owner, name: $name) {
+		pullRequest(number: $number) {
+			projectItems(first: 20) {
+				nodes {
+					id
+					project {
+						id number title
+						field(name: $field) { ... on ProjectV2SingleSelectField { id options { id name } } }
+					}
+					fieldValueByName(name: $field) { ... on ProjectV2ItemFieldSingleSelectValue { name } }
+				}
+			}
+		}
+	}
+}`
+
+// fetchProjectItems returns the project cards of a PR with the value and
+// options of the given single-select field.
+func fetchProjectItems(c *GitHubClient, repo string, number int, field string) ([]projectItem, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ProjectItems struct {
+					Nodes []struct {
+						ID      string `json:"id"`
+						Project struct {
+							ID     string `json:"id"`
+							Number int    `json:"number"`
+							Title  string `json:"title"`
+							Field  struct {
+								ID      string          `json:"id"`
+								Options []projectOption `json:"options"`
+							} `json:"field"`
+						} `json:"project"`
+						FieldValueByName struct {
+							Name string `json:"name"`
+						} `json:"fieldValueByName"`
+					} `json:"nodes"`
+				} `json:"projectItems"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": owner, "name": name, "number": number, "field": field}
+	if err := c.graphql(projectItemsQuery, vars, &data); err != nil {
+		return nil, err
+	}
+	var items []projectItem
+	for _, n := range data.Repository.PullRequest.ProjectItems.Nodes {
+		items = append(items, projectItem{
+			ItemID:        n.ID,
+			ProjectID:     n.Project.ID,
+			ProjectNumber: n.Project.Number,
+			ProjectTitle:  n.Project.Title,
+			Status:        n.FieldValueByName.Name,
+			fieldID:       n.Project.Field.ID,
+			options:       n.Project.Field.Options,
+		})
+	}
+	return items, nil
+}
+
+// enrichProjects sets the boards a PR is on and its status on each. It
+// needs a token with the read:project scope.
+var enrichProjects = &enricher{name: "projects", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	items, err := fetchProjectItems(c, repo, pr.Number, defaultStatusField)
+	if err != nil {
+		return err
+	}
+	pr.Projects = items
+	return nil
+}}
+
+// projectsSummary renders the boards as "Title: Status", comma separated.
+func projectsSummary(items []projectItem) string {
+	var parts []string
+	for _, it := range items {
+		parts = append(parts, it.ProjectTitle+": "+firstNonEmpty(it.Status, "no status"))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func cmdProject(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 || args[0] != "move" {
+		fmt.Println(`usage: pr-view project move owner/repo#number --status "In review" [--project number|title] [--field Status]`)
+		return 2
+	}
+	fs := flag.NewFlagSet("project move", flag.ContinueOnError)
+	status := fs.String("status", "", "status (board column) to move the PR to")
+	project := fs.String("project", "", "project number or title, needed when the PR is on several boards")
+	field := fs.String("field", defaultStatusField, "single-select field holding the board column")
+	repo, number, err := parsePRCommand(fs, args[1:])
+	if err != nil || *status == "" {
+		fmt.Println(`usage: pr-view project move owner/repo#number --status "In review" [--project number|title] [--field Status]`)
+		return 2
+	}
+	entry := fmt.Sprintf("%s#%d", repo, number)
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	items, err := fetchProjectItems(gh, repo, number, *field)
+	if err != nil {
+		slog.Error("fetching projects", "err", err)
+		return 1
+	}
+	item, err := pickProjectItem(items, *project)
+	if err != nil {
+		fmt.Println(entry+":", err)
+		return 1
+	}
+	if item.fieldID == "" {
+		fmt.Printf("project %q has no single-select field %q\n", item.ProjectTitle, *field)
+		return 1
+	}
+	var option *projectOption
+	var names []string
+	for i, o := range item.options {
+		names = append(names, o.Name)
+		if strings.EqualFold(o.Name, *status) {
+			option = &item.options[i]
+		}
+	}
+	if option == nil {
+		fmt.Printf("project %q has no %s %q, expected one of: %s\n", item.ProjectTitle, *field, *status, strings.Join(names, ", "))
+		return 1
+	}
+	const mutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+	updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) { projectV2Item { id } }
+}`
+	vars := map[string]any{"project": item.ProjectID, "item": item.ItemID, "field": item.fieldID, "option": option.ID}
+	if err := gh.graphql(mutation, vars, nil); err != nil {
+		slog.Error("moving PR", "err", err)
+		return 1
+	}
+	fmt.Printf("moved %s to %q on %s\n", entry, option.Name, item.ProjectTitle)
+	return 0
+}
+
+// pickProjectItem selects the card to update: the only one, or the one whose
+// project number or title matches project.
+func pickProjectItem(items []projectItem, project string) (*projectItem, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("PR isn't on any project board")
+	}
+	if project == "" {
+		if len(items) > 1 {
+			return nil, fmt.Errorf("PR is on several boards (%s), pick one with --project", projectsSummary(items))
+		}
+		return &items[0], nil
+	}
+	for i, it := range items {
+		if strconv.Itoa(it.ProjectNumber) == project || strings.EqualFold(it.ProjectTitle, project) {
+			return &items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("PR isn't on project %q (it is on %s)", project, projectsSummary(items))
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// branchProtection is what a branch requires before a PR can merge, merged
+// from classic branch protection and repository rulesets.
+type branchProtection struct {
+	RequiredApprovals      int
+	CodeOwnerReview        bool
+	DismissStaleReviews    bool
+	ConversationResolution bool
+	RequiredChecks         []string
+	LinearHistory          bool
+	Signatures             bool
+	// ClassicHidden is set when classic protection couldn't be read, which
+	// needs admin access; rulesets are readable by anyone with read access.
+	ClassicHidden bool
+}
+
+var branchProtectionCache onceCache[*branchProtection]
+
+// fetchBranchProtection returns the merge requirements of branch, once per
+// process.
+func fetchBranchProtection(c *GitHubClient, repo, branch string) (*branchProtection, error) {
+	return branchProtectionCache.get(repo+"@"+branch, func() (*branchProtection, error) {
+		bp := &branchProtection{}
+		var classic struct {
+			RequiredStatusChecks *struct {
+				Contexts []string `json:"contexts"`
+				Checks   []struct {
+					Context string `json:"context"`
+				} `json:"checks"`
+			} `json:"required_status_checks"`
+			RequiredPullRequestReviews *struct {
+				RequiredApprovingReviewCount int  `json:"required_approving_review_count"`
+				RequireCodeOwnerReviews      bool `json:"require_code_owner_reviews"`
+				DismissStaleReviews          bool `json:"dismiss_stale_reviews"`
+			} `json:"required_pull_request_reviews"`
+			RequiredLinearHistory struct {
+				Enabled bool `json:"enabled"`
+			} `json:"required_linear_history"`
+			RequiredConversationResolution struct {
+				Enabled bool `json:"enabled"`
+			} `json:"required_conversation_resolution"`
+			RequiredSignatures struct {
+				Enabled bool `json:"enabled"`
+			} `json:"required_signatures"`
+		}
+		err := c.do("GET", fmt.Sprintf("/repos/%s/branches/%s/protection", repo, url.PathEscape(branch)), nil, &classic)
+		var apiErr *apiError
+		switch {
+		case err == nil:
+			if rsc := classic.RequiredStatusChecks; rsc != nil {
+				bp.addChecks(rsc.Contexts...)
+				for _, ch := range rsc.Checks {
+					bp.addChecks(ch.Context)
+				}
+			}
+			if r := classic.RequiredPullRequestReviews; r != nil {
+				bp.RequiredApprovals = r.RequiredApprovingReviewCount
+				bp.CodeOwnerReview = r.RequireCodeOwnerReviews
+				bp.DismissStaleReviews = r.DismissStaleReviews
+			}
+			bp.LinearHistory = classic.RequiredLinearHistory.Enabled
+			bp.ConversationResolution = classic.RequiredConversationResolution.Enabled
+			bp.Signatures = classic.RequiredSignatures.Enabled
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && strings.Contains(apiErr.Body, "not protected"):
+			// readable, and there's simply no classic protection
+		case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusForbidden):
+			bp.ClassicHidden = true
+		default:
+			return nil, err
+		}
+
+		var rules []struct {
+			Type       string `json:"type"`
+			Parameters struct {
+				RequiredApprovingReviewCount   int  `json:"required_approving_review_count"`
+				RequireCodeOwnerReview         bool `json:"require_code_owner_review"`
+				DismissStaleReviewsOnPush      bool `json:"dismiss_stale_reviews_on_push"`
+				RequiredReviewThreadResolution bool `json:"required_review_thread_resolution"`
+				RequiredStatusChecks           []struct {
+					Context string `json:"context"`
+				} `json:"required_status_checks"`
+			} `json:"parameters"`
+		}
+		err = c.do("GET", fmt.Sprintf("/repos/%s/rules/branches/%s", repo, url.PathEscape(branch)), nil, &rules)
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// rulesets aren't available on older GitHub Enterprise Server
+			return bp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			p := r.Parameters
+			switch r.Type {
+			case "pull_request":
+				bp.RequiredApprovals = max(bp.RequiredApprovals, p.RequiredApprovingReviewCount)
+				bp.CodeOwnerReview = bp.CodeOwnerReview || p.RequireCodeOwnerReview
+				bp.DismissStaleReviews = bp.DismissStaleReviews || p.DismissStaleReviewsOnPush
+				bp.ConversationResolution = bp.ConversationResolution || p.RequiredReviewThreadResolution
+			case "required_status_checks":
+				for _, ch := range p.RequiredStatusChecks {
+					bp.addChecks(ch.Context)
+				}
+			case "required_linear_history":
+				bp.LinearHistory = true
+			case "required_signatures":
+				bp.Signatures = true
+			}
+		}
+		return bp, nil
+	})
+}
+
+func (bp *branchProtection) addChecks(names ...string) {
+	for _, n := range names {
+		if !containsFold(bp.RequiredChecks, n) {
+			bp.RequiredChecks = append(bp.RequiredChecks, n)
+		}
+	}
+}
+
+// summary describes the requirements in one line, e.g. "2 approvals (code
+// owners); checks: ci, lint; linear history".
+func (bp *branchProtection) summary() string {
+	var parts []string
+	if bp.RequiredApprovals > 0 || bp.CodeOwnerReview {
+		s := fmt.Sprintf("%d approval", bp.RequiredApprovals)
+		if bp.RequiredApprovals != 1 {
+			s += "s"
+		}
+		var extra []string
+		if bp.CodeOwnerReview {
+			extra = append(extra, "code owners")
+		}
+		if bp.DismissStaleReviews {
+			extra = append(extra, "stale reviews dismissed")
+		}
+		if len(extra) > 0 {
+			s += " (" + strings.Join(extra, ", ") + ")"
+		}
+		parts = append(parts, s)
+	}
+	if len(bp.RequiredChecks) > 0 {
+		parts = append(parts, "checks: "+strings.Join(bp.RequiredChecks, ", "))
+	}
+	if bp.ConversationResolution {
+		parts = append(parts, "resolved conversations")
+	}
+	if bp.LinearHistory {
+		parts = append(parts, "linear history")
+	}
+	if bp.Signatures {
+		parts = append(parts, "signed commits")
+	}
+	s := strings.Join(parts, "; ")
+	if s == "" {
+		s = "none"
+	}
+	if bp.ClassicHidden {
+		s += " (classic branch protection needs admin access to read)"
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var prTemplatesCache onceCache[[]string]
+
+// repoPRTemplates returns the bodies of repo's pull request templates on
+// its default branch, once per process.
+func repoPRTemplates(c *GitHubClient, repo string) ([]string, error) {
+	return prTemplatesCache.get(repo, func() ([]string, error) {
+		owner, name, _ := strings.Cut(repo, "/")
+		const query = `query($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) { pullRequestTemplates { body } }
+}`
+		var data struct {
+			Repository struct {
+				PullRequestTemplates []struct {
+					Body string `json:"body"`
+				} `json:"pullRequestTemplates"`
+			} `json:"repository"`
+		}
+		if err := c.graphql(query, map[string]any{"owner": owner, "name": name}, &data); err != nil {
+			return nil, err
+		}
+		var bodies []string
+		for _, t := range data.Repository.PullRequestTemplates {
+			bodies = append(bodies, t.Body)
+		}
+		return bodies, nil
+	})
+}
+
+// enrichTemplateCheck sets what's missing from the PR description: it's
+// empty, or parts of the repo's PR template were left as they are.
+var enrichTemplateCheck = &enricher{name: "PR template", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	templates, err := repoPRTemplates(c, repo)
+	if err != nil {
+		return err
+	}
+	pr.TemplateIssues = templateIssues(pr.Body, templates)
+	return nil
+}}
+
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// descriptionLines are the visible lines of a description: without HTML
+// comments, trimmed, blank ones dropped.
+func descriptionLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(htmlComment.ReplaceAllString(s, ""), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// descriptionSection is a heading of a description with the lines under it.
+type descriptionSection struct {
+	heading string
+	lines   []string
+}
+
+// descriptionSections splits lines at Markdown headings. Lines before the
+// first heading go in a section without one.
+func descriptionSections(lines []string) []descriptionSection {
+	sections := []descriptionSection{{}}
+	for _, l := range lines {
+		if strings.HasPrefix(l, "#") {
+			sections = append(sections, descriptionSection{heading: strings.TrimSpace(strings.TrimLeft(l, "#"))})
+			continue
+		}
+		last := &sections[len(sections)-1]
+		last.lines = append(last.lines, l)
+	}
+	return sections
+}
+
+// templateIssues checks a PR body against the repo's PR templates: an empty
+// body, a template left as is, and template sections whose content wasn't
+// filled in or changed. It returns nil when the body looks filled in.
+func templateIssues(body string, templates []string) []string {
+	lines := descriptionLines(body)
+	if len(lines) == 0 {
+		return []string{"empty description"}
+	}
+	// compare against the template sharing the most headings with the body
+	var best []descriptionSection
+	bestShared := 0
+	sections := descriptionSections(lines)
+	for _, t := range templates {
+		tlines := descriptionLines(t)
+		if len(tlines) > 0 && slices.Equal(lines, tlines) {
+			return []string{"template not filled in"}
+		}
+		tsections := descriptionSections(tlines)
+		shared := 0
+		for _, ts := range tsections[1:] {
+			if findSection(sections, ts.heading) != nil {
+				shared++
+			}
+		}
+		if shared > bestShared {
+			best, bestShared = tsections, shared
+		}
+	}
+	var unfilled []string
+	for _, ts := range best {
+		if ts.heading == "" {
+			continue
+		}
+		if s := findSection(sections, ts.heading); s != nil && slices.Equal(s.lines, ts.lines) {
+			unfilled = append(unfilled, ts.heading)
+		}
+	}
+	if len(unfilled) > 0 {
+		return []string{"unfilled: " + strings.Join(unfilled, ", ")}
+	}
+	return nil
+}
+
+func findSection(sections []descriptionSection, heading string) *descriptionSection {
+	for i := range sections {
+		if sections[i].heading != "" && strings.EqualFold(sections[i].heading, heading) {
+			return &sections[i]
+		}
+	}
+	return nil
+}
+
+// templateLabel renders the template check: "ok", or what's missing in red.
+func templateLabel(issues []string) string {
+	if len(issues) == 0 {
+		return paint(ansiGreen, "ok")
+	}
+	return paint(ansiRed, strings.Join(issues, "; "))
+}
+
+// unfilledTemplateFilter keeps PRs with an empty description or an
+// unfilled PR template, the ones reviewers bounce anyway.
+var unfilledTemplateFilter = prFilter{
+	name:  "unfilled-template",
+	needs: []*enricher{enrichTemplateCheck},
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return len(pr.TemplateIssues) > 0
+	},
+}
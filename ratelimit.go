@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// rateLimits is the resources object of GET /rate_limit, which doesn't
+// count against the limit.
+type rateLimits struct {
+	Resources map[string]rateLimit `json:"resources"`
+}
+
+type rateLimit struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Used      int   `json:"used"`
+	Reset     int64 `json:"reset"`
+}
+
+func (r rateLimit) resetAt() time.Time { return time.Unix(r.Reset, 0) }
+
+// runCost is the number of core requests and GraphQL queries one list run
+// needs to fetch the tracked entries, before enrichment.
+type runCost struct {
+	core, graphql int
+}
+
+// listRunCost plans a run like fetchAll: owners with several whole-repo
+// entries share GraphQL queries, everything else is one REST request per
+// page. Cached responses are free, so this is an upper bound.
+func listRunCost(gh *GitHubClient, entries []string, opts *listOptions) runCost {
+	var cost runCost
+	byOwner := map[string]int{}
+	for _, r := range entries {
+		q := opts.queryFor(r)
+		if canBatch(gh, r, q) {
+			byOwner[strings.ToLower(strings.SplitN(r, "/", 2)[0])]++
+			continue
+		}
+		cost.core += max(1, (q.Limit+99)/100)
+	}
+	for _, n := range byOwner {
+		if n < 2 {
+			cost.core++
+			continue
+		}
+		cost.graphql += (n + batchSize - 1) / batchSize
+	}
+	return cost
+}
+
+// cmdRateLimit shows the remaining API quota of each configured token and
+// how many list runs they cover. List flags shape the estimate, e.g.
+// `pr-view ratelimit --limit 200`.
+func cmdRateLimit(cfg *Config, hc *http.Client, args []string) int {
+	opts, err := parseListFlags(cfg, flag.NewFlagSet("ratelimit", flag.ContinueOnError), args)
+	if err != nil {
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	// with several tokens each has its own quota, and a run can use them all
+	type tokenLimits struct {
+		label string
+		rl    rateLimits
+	}
+	clients := []tokenLimits{{label: tokenLabel(cfg.Auth)}}
+	if gh.pool != nil {
+		clients = clients[:0]
+		for _, t := range gh.pool.tokens {
+			clients = append(clients, tokenLimits{label: t.label})
+		}
+	}
+	for i := range clients {
+		c := gh
+		if gh.pool != nil {
+			c = gh.withToken(gh.pool.tokens[i].value)
+		}
+		if err := c.do("GET", "/rate_limit", nil, &clients[i].rl); err != nil {
+			if len(clients) == 1 {
+				slog.Error("fetching rate limit", "err", err)
+				return 1
+			}
+			// one broken token shouldn't hide the others
+			slog.Warn("fetching rate limit", "token", clients[i].label, "err", err)
+		}
+	}
+	if gh.token == "" {
+		fmt.Println("no token: limits are per IP address, set GITHUB_TOKEN for 5000 requests per hour")
+	}
+	total := map[string]rateLimit{}
+	for i, tl := range clients {
+		if len(clients) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", tl.label)
+		}
+		names := []string{"core", "search", "graphql"}
+		for name := range tl.rl.Resources {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names[3:])
+		fmt.Printf("%-22s %9s %6s  %s\n", "RESOURCE", "REMAINING", "LIMIT", "RESETS")
+		for _, name := range names {
+			r, ok := tl.rl.Resources[name]
+			if !ok {
+				continue
+			}
+			reset := r.resetAt().Local()
+			fmt.Printf("%-22s %9d %6d  %s (in %s)\n", name, r.Remaining, r.Limit, reset.Format("15:04"), fmtDuration(time.Until(reset)))
+			t := total[name]
+			t.Remaining += r.Remaining
+			t.Limit += r.Limit
+			total[name] = t
+		}
+	}
+
+	store, err := NewRepoStore()
+	if err != nil {
+		slog.Error("initializing store", "err", err)
+		return 1
+	}
+	repos, err := store.Load()
+	if err != nil {
+		slog.Error("loading repos", "err", err)
+		return 1
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	st, err := states.Load()
+	if err != nil {
+		slog.Error("loading state", "err", err)
+		return 1
+	}
+	entries := withPins(repos, st.Pins)
+	if len(entries) == 0 {
+		return 0
+	}
+	cost := listRunCost(gh, entries, opts)
+	runs := -1
+	if r, ok := total["core"]; ok && cost.core > 0 {
+		runs = r.Remaining / cost.core
+	}
+	if r, ok := total["graphql"]; ok && cost.graphql > 0 {
+		if n := r.Remaining / cost.graphql; runs < 0 || n < runs {
+			runs = n
+		}
+	}
+	fmt.Println()
+	queries := "queries"
+	if cost.graphql == 1 {
+		queries = "query"
+	}
+	fmt.Printf("a list run fetches %d entries with %d REST %s and %d GraphQL %s\n",
+		len(entries), cost.core, plural(cost.core, "request"), cost.graphql, queries)
+	if runs >= 0 {
+		across := ""
+		if len(clients) > 1 {
+			across = fmt.Sprintf(" across %d tokens", len(clients))
+		}
+		fmt.Printf("about %d list %s left%s before the reset (unchanged, cached responses are free)\n", runs, plural(runs, "run"), across)
+	}
+	var enrichers []string
+	for _, e := range enricherOrder {
+		if opts.needs[e] {
+			enrichers = append(enrichers, e.name)
+		}
+	}
+	if len(enrichers) > 0 {
+		fmt.Printf("not counted: requests per PR for %s\n", strings.Join(enrichers, ", "))
+	}
+	return 0
+}
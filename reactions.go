@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// reactions maps the emoji and names `react` accepts to the reaction
+// content the API expects.
+var reactions = map[string]string{
+	"👍": "+1", "+1": "+1", "thumbsup": "+1",
+	"👎": "-1", "-1": "-1", "thumbsdown": "-1",
+	"😄": "laugh", "laugh": "laugh",
+	"😕": "confused", "confused": "confused",
+	"❤️": "heart", "❤": "heart", "heart": "heart",
+	"🎉": "hooray", "hooray": "hooray",
+	"🚀": "rocket", "rocket": "rocket",
+	"👀": "eyes", "eyes": "eyes",
+}
+
+// reactionEmoji is the emoji printed for each reaction content.
+var reactionEmoji = map[string]string{
+	"+1": "👍", "-1": "👎", "laugh": "😄", "confused": "😕",
+	"heart": "❤️", "hooray": "🎉", "rocket": "🚀", "eyes": "👀",
+}
+
+// commentRef matches the comment anchors of GitHub URLs, #discussion_r123
+// for review comments and #issuecomment-123 for conversation comments.
+var commentRef = regexp.MustCompile(`(discussion_r|issuecomment-)?(\d+)$`)
+
+const reactUsage = "usage: pr-view react owner/repo#number [--comment <id|url>] <👍|👎|😄|😕|❤️|🎉|🚀|👀>"
+
+// cmdReact adds a reaction to a PR, or to one of its comments.
+func cmdReact(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("react", flag.ContinueOnError)
+	comment := fs.String("comment", "", "comment ID or URL; without it the reaction goes on the PR itself")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 2 {
+		fmt.Println(reactUsage)
+		return 2
+	}
+	repo, number, err := parsePRArg(positional[0])
+	if err != nil {
+		fmt.Println(reactUsage)
+		return 2
+	}
+	content, ok := reactions[strings.ToLower(positional[1])]
+	if !ok {
+		fmt.Printf("unknown reaction %q\n", positional[1])
+		fmt.Println(reactUsage)
+		return 2
+	}
+	var paths []string
+	target := fmt.Sprintf("%s#%d", repo, number)
+	if *comment == "" {
+		paths = []string{fmt.Sprintf("/repos/%s/issues/%d/reactions", repo, number)}
+	} else {
+		m := commentRef.FindStringSubmatch(*comment)
+		if m == nil {
+			fmt.Printf("invalid --comment %q, expected an ID or a comment URL\n", *comment)
+			return 2
+		}
+		review := fmt.Sprintf("/repos/%s/pulls/comments/%s/reactions", repo, m[2])
+		issue := fmt.Sprintf("/repos/%s/issues/comments/%s/reactions", repo, m[2])
+		switch m[1] {
+		case "discussion_r":
+			paths = []string{review}
+		case "issuecomment-":
+			paths = []string{issue}
+		default:
+			// a bare ID may be either kind, review comments are likelier
+			paths = []string{review, issue}
+		}
+		target = "comment " + m[2] + " on " + target
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	for i, path := range paths {
+		err = gh.do("POST", path, map[string]string{"content": content}, nil)
+		var apiErr *apiError
+		if i < len(paths)-1 && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			continue
+		}
+		break
+	}
+	if err != nil {
+		slog.Error("adding reaction", "err", err)
+		return 1
+	}
+	fmt.Printf("reacted %s to %s\n", reactionEmoji[content], target)
+	return 0
+}
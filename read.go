@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prKey identifies a PR in local state, e.g. "owner/repo#12", lowercased.
+func prKey(repo string, number int) string {
+	return strings.ToLower(fmt.Sprintf("%s#%d", repoName(repo), number))
+}
+
+// markUnread flags PRs updated (new commits, comments or reviews) after
+// the version last marked read, or never marked read at all.
+func markUnread(results []PRResult, seen map[string]time.Time) {
+	for i := range results {
+		res := &results[i]
+		for j := range res.PRs {
+			pr := &res.PRs[j]
+			last, ok := seen[prKey(res.Repo, pr.Number)]
+			pr.Unread = !ok || pr.UpdatedAt.After(last)
+		}
+	}
+}
+
+var unreadFilter = prFilter{
+	name: "unread",
+	keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+		return pr.Unread
+	},
+}
+
+// markRead records the given PR versions as read.
+func markRead(states *StateStore, repo string, prs []PullRequest) error {
+	return states.Update(func(st *State) error {
+		if st.Seen == nil {
+			st.Seen = map[string]time.Time{}
+		}
+		for _, pr := range prs {
+			st.Seen[prKey(repo, pr.Number)] = pr.UpdatedAt
+		}
+		return nil
+	})
+}
+
+// cmdMarkRead marks PRs read at their current version, or unread again.
+// `mark-read --all` marks everything `list` would show.
+func cmdMarkRead(cfg *Config, hc *http.Client, args []string, read bool) int {
+	name := "mark-read"
+	if !read {
+		name = "mark-unread"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	all := fs.Bool("all", false, "mark every tracked PR")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (fs.NArg() == 0) == !*all {
+		fmt.Printf("usage: pr-view %s owner/repo#number|<PR_URL>... | --all\n", name)
+		return 2
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return 1
+	}
+	var entries []string
+	for _, arg := range fs.Args() {
+		entry, err := normalizeEntry(arg)
+		if err != nil || !strings.Contains(entry, "#") {
+			fmt.Println("expected a PR: owner/repo#number or a PR URL:", arg)
+			return 2
+		}
+		entries = append(entries, entry)
+	}
+	if !read && !*all {
+		err := states.Update(func(st *State) error {
+			for _, e := range entries {
+				repo, num, _ := strings.Cut(e, "#")
+				n, _ := strconv.Atoi(num)
+				delete(st.Seen, prKey(repo, n))
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("saving state", "err", err)
+			return 1
+		}
+		fmt.Printf("marked %d PRs unread\n", len(entries))
+		return 0
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	opts, err := parseListFlags(cfg, flag.NewFlagSet("list", flag.ContinueOnError), nil)
+	if err != nil {
+		return 2
+	}
+	if *all {
+		store, err := NewRepoStore()
+		if err != nil {
+			slog.Error("initializing store", "err", err)
+			return 1
+		}
+		repos, err := store.Load()
+		if err != nil {
+			slog.Error("loading repos", "err", err)
+			return 1
+		}
+		st, err := states.Load()
+		if err != nil {
+			slog.Error("loading state", "err", err)
+			return 1
+		}
+		entries = withPins(repos, st.Pins)
+	}
+	results := fetchAll(gh, entries, opts.queryFor, func(string) error { return nil })
+	code := 0
+	count := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("fetching", "repo", res.Repo, "err", res.Err)
+			code = 1
+			continue
+		}
+		if !read {
+			// --all without read: forget every version seen for these PRs
+			err = states.Update(func(st *State) error {
+				for _, pr := range res.PRs {
+					delete(st.Seen, prKey(res.Repo, pr.Number))
+				}
+				return nil
+			})
+		} else {
+			err = markRead(states, res.Repo, res.PRs)
+		}
+		if err != nil {
+			slog.Error("saving state", "err", err)
+			return 1
+		}
+		count += len(res.PRs)
+	}
+	fmt.Printf("marked %d PRs %s\n", count, strings.TrimPrefix(name, "mark-"))
+	return code
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// actionsApp is the GitHub App behind Actions check suites; those are
+// re-run through the workflow runs API, everything else is re-requested.
+const actionsApp = "github-actions"
+
+const checksUsage = "usage: pr-view checks rerun owner/repo#number [--failed-only]"
+
+// cmdChecks is `pr-view checks rerun`: re-run the CI of a PR's head commit.
+// Actions workflows are re-run, failed jobs only with --failed-only, and
+// other apps' check suites are re-requested. Runs still in progress are
+// left alone.
+func cmdChecks(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 || args[0] != "rerun" {
+		fmt.Println(checksUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("checks rerun", flag.ContinueOnError)
+	failedOnly := fs.Bool("failed-only", false, "only re-run failed jobs and check suites with failures")
+	repo, number, err := parsePRCommand(fs, args[1:])
+	if err != nil {
+		fmt.Println(checksUsage)
+		return 2
+	}
+	entry := fmt.Sprintf("%s#%d", repo, number)
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	prs, err := fetchPRs(gh, entry, prQuery{})
+	if err != nil {
+		slog.Error("fetching PR", "err", err)
+		return 1
+	}
+	sha := prs[0].Head.SHA
+	runs, statuses, err := fetchChecks(gh, repo, sha)
+	if err != nil {
+		slog.Error("fetching checks", "err", err)
+		return 1
+	}
+	workflows, err := fetchWorkflowRuns(gh, repo, sha)
+	if err != nil {
+		slog.Error("fetching workflow runs", "err", err)
+		return 1
+	}
+
+	code, rerun := 0, 0
+	for _, w := range workflows {
+		state := checkRunState(checkRun{Status: w.Status, Conclusion: w.Conclusion})
+		if state == ciPending || (*failedOnly && state != ciFailure) {
+			continue
+		}
+		path, what := fmt.Sprintf("/repos/%s/actions/runs/%d/rerun", repo, w.ID), "workflow"
+		if state == ciFailure && *failedOnly {
+			path, what = path+"-failed-jobs", "failed jobs of workflow"
+		}
+		if err := gh.do("POST", path, nil, nil); err != nil {
+			slog.Error("re-running workflow", "workflow", w.Name, "err", err)
+			code = 1
+			continue
+		}
+		fmt.Printf("re-running %s %q\n", what, w.Name)
+		rerun++
+	}
+
+	// group the other apps' runs by suite, a suite is re-requested as a whole
+	type suite struct {
+		app     string
+		names   []string
+		pending bool
+		failed  bool
+	}
+	suites := map[int64]*suite{}
+	var order []int64
+	for _, r := range runs {
+		if r.App.Slug == actionsApp {
+			continue
+		}
+		s, ok := suites[r.CheckSuite.ID]
+		if !ok {
+			s = &suite{app: r.App.Slug}
+			suites[r.CheckSuite.ID] = s
+			order = append(order, r.CheckSuite.ID)
+		}
+		s.names = append(s.names, r.Name)
+		switch checkRunState(r) {
+		case ciPending:
+			s.pending = true
+		case ciFailure:
+			s.failed = true
+		}
+	}
+	for _, id := range order {
+		s := suites[id]
+		if s.pending || (*failedOnly && !s.failed) {
+			continue
+		}
+		if err := gh.do("POST", fmt.Sprintf("/repos/%s/check-suites/%d/rerequest", repo, id), nil, nil); err != nil {
+			slog.Error("re-requesting check suite", "app", s.app, "err", err)
+			code = 1
+			continue
+		}
+		fmt.Printf("re-requested %s checks: %s\n", s.app, strings.Join(s.names, ", "))
+		rerun++
+	}
+
+	// commit statuses come from outside GitHub and can't be re-run from here
+	for _, st := range statuses {
+		if statusState(st) == ciFailure {
+			fmt.Printf("can't re-run status %q, it is reported by an external service: %s\n", st.Context, firstNonEmpty(st.TargetURL, "no link"))
+		}
+	}
+	if rerun == 0 && code == 0 {
+		if *failedOnly {
+			fmt.Println("no failed checks to re-run on", entry)
+		} else {
+			fmt.Println("no finished checks to re-run on", entry)
+		}
+	}
+	return code
+}
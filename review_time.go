@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type timelineEvent struct {
+	Event       string    `json:"event"`
+	CreatedAt   time.Time `json:"created_at"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	State       string    `json:"state"`
+	User        User      `json:"user"`
+	Actor       User      `json:"actor"`
+}
+
+// enrichTimeline sets when the PR was last marked ready for review and when
+// it got its first review after that, from the issue timeline.
+var enrichTimeline = &enricher{name: "timeline", run: func(c *GitHubClient, repo string, pr *PullRequest) error {
+	var events []timelineEvent
+	next := fmt.Sprintf("/repos/%s/issues/%d/timeline?per_page=100", repo, pr.Number)
+	for next != "" {
+		var page []timelineEvent
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			return err
+		}
+		events = append(events, page...)
+	}
+	pr.ReadyAt = pr.CreatedAt
+	pr.FirstReviewAt = nil
+	for _, e := range events {
+		switch e.Event {
+		case "ready_for_review":
+			// going back to draft and ready again restarts the clock
+			pr.ReadyAt = e.CreatedAt
+			pr.FirstReviewAt = nil
+		case "reviewed":
+			if e.State == "pending" || e.User.Login == pr.User.Login {
+				continue
+			}
+			if pr.FirstReviewAt == nil && !e.SubmittedAt.Before(pr.ReadyAt) {
+				t := e.SubmittedAt
+				pr.FirstReviewAt = &t
+			}
+		}
+	}
+	return nil
+}}
+
+// reviewWait is how long the PR waited for its first review, or has been
+// waiting so far, and whether it is still waiting.
+func reviewWait(pr PullRequest, now time.Time) (time.Duration, bool) {
+	if pr.FirstReviewAt != nil {
+		return pr.FirstReviewAt.Sub(pr.ReadyAt), false
+	}
+	return now.Sub(pr.ReadyAt), true
+}
+
+const (
+	defaultReviewWarn  = 24 * time.Hour
+	defaultReviewAlert = 72 * time.Hour
+)
+
+// reviewTimeLabel renders the review wait, highlighted in yellow past
+// list.review_warn and red past list.review_alert while still waiting.
+func reviewTimeLabel(cfg ListConfig, pr PullRequest) string {
+	if pr.Draft {
+		return "draft"
+	}
+	wait, waiting := reviewWait(pr, time.Now())
+	if !waiting {
+		return fmtDuration(wait) + " to review"
+	}
+	label := fmtDuration(wait) + " waiting"
+	warn, alert := defaultReviewWarn, defaultReviewAlert
+	if cfg.ReviewWarn > 0 {
+		warn = time.Duration(cfg.ReviewWarn)
+	}
+	if cfg.ReviewAlert > 0 {
+		alert = time.Duration(cfg.ReviewAlert)
+	}
+	switch {
+	case wait >= alert:
+		return paint(ansiRed, label)
+	case wait >= warn:
+		return paint(ansiYellow, label)
+	}
+	return label
+}
+
+// fmtDuration renders d in its two largest units, e.g. 3d4h, 5h12m or 40m.
+func fmtDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	mins := int(d/time.Minute) % 60
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0 && mins > 0:
+		return fmt.Sprintf("%dh%dm", hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
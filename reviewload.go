@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// reviewerLoad is the open review requests of one user or team.
+type reviewerLoad struct {
+	name   string
+	prs    []string
+	oldest time.Time // creation of the oldest PR awaiting them
+}
+
+// reviewLoads counts the pending review requests per user and team over
+// the open PRs of results, busiest first.
+func reviewLoads(results []PRResult) []*reviewerLoad {
+	byName := map[string]*reviewerLoad{}
+	add := func(name string, res PRResult, pr PullRequest) {
+		l := byName[strings.ToLower(name)]
+		if l == nil {
+			l = &reviewerLoad{name: name}
+			byName[strings.ToLower(name)] = l
+		}
+		l.prs = append(l.prs, fmt.Sprintf("%s#%d", repoName(res.Repo), pr.Number))
+		if l.oldest.IsZero() || pr.CreatedAt.Before(l.oldest) {
+			l.oldest = pr.CreatedAt
+		}
+	}
+	seen := map[string]bool{} // a PR tracked on its own and with its repo
+	for _, res := range results {
+		owner, _, _ := strings.Cut(repoName(res.Repo), "/")
+		for _, pr := range res.PRs {
+			key := prKey(repoName(res.Repo), pr.Number)
+			if seen[key] || strings.ToLower(pr.State) != "open" {
+				continue
+			}
+			seen[key] = true
+			for _, u := range pr.RequestedReviewers {
+				add(u.Login, res, pr)
+			}
+			for _, t := range pr.RequestedTeams {
+				add("@"+owner+"/"+t.Slug, res, pr)
+			}
+		}
+	}
+	var loads []*reviewerLoad
+	for _, l := range byName {
+		loads = append(loads, l)
+	}
+	slices.SortFunc(loads, func(a, b *reviewerLoad) int {
+		if len(a.prs) != len(b.prs) {
+			return len(b.prs) - len(a.prs)
+		}
+		return strings.Compare(strings.ToLower(a.name), strings.ToLower(b.name))
+	})
+	return loads
+}
+
+const reviewLoadUsage = "usage: pr-view review-load [--overloaded 5] [--prs]"
+
+// cmdReviewLoad reports the open review requests per person and team
+// across the tracked repos, highlighting those with --overloaded or more.
+func cmdReviewLoad(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("review-load", flag.ContinueOnError)
+	overloaded := fs.Int("overloaded", 5, "highlight reviewers with at least this many open requests")
+	showPRs := fs.Bool("prs", false, "list the PRs awaiting each reviewer")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *overloaded < 1 {
+		fmt.Println(reviewLoadUsage)
+		return 2
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		slog.Error("initializing store", "err", err)
+		return 1
+	}
+	repos, err := store.Load()
+	if err != nil {
+		slog.Error("loading repos", "err", err)
+		return 1
+	}
+	if len(repos) == 0 {
+		fmt.Println(errNoRepos)
+		return 0
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	query := func(string) prQuery { return prQuery{Limit: 100, Sort: "updated", Direction: "desc"} }
+	results := fetchAll(gh, repos, query, func(string) error { return nil })
+	code := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("listing", "repo", res.Repo, "err", res.Err)
+			code = 1
+		}
+	}
+
+	loads := reviewLoads(results)
+	if len(loads) == 0 {
+		fmt.Println("no open review requests")
+		return code
+	}
+	width := 0
+	for _, l := range loads {
+		width = max(width, displayWidth(l.name))
+	}
+	busy := 0
+	for _, l := range loads {
+		count := fmt.Sprintf("%3d", len(l.prs))
+		if len(l.prs) >= *overloaded {
+			count = paint(ansiRed, count)
+			busy++
+		}
+		fmt.Printf("%s  %s %s, oldest opened %s\n", padWidth(l.name, width, false), count, plural(len(l.prs), "request"), fmtTime(cfg, l.oldest))
+		if *showPRs {
+			for _, pr := range l.prs {
+				fmt.Printf("%s    %s\n", strings.Repeat(" ", width), pr)
+			}
+		}
+	}
+	if busy > 0 {
+		fmt.Printf("\n%d %s with %d or more open requests\n", busy, plural(busy, "reviewer"), *overloaded)
+	}
+	return code
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ListedRow is one PR row of the last list table, so later commands can
+// refer to it by its row number.
+type ListedRow struct {
+	Entry string `json:"entry"`
+	URL   string `json:"url"`
+}
+
+// numberRows numbers the PRs of results 1, 2, ... in listing order and
+// returns the rows to remember for `open N` and `show N`.
+func numberRows(results []PRResult) []ListedRow {
+	var rows []ListedRow
+	for i := range results {
+		res := &results[i]
+		for j := range res.PRs {
+			pr := &res.PRs[j]
+			rows = append(rows, ListedRow{Entry: fmt.Sprintf("%s#%d", repoName(res.Repo), pr.Number), URL: pr.HTMLURL})
+			pr.Row = len(rows)
+		}
+	}
+	return rows
+}
+
+// saveLastList remembers the rows of the table just printed. Failing to is
+// logged and otherwise ignored, the listing itself worked.
+func saveLastList(cfg *Config, rows []ListedRow) {
+	states, err := NewStateStore(cfg)
+	if err == nil {
+		err = states.Update(func(st *State) error {
+			st.LastList = rows
+			return nil
+		})
+	}
+	if err != nil {
+		slog.Warn("saving the listing for row numbers", "err", err)
+	}
+}
+
+// lookupRow resolves a row number of the last listing. ok is false when
+// arg isn't a number, so callers can parse it as a PR instead.
+func lookupRow(cfg *Config, arg string) (row ListedRow, ok bool, err error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return ListedRow{}, false, nil
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return ListedRow{}, true, err
+	}
+	st, err := states.Load()
+	if err != nil {
+		return ListedRow{}, true, err
+	}
+	if n < 1 || n > len(st.LastList) {
+		return ListedRow{}, true, fmt.Errorf("no row %d in the last listing (%d %s), run pr-view list first", n, len(st.LastList), plural(len(st.LastList), "row"))
+	}
+	return st.LastList[n-1], true, nil
+}
+
+// openBrowser opens url with the OS's default handler.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Run()
+}
+
+const openUsage = "usage: pr-view open <row>|owner/repo#number|<PR_URL>"
+
+// cmdOpen opens a PR in the browser, by its row in the last listing or as
+// owner/repo#number.
+func cmdOpen(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("open", flag.ContinueOnError)
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 1 {
+		fmt.Println(openUsage)
+		return 2
+	}
+	row, ok, err := lookupRow(cfg, positional[0])
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	url := row.URL
+	if !ok {
+		if strings.HasPrefix(positional[0], "https://") {
+			url = positional[0]
+		}
+		repo, number, err := parsePRArg(positional[0])
+		if err != nil {
+			fmt.Println(openUsage)
+			return 2
+		}
+		if url == "" {
+			gh, err := newGitHubClient(cfg, hc)
+			if err != nil {
+				slog.Error("reading token", "err", err)
+				return 1
+			}
+			prs, err := fetchPRs(gh, fmt.Sprintf("%s#%d", repo, number), prQuery{})
+			if err != nil {
+				slog.Error("fetching PR", "err", err)
+				return 1
+			}
+			url = prs[0].HTMLURL
+		}
+	}
+	if err := openBrowser(url); err != nil {
+		slog.Error("opening browser", "url", url, "err", err)
+		return 1
+	}
+	return 0
+}
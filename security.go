@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// tokenPattern matches the GitHub token formats (classic, OAuth, app and
+// fine-grained) so they are scrubbed even if we never saw the value.
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// registerSecret records a credential so redact removes it from any output.
+func registerSecret(s string) {
+	if len(s) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, s)
+}
+
+// redact formats v and replaces known secrets and anything that looks like a
+// GitHub token. Use it for every error or diagnostic that reaches the user.
+func redact(v any) string {
+	s := fmt.Sprint(v)
+	secretsMu.Lock()
+	for _, sec := range secrets {
+		s = strings.ReplaceAll(s, sec, "[REDACTED]")
+	}
+	secretsMu.Unlock()
+	return tokenPattern.ReplaceAllString(s, "[REDACTED]")
+}
+
+// encryptedMagic prefixes files written by writeLocalFile when encryption is
+// enabled, so readLocalFile can tell them apart from plaintext files.
+var encryptedMagic = []byte("pr-view:enc:v1\n")
+
+const (
+	keychainService = "pr-view"
+	keychainAccount = "encryption-key"
+	keyFileName     = "encryption.key"
+)
+
+var (
+	keyMu     sync.Mutex
+	cachedKey []byte
+)
+
+// writeLocalFile atomically writes data that may contain PR content, sealing
+// it with AES-GCM when security.encrypt_cache is enabled.
+func writeLocalFile(cfg SecurityConfig, path string, data []byte) error {
+	if cfg.EncryptCache {
+		key, err := encryptionKey(cfg)
+		if err != nil {
+			return err
+		}
+		if data, err = seal(key, data); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// readLocalFile reads a file written by writeLocalFile, decrypting it if
+// needed. Plaintext files are returned as is so toggling encryption does not
+// invalidate existing data.
+func readLocalFile(cfg SecurityConfig, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, nil
+	}
+	key, err := encryptionKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return unseal(key, data)
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, encryptedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
+
+func unseal(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted file is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt local data (wrong or rotated encryption key?)")
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptionKey returns the 256-bit local data key from the configured
+// source, generating and storing a new one on first use.
+func encryptionKey(cfg SecurityConfig) ([]byte, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if cachedKey != nil {
+		return cachedKey, nil
+	}
+	var load func() (string, error)
+	var store func(string) error
+	switch cfg.KeySource {
+	case "", "file":
+		path := cfg.KeyFile
+		if path == "" {
+			dir, err := configDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(dir, keyFileName)
+		}
+		load = func() (string, error) {
+			b, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				return "", nil
+			}
+			return string(b), err
+		}
+		store = func(k string) error { return writeFileAtomic(path, []byte(k+"\n"), 0o600) }
+	case "keychain":
+		load, store = keychainLoad, keychainStore
+	default:
+		return nil, fmt.Errorf("unknown security.key_source %q (expected file or keychain)", cfg.KeySource)
+	}
+	encoded, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("loading encryption key: %w", err)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		encoded = hex.EncodeToString(key)
+		if err := store(encoded); err != nil {
+			return nil, fmt.Errorf("storing encryption key: %w", err)
+		}
+	}
+	key, err := hex.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("encryption key must be 64 hex characters")
+	}
+	cachedKey = key
+	return key, nil
+}
+
+// keychainLoad reads the key from the macOS keychain or the freedesktop
+// secret service. A missing entry returns an empty key.
+func keychainLoad() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	default:
+		return "", fmt.Errorf("keychain key source is not supported on %s", runtime.GOOS)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// both tools exit non-zero when the item does not exist
+			return "", nil
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func keychainStore(key string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", keychainAccount, "-w", key)
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=pr-view encryption key", "service", keychainService, "account", keychainAccount)
+		cmd.Stdin = strings.NewReader(key)
+	default:
+		return fmt.Errorf("keychain key source is not supported on %s", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
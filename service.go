@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	systemdUnit    = "pr-view.service"
+	launchdLabel   = "com.github.mtintes.pr-view"
+	windowsService = "pr-view"
+	serviceFlags   = "[--print] [--no-start] [daemon flags]"
+	serviceUsage   = "usage: pr-view daemon install " + serviceFlags + "\n       pr-view daemon uninstall|start|stop|status"
+	installedMode  = 0o600 // the environment may hold the token
+)
+
+// serviceEnvVars are the variables pr-view reads, copied into the service
+// when set, since services don't inherit the login shell's environment.
+var serviceEnvVars = []string{
+	"PATH", "XDG_CACHE_HOME", "NO_COLOR",
+	// where a Windows service, running as LocalSystem, finds your config
+	// and cache
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA",
+	"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy",
+	"VAULT_ADDR", "VAULT_NAMESPACE", "VAULT_TOKEN", "VAULT_ROLE_ID", "VAULT_SECRET_ID",
+	"GITHUB_APP_ID", "GITHUB_APP_PRIVATE_KEY",
+	"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_SERVICE_NAME",
+	"PR_VIEW_WEBHOOK_SECRET",
+}
+
+// serviceEnv returns the environment for the service that runs the
+// daemon, and whether it holds credentials: the token or a GitHub App's
+// private key.
+func serviceEnv(cfg *Config) (env [][2]string, creds bool) {
+	names := slices.Clone(serviceEnvVars)
+	if cfg.Auth.TokenSource == "" || cfg.Auth.TokenSource == "env" {
+		name := firstNonEmpty(cfg.Auth.EnvVar, "GITHUB_TOKEN")
+		names = append(names, name)
+		creds = os.Getenv(name) != ""
+	}
+	// the extra tokens reads rotate over
+	for _, name := range cfg.Auth.EnvVars {
+		names = append(names, name)
+		creds = creds || os.Getenv(name) != ""
+	}
+	creds = creds || os.Getenv("GITHUB_APP_PRIVATE_KEY") != ""
+	for _, n := range names {
+		if v, ok := os.LookupEnv(n); ok {
+			env = append(env, [2]string{n, v})
+		}
+	}
+	return env, creds
+}
+
+// serviceFile returns where the service definition goes on this OS, for
+// systemd and launchd.
+func serviceFile() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "systemd", "user", systemdUnit), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+	}
+	return "", fmt.Errorf("daemon install supports Linux (systemd), macOS (launchd) and Windows, not %s", runtime.GOOS)
+}
+
+// systemdQuote quotes a word of an ExecStart or Environment line.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s)
+	if s == "" || strings.ContainsAny(s, " \t'") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+func systemdUnitFile(argv []string, env [][2]string) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\nDescription=pr-view daemon, polling the tracked PRs\nWants=network-online.target\nAfter=network-online.target\n\n[Service]\n")
+	words := make([]string, len(argv))
+	for i, a := range argv {
+		words[i] = systemdQuote(a)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(words, " "))
+	for _, kv := range env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(kv[0]+"="+kv[1]))
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=30\n\n[Install]\nWantedBy=default.target\n")
+	return b.String()
+}
+
+func launchdPlist(argv []string, env [][2]string, logPath string) string {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", launchdLabel)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, a := range argv {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", esc(a))
+	}
+	b.WriteString("\t</array>\n")
+	if len(env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, kv := range env {
+			fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", esc(kv[0]), esc(kv[1]))
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	// restart after crashes, not after a clean exit
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", esc(logPath))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", esc(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// runServiceCommand runs systemctl or launchctl, folding its output into
+// the error.
+func runServiceCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// cmdDaemonInstall writes a user service running `pr-view daemon` with the
+// given daemon flags, and starts it unless --no-start.
+func cmdDaemonInstall(cfg *Config, args []string) int {
+	// the install flags are picked out, the rest is checked as daemon
+	// flags and passed on as given
+	var printOnly, noStart bool
+	var daemonArgs []string
+	for _, a := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || (name != "print" && name != "no-start") {
+			daemonArgs = append(daemonArgs, a)
+			continue
+		}
+		on := true
+		if hasValue {
+			var err error
+			if on, err = strconv.ParseBool(value); err != nil {
+				fmt.Println(serviceUsage)
+				return 2
+			}
+		}
+		if name == "print" {
+			printOnly = on
+		} else {
+			noStart = on
+		}
+	}
+	fs, _, _ := daemonFlags(cfg)
+	if _, err := parseListFlags(cfg, fs, daemonArgs); err != nil || fs.NArg() > 0 {
+		fmt.Println(serviceUsage)
+		return 2
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		slog.Error("locating the pr-view binary", "err", err)
+		return 1
+	}
+	env, creds := serviceEnv(cfg)
+	if runtime.GOOS == "windows" {
+		return installWindowsService(append([]string{exe, "daemon", "service"}, daemonArgs...), env, creds, printOnly, noStart)
+	}
+	path, err := serviceFile()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	argv := append([]string{exe, "daemon"}, daemonArgs...)
+	var def string
+	if runtime.GOOS == "darwin" {
+		logPath, err := daemonLogPath()
+		if err != nil {
+			slog.Error("locating log file", "err", err)
+			return 1
+		}
+		def = launchdPlist(argv, env, strings.TrimSuffix(logPath, ".log")+".out")
+	} else {
+		def = systemdUnitFile(argv, env)
+	}
+	if printOnly {
+		fmt.Print(def)
+		return 0
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Error("creating service directory", "err", err)
+		return 1
+	}
+	if err := writeFileAtomic(path, []byte(def), installedMode); err != nil {
+		slog.Error("writing service", "err", err)
+		return 1
+	}
+	fmt.Println("wrote", path)
+	if creds {
+		fmt.Println("it holds your credentials from the environment, readable only by you; reinstall after changing them")
+	}
+	if noStart {
+		return 0
+	}
+	if runtime.GOOS == "darwin" {
+		// reload a previous install so it picks up the changes
+		_ = runServiceCommand("launchctl", "unload", path)
+		err = runServiceCommand("launchctl", "load", "-w", path)
+	} else {
+		// restart rather than start, a running daemon has the old flags
+		err = runServiceCommand("systemctl", "--user", "daemon-reload")
+		if err == nil {
+			err = runServiceCommand("systemctl", "--user", "enable", systemdUnit)
+		}
+		if err == nil {
+			err = runServiceCommand("systemctl", "--user", "restart", systemdUnit)
+		}
+	}
+	if err != nil {
+		slog.Error("starting service", "err", err)
+		return 1
+	}
+	fmt.Println("pr-view daemon started, and starts again at login")
+	return 0
+}
+
+// cmdDaemonUninstall stops the user service and removes it.
+func cmdDaemonUninstall(args []string) int {
+	if len(args) > 0 {
+		fmt.Println(serviceUsage)
+		return 2
+	}
+	if runtime.GOOS == "windows" {
+		return uninstallWindowsService()
+	}
+	path, err := serviceFile()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Println("the daemon isn't installed")
+		return 0
+	}
+	if runtime.GOOS == "darwin" {
+		err = runServiceCommand("launchctl", "unload", "-w", path)
+	} else {
+		err = runServiceCommand("systemctl", "--user", "disable", "--now", systemdUnit)
+	}
+	if err != nil {
+		// still remove it, it may never have been started
+		slog.Warn("stopping service", "err", err)
+	}
+	if err := os.Remove(path); err != nil {
+		slog.Error("removing service", "err", err)
+		return 1
+	}
+	if runtime.GOOS == "linux" {
+		if err := runServiceCommand("systemctl", "--user", "daemon-reload"); err != nil {
+			slog.Warn("reloading systemd", "err", err)
+		}
+	}
+	fmt.Println("removed", path)
+	return 0
+}
+
+// cmdDaemonStartStop starts or stops the installed service.
+func cmdDaemonStartStop(args []string, start bool) int {
+	if len(args) > 0 {
+		fmt.Println(serviceUsage)
+		return 2
+	}
+	verb := map[bool]string{true: "start", false: "stop"}[start]
+	var err error
+	switch runtime.GOOS {
+	case "windows":
+		err = runServiceCommand("sc.exe", verb, windowsService)
+	case "darwin":
+		err = runServiceCommand("launchctl", verb, launchdLabel)
+	default:
+		if _, err = serviceFile(); err == nil {
+			err = runServiceCommand("systemctl", "--user", verb, systemdUnit)
+		}
+	}
+	if err != nil {
+		slog.Error("trying to "+verb+" the service, is it installed? (pr-view daemon install)", "err", err)
+		return 1
+	}
+	if start {
+		fmt.Println("pr-view daemon started")
+	} else {
+		fmt.Println("pr-view daemon stopped")
+	}
+	return 0
+}
+
+// windowsCommandLine joins argv for a service's binPath, quoting the words
+// with spaces.
+func windowsCommandLine(argv []string) string {
+	words := make([]string, len(argv))
+	for i, a := range argv {
+		if a == "" || strings.ContainsAny(a, " \t\"") {
+			a = `"` + strings.ReplaceAll(a, `"`, `\"`) + `"`
+		}
+		words[i] = a
+	}
+	return strings.Join(words, " ")
+}
+
+// installWindowsService registers argv as a service started at boot,
+// restarting after failures, with env in its registry key. It needs an
+// elevated prompt, like sc.exe itself.
+func installWindowsService(argv []string, env [][2]string, creds, printOnly, noStart bool) int {
+	verb := "create"
+	if runServiceCommand("sc.exe", "query", windowsService) == nil {
+		verb = "config" // reinstalling
+	}
+	var vars []string
+	for _, kv := range env {
+		vars = append(vars, kv[0]+"="+kv[1])
+	}
+	commands := [][]string{
+		{"sc.exe", verb, windowsService, "binPath=", windowsCommandLine(argv), "start=", "auto", "DisplayName=", "pr-view daemon"},
+		{"sc.exe", "description", windowsService, "Polls the PRs tracked by pr-view"},
+		{"sc.exe", "failure", windowsService, "reset=", "86400", "actions=", "restart/30000"},
+		{"reg.exe", "add", `HKLM\SYSTEM\CurrentControlSet\Services\` + windowsService, "/v", "Environment", "/t", "REG_MULTI_SZ", "/d", strings.Join(vars, `\0`), "/f"},
+	}
+	if !noStart {
+		commands = append(commands, []string{"sc.exe", "start", windowsService})
+	}
+	if printOnly {
+		for _, c := range commands {
+			fmt.Println(windowsCommandLine(c))
+		}
+		return 0
+	}
+	if verb == "config" && !noStart {
+		// a running service only picks up the new settings on restart
+		if err := stopWindowsService(); err != nil {
+			slog.Warn("stopping service", "err", err)
+		}
+	}
+	for _, c := range commands {
+		if err := runServiceCommand(c[0], c[1:]...); err != nil {
+			slog.Error("installing service, from an elevated prompt?", "err", err)
+			return 1
+		}
+	}
+	fmt.Println("installed the", windowsService, "service")
+	if creds {
+		fmt.Println("its registry key holds your credentials from the environment; reinstall after changing them")
+	}
+	if !noStart {
+		fmt.Println("pr-view daemon started, and starts again at boot")
+	}
+	return 0
+}
+
+// stopWindowsService stops the service and waits for it to be stopped,
+// sc.exe only asks it to.
+func stopWindowsService() error {
+	if err := runServiceCommand("sc.exe", "stop", windowsService); err != nil {
+		return err
+	}
+	for range 20 {
+		out, err := exec.Command("sc.exe", "query", windowsService).Output()
+		if err != nil || strings.Contains(string(out), "STOPPED") {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return errors.New("the service didn't stop within 10s")
+}
+
+// uninstallWindowsService stops and deletes the service.
+func uninstallWindowsService() int {
+	if runServiceCommand("sc.exe", "query", windowsService) != nil {
+		fmt.Println("the daemon isn't installed")
+		return 0
+	}
+	if err := stopWindowsService(); err != nil {
+		// still delete it, it may not be running
+		slog.Warn("stopping service", "err", err)
+	}
+	if err := runServiceCommand("sc.exe", "delete", windowsService); err != nil {
+		slog.Error("removing service, from an elevated prompt?", "err", err)
+		return 1
+	}
+	fmt.Println("removed the", windowsService, "service")
+	return 0
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package main
+
+import "fmt"
+
+// runDaemonService is only for Windows, where the service manager runs the
+// daemon; systemd and launchd run `pr-view daemon` itself.
+func runDaemonService(func() int) int {
+	fmt.Println("daemon service is for the Windows service manager, use pr-view daemon install")
+	return 2
+}
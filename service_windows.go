@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// The service control manager API, see winsvc.h.
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped      = 1
+	serviceStartPending = 2
+	serviceStopPending  = 3
+	serviceRunning      = 4
+
+	serviceAcceptStop     = 0x1
+	serviceAcceptShutdown = 0x4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented    = 120
+	errorServiceSpecificError  = 1066
+	errorFailedServiceNotFound = 1063 // not started by the service manager
+)
+
+var (
+	advapi32                          = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+type serviceStatus struct {
+	serviceType             uint32
+	currentState            uint32
+	controlsAccepted        uint32
+	win32ExitCode           uint32
+	serviceSpecificExitCode uint32
+	checkPoint              uint32
+	waitHint                uint32
+}
+
+// serviceHandle is the status handle of the running service.
+var serviceHandle atomic.Uintptr
+
+func setServiceState(state, accepted, exitCode uint32) {
+	st := serviceStatus{serviceType: serviceWin32OwnProcess, currentState: state, controlsAccepted: accepted}
+	if exitCode != 0 {
+		st.win32ExitCode, st.serviceSpecificExitCode = errorServiceSpecificError, exitCode
+	}
+	if state == serviceStartPending || state == serviceStopPending {
+		st.waitHint = 30000
+	}
+	if ok, _, err := procSetServiceStatus.Call(serviceHandle.Load(), uintptr(unsafe.Pointer(&st))); ok == 0 {
+		slog.Warn("reporting service status", "state", state, "err", err)
+	}
+}
+
+// runDaemonService runs the daemon as the Windows service installed by
+// `daemon install`, reporting to the service manager and stopping when it
+// asks.
+func runDaemonService(run func() int) int {
+	name, _ := syscall.UTF16PtrFromString(windowsService)
+	handler := syscall.NewCallback(func(control, eventType uint32, eventData, context uintptr) uintptr {
+		switch control {
+		case serviceControlStop, serviceControlShutdown:
+			setServiceState(serviceStopPending, 0, 0)
+			select {
+			case daemonStop <- os.Interrupt:
+			default:
+			}
+		case serviceControlInterrogate:
+		default:
+			return errorCallNotImplemented
+		}
+		return 0
+	})
+	code := 0
+	serviceMain := syscall.NewCallback(func(argc uint32, argv uintptr) uintptr {
+		h, _, err := procRegisterServiceCtrlHandlerExW.Call(uintptr(unsafe.Pointer(name)), handler, 0)
+		if h == 0 {
+			slog.Error("registering service handler", "err", err)
+			code = 1
+			return 0
+		}
+		serviceHandle.Store(h)
+		setServiceState(serviceRunning, serviceAcceptStop|serviceAcceptShutdown, 0)
+		code = run()
+		setServiceState(serviceStopped, 0, uint32(code))
+		return 0
+	})
+	table := []serviceTableEntry{{name, serviceMain}, {}}
+	// blocks until the service has stopped
+	if ok, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0]))); ok == 0 {
+		if errno, _ := err.(syscall.Errno); errno == errorFailedServiceNotFound {
+			fmt.Println("daemon service is run by the Windows service manager, see pr-view daemon install")
+			return 2
+		}
+		slog.Error("starting service", "err", err)
+		return 1
+	}
+	return code
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// mergeStates explains GitHub's mergeable_state values.
+var mergeStates = map[string]string{
+	"clean":     "ready to merge",
+	"behind":    "head branch is behind the base branch",
+	"blocked":   "blocked by branch protection (reviews or required checks)",
+	"dirty":     "merge conflicts with the base branch",
+	"unstable":  "mergeable, but non-required checks are failing",
+	"has_hooks": "mergeable, pre-receive hooks will run",
+	"draft":     "draft PRs can't be merged",
+	"unknown":   "GitHub is still computing mergeability",
+}
+
+// mergeState returns the PR's mergeable_state, "unknown" until GitHub has
+// computed it.
+func mergeState(pr PullRequest) string {
+	return firstNonEmpty(pr.MergeableState, "unknown")
+}
+
+// autoMergeLabel describes a PR's auto-merge, e.g. "squash by alice", or
+// "-" when it's off.
+func autoMergeLabel(pr PullRequest) string {
+	if pr.AutoMerge == nil {
+		return "-"
+	}
+	label := firstNonEmpty(pr.AutoMerge.MergeMethod, "merge")
+	if login := pr.AutoMerge.EnabledBy.Login; login != "" {
+		label += " by " + login
+	}
+	return label
+}
+
+// cmdShow prints the details of one PR, including why it can or can't be
+// merged yet.
+func cmdShow(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	logs := fs.Bool("logs", false, "print the last lines of failed Actions jobs' logs")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 1 {
+		fmt.Println("usage: pr-view show [--logs] <row>|owner/repo#number|<PR_URL>")
+		return 2
+	}
+	row, ok, err := lookupRow(cfg, positional[0])
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	entry := row.Entry
+	if !ok {
+		entry, err = normalizeEntry(positional[0])
+		if err != nil || !strings.Contains(entry, "#") {
+			fmt.Println("expected a PR: owner/repo#number, a PR URL or a row of the last listing")
+			return 2
+		}
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	prs, err := fetchPRs(gh, entry, prQuery{})
+	if err != nil {
+		slog.Error("fetching PR", "err", err)
+		return 1
+	}
+	results := []PRResult{{Repo: entry, PRs: prs}}
+	enrich(gh, results, map[*enricher]bool{enrichDetail: true, enrichReviews: true, enrichCI: true, enrichRequiredChecks: true, enrichBehind: true, enrichProjects: true, enrichTimeline: true, enrichDeployments: true, enrichMergeQueue: true, enrichCommits: true, enrichTemplateCheck: true})
+	if results[0].Err != nil {
+		slog.Error("fetching PR details", "err", results[0].Err)
+		return 1
+	}
+	pr := results[0].PRs[0]
+
+	fmt.Printf("%s  %s\n", entry, pr.Title)
+	field := func(name, value string) { fmt.Printf("%-11s %s\n", name+":", value) }
+	field("URL", pr.HTMLURL)
+	field("Author", pr.User.Login)
+	field("Opened", fmtTime(cfg, pr.CreatedAt))
+	field("Updated", fmtTime(cfg, pr.UpdatedAt))
+	state := pr.State
+	if pr.Draft {
+		state += " (draft)"
+	}
+	field("State", state)
+	branch := pr.Head.Ref + " -> " + pr.Base.Ref
+	if fork := pr.headFork(); fork != "" {
+		branch += " from " + fork
+	}
+	if pr.BehindBy > 0 {
+		branch += fmt.Sprintf(" (%d commits behind)", pr.BehindBy)
+	}
+	field("Branch", branch)
+	if len(pr.Labels) > 0 {
+		var names []string
+		for _, l := range pr.Labels {
+			names = append(names, l.Name)
+		}
+		field("Labels", strings.Join(names, ", "))
+	}
+	if len(pr.Projects) > 0 {
+		field("Projects", projectsSummary(pr.Projects))
+	}
+	field("Size", sizeLabel(pr)+fmt.Sprintf(" in %d files", pr.ChangedFiles))
+	ms := mergeState(pr)
+	field("Merge", ms+" - "+firstNonEmpty(mergeStates[ms], "unrecognized state"))
+	field("Auto-merge", autoMergeLabel(pr))
+	field("DCO", dcoLabel(pr.Commits))
+	for _, c := range missingSignOff(pr.Commits) {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		fmt.Printf("%-11s %s %s\n", "", c.SHA[:min(7, len(c.SHA))], truncate(subject, 72))
+	}
+	field("Signatures", signaturesLabel(pr.Commits))
+	for _, c := range unverified(pr.Commits) {
+		fmt.Printf("%-11s %s %s\n", "", c.SHA[:min(7, len(c.SHA))], strings.ReplaceAll(firstNonEmpty(c.Reason, "unverified"), "_", " "))
+	}
+	if pr.MergeQueue != nil {
+		field("Queue", queueLabel(pr.MergeQueue))
+	}
+	reviews := strings.ReplaceAll(pr.ReviewDecision, "_", " ")
+	if latest := latestReviews(pr.Reviews); len(latest) > 0 {
+		reviews += " (" + strings.Join(latest, ", ") + ")"
+	}
+	field("Reviews", reviews)
+	field("In review", reviewTimeLabel(cfg.List, pr))
+	field("Template", templateLabel(pr.TemplateIssues))
+	if titleChecked(cfg) {
+		label := titleLabel(cfg, repoName(entry), pr.Title)
+		if !titleOK(cfg, repoName(entry), pr.Title) {
+			label += " (expected " + strings.Join(titlePatternsFor(cfg, repoName(entry)), " or ") + ")"
+		}
+		field("Title", label)
+	}
+	if slaConfigured(cfg) {
+		field("SLA", slaLabel(cfg, repoName(entry), pr))
+	}
+	repo, number := repoName(entry), pr.Number
+	if threads, err := fetchReviewThreads(gh, repo, number); err != nil {
+		field("Threads", "(error: "+redact(err)+")")
+	} else {
+		field("Threads", threadsSummary(threads))
+	}
+	field("CI", pr.CIState)
+	if runs, err := fetchWorkflowRuns(gh, repo, pr.Head.SHA); err != nil {
+		field("Workflows", "(error: "+redact(err)+")")
+	} else if len(runs) > 0 {
+		for i, l := range workflowLines(gh, repo, runs, *logs) {
+			if i == 0 {
+				field("Workflows", l)
+			} else {
+				fmt.Printf("%-11s %s\n", "", l)
+			}
+		}
+	}
+	field("Required", requiredChecksSummary(pr.RequiredChecks))
+	for i, d := range pr.Deployments {
+		label := ""
+		if i == 0 {
+			label = "Deploys:"
+		}
+		fmt.Printf("%-11s %s\n", label, deploymentsSummary([]deployment{d}))
+	}
+	bp, err := fetchBranchProtection(gh, repoName(entry), pr.Base.Ref)
+	if err != nil {
+		field("Protection", "(error: "+redact(err)+")")
+	} else {
+		field("Protection", bp.summary())
+	}
+	// showing a PR counts as reading it
+	if states, err := NewStateStore(cfg); err == nil {
+		if err := markRead(states, entry, []PullRequest{pr}); err != nil {
+			slog.Error("saving read state", "err", err)
+		}
+	}
+	return 0
+}
+
+// latestReviews lists each reviewer's latest verdict as "login: STATE", in
+// the order they first reviewed.
+func latestReviews(reviews []review) []string {
+	var order []string
+	latest := map[string]string{}
+	for _, r := range reviews {
+		if r.State == "PENDING" {
+			continue
+		}
+		if _, ok := latest[r.User.Login]; !ok {
+			order = append(order, r.User.Login)
+		}
+		// a comment doesn't replace an earlier approval or change request
+		if r.State != "COMMENTED" || latest[r.User.Login] == "" {
+			latest[r.User.Login] = r.State
+		}
+	}
+	var out []string
+	for _, login := range order {
+		out = append(out, login+": "+strings.ToLower(strings.ReplaceAll(latest[login], "_", " ")))
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sizeBuckets classify PRs by changed lines (additions plus deletions), the
+// same thresholds as the common size/* labelers.
+var sizeBuckets = []struct {
+	name  string
+	max   int // inclusive; -1 for no upper bound
+	color string
+}{
+	{"XS", 9, ansiGreen},
+	{"S", 29, ansiGreen},
+	{"M", 99, ansiYellow},
+	{"L", 499, ansiRed},
+	{"XL", -1, ansiBoldRed},
+}
+
+// prSize returns the size bucket index of a PR enriched with enrichDetail.
+func prSize(pr PullRequest) int {
+	lines := pr.Additions + pr.Deletions
+	for i, b := range sizeBuckets {
+		if b.max < 0 || lines <= b.max {
+			return i
+		}
+	}
+	return len(sizeBuckets) - 1
+}
+
+// parseSize returns the bucket index for a name like "M".
+func parseSize(s string) (int, error) {
+	var names []string
+	for i, b := range sizeBuckets {
+		if strings.EqualFold(b.name, s) {
+			return i, nil
+		}
+		names = append(names, b.name)
+	}
+	return 0, fmt.Errorf("invalid size %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// sizeLabel renders the bucket with the diffstat, colored by size.
+func sizeLabel(pr PullRequest) string {
+	b := sizeBuckets[prSize(pr)]
+	return paint(b.color, fmt.Sprintf("%-2s +%d/-%d", b.name, pr.Additions, pr.Deletions))
+}
+
+// maxSizeFilter keeps PRs no bigger than the given bucket.
+func maxSizeFilter(max int) prFilter {
+	return prFilter{
+		name:  "max-size",
+		needs: []*enricher{enrichDetail},
+		keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+			return prSize(pr) <= max
+		},
+	}
+}
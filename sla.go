@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// slaFor resolves the SLAs of repo field by field: repo_settings, then the
+// first matching group, then the sla defaults.
+func slaFor(cfg *Config, repo string) SLAConfig {
+	layers := []SLAConfig{}
+	for k, rs := range cfg.RepoSettings {
+		if strings.EqualFold(k, repo) && rs.SLA != nil {
+			layers = append(layers, *rs.SLA)
+		}
+	}
+	names := make([]string, 0, len(cfg.SLA.Groups))
+	for name := range cfg.SLA.Groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if g := cfg.SLA.Groups[name]; matchesRepo(g.Repos, repo) {
+			layers = append(layers, g.SLAConfig)
+			break
+		}
+	}
+	layers = append(layers, cfg.SLA.SLAConfig)
+	var sla SLAConfig
+	for _, l := range layers {
+		if sla.FirstReview == 0 {
+			sla.FirstReview = l.FirstReview
+		}
+		if sla.Merge == 0 {
+			sla.Merge = l.Merge
+		}
+	}
+	return sla
+}
+
+func matchesRepo(patterns []string, repo string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(repo)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// slaConfigured reports whether any SLA is set, anywhere in the config.
+func slaConfigured(cfg *Config) bool {
+	if cfg.SLA.SLAConfig != (SLAConfig{}) || len(cfg.SLA.Groups) > 0 {
+		return true
+	}
+	for _, rs := range cfg.RepoSettings {
+		if rs.SLA != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// slaBreaches lists the SLAs the PR has broken with the overrun, e.g.
+// "first review +6h". Drafts aren't held to any SLA.
+func slaBreaches(sla SLAConfig, pr PullRequest, now time.Time) []string {
+	if pr.Draft {
+		return nil
+	}
+	var out []string
+	if sla.FirstReview > 0 {
+		wait, waiting := reviewWait(pr, now)
+		if over := wait - time.Duration(sla.FirstReview); over > 0 {
+			label := "first review +" + fmtDuration(over)
+			if !waiting {
+				label += " (reviewed)"
+			}
+			out = append(out, label)
+		}
+	}
+	if sla.Merge > 0 && strings.EqualFold(pr.State, "open") {
+		if over := now.Sub(pr.ReadyAt) - time.Duration(sla.Merge); over > 0 {
+			out = append(out, "merge +"+fmtDuration(over))
+		}
+	}
+	return out
+}
+
+// slaLabel renders a PR's SLA status for the sla column.
+func slaLabel(cfg *Config, repo string, pr PullRequest) string {
+	sla := slaFor(cfg, repo)
+	if sla == (SLAConfig{}) {
+		return "-"
+	}
+	breaches := slaBreaches(sla, pr, time.Now())
+	if len(breaches) == 0 {
+		return "ok"
+	}
+	return paint(ansiRed, strings.Join(breaches, ", "))
+}
+
+// slaBreachFilter keeps PRs that currently break one of their SLAs. A first
+// review that came late no longer counts, there's nothing left to act on.
+func slaBreachFilter(cfg *Config) prFilter {
+	return prFilter{
+		name:  "sla-breach",
+		needs: []*enricher{enrichTimeline},
+		keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+			for _, b := range slaBreaches(slaFor(cfg, repo), pr, time.Now()) {
+				if !strings.HasSuffix(b, "(reviewed)") {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is the open PRs at one point in time, saved by `snapshot save`.
+type Snapshot struct {
+	Name string       `json:"name"`
+	At   time.Time    `json:"at"`
+	PRs  []SnapshotPR `json:"prs"`
+	// Failed are the repos that couldn't be fetched; their PRs are left out
+	// of diffs rather than shown as closed.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// SnapshotPR is a PR as a snapshot remembers it.
+type SnapshotPR struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Author string `json:"author"`
+	Draft  bool   `json:"draft,omitempty"`
+	// Review is the review decision: approved, changes_requested or
+	// review_required.
+	Review string `json:"review"`
+}
+
+func (p SnapshotPR) key() string { return prKey(p.Repo, p.Number) }
+
+var snapshotName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func snapshotPath(name string) (string, error) {
+	if !snapshotName.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snapshots", name+".json"), nil
+}
+
+func loadSnapshot(cfg *Config, name string) (*Snapshot, error) {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readLocalFile(cfg.Security, path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot %q, save one with pr-view snapshot save %s", name, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func saveSnapshot(cfg *Config, s *Snapshot) error {
+	path, err := snapshotPath(s.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeLocalFile(cfg.Security, path, append(data, '\n'))
+}
+
+// takeSnapshot fetches the open PRs opts select, with their review state.
+func takeSnapshot(cfg *Config, gh *GitHubClient, opts *listOptions) (*Snapshot, error) {
+	opts.needs[enrichReviews] = true
+	results, err := collectPRs(cfg, gh, opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{At: time.Now()}
+	seen := map[string]bool{}
+	for _, res := range results {
+		repo := repoName(res.Repo)
+		if res.Err != nil {
+			slog.Error("listing", "repo", res.Repo, "err", redact(res.Err))
+			s.Failed = append(s.Failed, repo)
+			continue
+		}
+		for _, pr := range append(res.PRs, res.Bots...) {
+			key := prKey(repo, pr.Number)
+			if seen[key] || strings.ToLower(pr.State) != "open" {
+				continue
+			}
+			seen[key] = true
+			s.PRs = append(s.PRs, SnapshotPR{repo, pr.Number, pr.Title, pr.HTMLURL, pr.User.Login, pr.Draft, pr.ReviewDecision})
+		}
+	}
+	return s, nil
+}
+
+// prClosedState tells merged from closed for a PR gone from the open PRs:
+// merged, closed, or open again.
+func prClosedState(gh *GitHubClient, repo string, number int) (string, error) {
+	var pr struct {
+		State    string     `json:"state"`
+		MergedAt *time.Time `json:"merged_at"`
+	}
+	if err := gh.do("GET", fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, &pr); err != nil {
+		return "", err
+	}
+	if pr.MergedAt != nil {
+		return "merged", nil
+	}
+	return pr.State, nil
+}
+
+func reviewLabel(decision string) string {
+	if decision == "" {
+		return "unknown"
+	}
+	return strings.ReplaceAll(decision, "_", " ")
+}
+
+const snapshotUsage = `usage: pr-view snapshot save <name> [list filters]
+       pr-view snapshot list
+       pr-view snapshot diff <a> [b] (b defaults to the PRs now)
+       pr-view snapshot rm <name>`
+
+// cmdSnapshot saves the open PRs under a name, to compare two points in
+// time later, e.g. the start and end of a sprint.
+func cmdSnapshot(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(snapshotUsage)
+		return 2
+	}
+	switch args[0] {
+	case "save":
+		return cmdSnapshotSave(cfg, hc, args[1:])
+	case "list":
+		return cmdSnapshotList(cfg, args[1:])
+	case "rm":
+		if len(args) != 2 {
+			fmt.Println(snapshotUsage)
+			return 2
+		}
+		path, err := snapshotPath(args[1])
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				fmt.Printf("no snapshot %q\n", args[1])
+				return 1
+			}
+			slog.Error("removing snapshot", "err", err)
+			return 1
+		}
+		fmt.Println("removed snapshot", args[1])
+		return 0
+	case "diff":
+		return cmdSnapshotDiff(cfg, hc, args[1:])
+	}
+	fmt.Println(snapshotUsage)
+	return 2
+}
+
+func cmdSnapshotSave(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Println(snapshotUsage)
+		return 2
+	}
+	name := args[0]
+	if _, err := snapshotPath(name); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	fs := flag.NewFlagSet("snapshot save", flag.ContinueOnError)
+	opts, err := parseListFlags(cfg, fs, args[1:])
+	if err != nil || fs.NArg() > 0 {
+		fmt.Println(snapshotUsage)
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("snapshots hold PRs, --issues doesn't apply")
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	s, err := takeSnapshot(cfg, gh, opts)
+	if errors.Is(err, errNoRepos) {
+		fmt.Println(err)
+		return 0
+	}
+	if err != nil {
+		slog.Error("listing PRs", "err", err)
+		return 1
+	}
+	s.Name = name
+	if err := saveSnapshot(cfg, s); err != nil {
+		slog.Error("saving snapshot", "err", err)
+		return 1
+	}
+	fmt.Printf("saved snapshot %s: %d open %s\n", name, len(s.PRs), plural(len(s.PRs), "PR"))
+	if len(s.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func cmdSnapshotList(cfg *Config, args []string) int {
+	if len(args) > 0 {
+		fmt.Println(snapshotUsage)
+		return 2
+	}
+	dir, err := configDir()
+	if err != nil {
+		slog.Error("locating config dir", "err", err)
+		return 1
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "snapshots", "*.json"))
+	var snaps []*Snapshot
+	for _, p := range paths {
+		s, err := loadSnapshot(cfg, strings.TrimSuffix(filepath.Base(p), ".json"))
+		if err != nil {
+			slog.Warn("reading snapshot", "path", p, "err", err)
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	if len(snaps) == 0 {
+		fmt.Println("no snapshots yet, save one with pr-view snapshot save <name>")
+		return 0
+	}
+	slices.SortFunc(snaps, func(a, b *Snapshot) int { return a.At.Compare(b.At) })
+	width := 0
+	for _, s := range snaps {
+		width = max(width, displayWidth(s.Name))
+	}
+	for _, s := range snaps {
+		fmt.Printf("%s  %s  %d open %s\n", padWidth(s.Name, width, false), fmtTime(cfg, s.At), len(s.PRs), plural(len(s.PRs), "PR"))
+	}
+	return 0
+}
+
+// cmdSnapshotDiff shows the PRs opened, merged or closed between two
+// snapshots, and those whose review state changed or that became ready.
+func cmdSnapshotDiff(cfg *Config, hc *http.Client, args []string) int {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Println(snapshotUsage)
+		return 2
+	}
+	a, err := loadSnapshot(cfg, args[0])
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	var b *Snapshot
+	if len(args) == 2 {
+		if b, err = loadSnapshot(cfg, args[1]); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	} else {
+		opts, err := parseListFlags(cfg, flag.NewFlagSet("snapshot diff", flag.ContinueOnError), nil)
+		if err == nil {
+			b, err = takeSnapshot(cfg, gh, opts)
+		}
+		if errors.Is(err, errNoRepos) {
+			fmt.Println(err)
+			return 0
+		}
+		if err != nil {
+			slog.Error("listing PRs", "err", err)
+			return 1
+		}
+		b.Name = "now"
+	}
+
+	// repos missing from either side can't be compared
+	failed := map[string]bool{}
+	for _, r := range append(slices.Clone(a.Failed), b.Failed...) {
+		failed[strings.ToLower(r)] = true
+	}
+	before := map[string]SnapshotPR{}
+	for _, p := range a.PRs {
+		if !failed[strings.ToLower(p.Repo)] {
+			before[p.key()] = p
+		}
+	}
+	type change struct {
+		pr   SnapshotPR
+		note string
+	}
+	var opened, merged, closed, changed []change
+	after := map[string]bool{}
+	for _, p := range b.PRs {
+		if failed[strings.ToLower(p.Repo)] {
+			continue
+		}
+		after[p.key()] = true
+		old, ok := before[p.key()]
+		switch {
+		case !ok:
+			opened = append(opened, change{p, "by " + p.Author})
+		case old.Review != p.Review:
+			changed = append(changed, change{p, reviewLabel(old.Review) + " → " + reviewLabel(p.Review)})
+		case old.Draft && !p.Draft:
+			changed = append(changed, change{p, "ready for review"})
+		}
+	}
+	code := 0
+	for _, p := range a.PRs {
+		if _, ok := before[p.key()]; !ok || after[p.key()] {
+			continue
+		}
+		state, err := prClosedState(gh, p.Repo, p.Number)
+		switch {
+		case err != nil:
+			slog.Warn("checking if merged", "pr", p.key(), "err", err)
+			code = 1
+			closed = append(closed, change{p, "merged or closed"})
+		case state == "merged":
+			merged = append(merged, change{p, ""})
+		case state == "closed":
+			closed = append(closed, change{p, ""})
+		default:
+			// still open, but outside the filters b was taken with
+			changed = append(changed, change{p, "no longer matches"})
+		}
+	}
+
+	fmt.Printf("%s (%s) → %s (%s)\n", a.Name, fmtTime(cfg, a.At), b.Name, fmtTime(cfg, b.At))
+	for _, sec := range []struct {
+		title   string
+		changes []change
+	}{{"Opened", opened}, {"Merged", merged}, {"Closed", closed}, {"Changed", changed}} {
+		fmt.Printf("\n%s (%d)\n", sec.title, len(sec.changes))
+		slices.SortFunc(sec.changes, func(x, y change) int { return strings.Compare(x.pr.key(), y.pr.key()) })
+		for _, c := range sec.changes {
+			line := fmt.Sprintf("  %s#%d  %s", c.pr.Repo, c.pr.Number, truncate(c.pr.Title, 60))
+			if c.note != "" {
+				line += "  (" + c.note + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("\n%d open %s then, %d now\n", len(a.PRs), plural(len(a.PRs), "PR"), len(b.PRs))
+	if len(failed) > 0 {
+		var names []string
+		for r := range failed {
+			names = append(names, r)
+		}
+		slices.Sort(names)
+		fmt.Printf("not compared, missing from a snapshot: %s\n", strings.Join(names, ", "))
+	}
+	return code
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStaleLabel = "stale"
+	defaultStaleAfter = 30 * 24 * time.Hour
+)
+
+// labeling a PR updates it too, activity is what comes later than this
+const staleLabelSlack = 2 * time.Minute
+
+// staleExempt reports whether the stale settings leave pr alone.
+func staleExempt(sc StaleConfig, repo string, pr PullRequest) bool {
+	if sc.ExcludeDrafts && pr.Draft {
+		return true
+	}
+	if matchesRepo(sc.ExcludeRepos, repo) || containsFold(sc.ExcludeAuthors, pr.User.Login) {
+		return true
+	}
+	return slices.ContainsFunc(pr.Labels, func(l Label) bool { return containsFold(sc.ExcludeLabels, l.Name) })
+}
+
+func hasLabel(pr PullRequest, name string) bool {
+	return slices.ContainsFunc(pr.Labels, func(l Label) bool { return strings.EqualFold(l.Name, name) })
+}
+
+// addLabel adds a label to a PR, creating it in the repo if needed.
+func addLabel(c *GitHubClient, repo string, number int, label string) error {
+	return c.do("POST", fmt.Sprintf("/repos/%s/issues/%d/labels", repo, number), map[string][]string{"labels": {label}}, nil)
+}
+
+// removeLabel takes a label off a PR.
+func removeLabel(c *GitHubClient, repo string, number int, label string) error {
+	return c.do("DELETE", fmt.Sprintf("/repos/%s/issues/%d/labels/%s", repo, number, url.PathEscape(label)), nil, nil)
+}
+
+// staleSweep labels the PRs of results without activity for stale.after,
+// and takes the label off PRs it labeled once they see activity again.
+// Labels applied by anyone else are left alone.
+func staleSweep(cfg *Config, gh *GitHubClient, results []PRResult) {
+	sc := cfg.Stale
+	label := firstNonEmpty(sc.Label, defaultStaleLabel)
+	after := time.Duration(sc.After)
+	if after <= 0 {
+		after = defaultStaleAfter
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		slog.Error("initializing state", "err", err)
+		return
+	}
+	st, err := states.Load()
+	if err != nil {
+		slog.Error("loading state", "err", err)
+		return
+	}
+	labeled, unlabeled := map[string]time.Time{}, map[string]bool{}
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		repo := repoName(res.Repo)
+		for _, pr := range append(res.PRs, res.Bots...) {
+			if strings.ToLower(pr.State) != "open" {
+				continue
+			}
+			key := prKey(repo, pr.Number)
+			at, ours := st.StaleLabeled[key]
+			switch {
+			case hasLabel(pr, label) && ours && pr.UpdatedAt.After(at.Add(staleLabelSlack)):
+				if err := removeLabel(gh, repo, pr.Number, label); err != nil {
+					slog.Warn("removing stale label", "pr", key, "err", err)
+					continue
+				}
+				slog.Info("no longer stale", "pr", key, "label", label)
+				unlabeled[key] = true
+			case !hasLabel(pr, label) && ours:
+				// someone took it off, forget about it
+				unlabeled[key] = true
+			case !hasLabel(pr, label) && time.Since(pr.UpdatedAt) >= after && !staleExempt(sc, repo, pr):
+				if err := addLabel(gh, repo, pr.Number, label); err != nil {
+					slog.Warn("adding stale label", "pr", key, "err", err)
+					continue
+				}
+				slog.Info("labeled stale", "pr", key, "label", label, "idle", fmtDuration(time.Since(pr.UpdatedAt)))
+				labeled[key] = time.Now()
+			}
+		}
+	}
+	if len(labeled) == 0 && len(unlabeled) == 0 {
+		return
+	}
+	err = states.Update(func(st *State) error {
+		if st.StaleLabeled == nil {
+			st.StaleLabeled = map[string]time.Time{}
+		}
+		for k := range unlabeled {
+			delete(st.StaleLabeled, k)
+		}
+		for k, t := range labeled {
+			st.StaleLabeled[k] = t
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("saving state", "err", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFileName = "state.json"
+
+// State is local bookkeeping that is not user configuration, kept in
+// ~/.config/pr-view/state.json.
+type State struct {
+	Breakers map[string]*BreakerState `json:"breakers,omitempty"`
+	// Pins are owner/repo#number entries listed before everything else.
+	Pins []string `json:"pins,omitempty"`
+	// Seen maps owner/repo#number to the updated_at of the PR version last
+	// marked read.
+	Seen map[string]time.Time `json:"seen,omitempty"`
+	// Archived maps owner/repo#number to when it was archived; archived
+	// PRs are hidden from list until restored.
+	Archived map[string]time.Time `json:"archived,omitempty"`
+	// LastList is the PR rows of the last list table, for `open N` and
+	// `show N`.
+	LastList []ListedRow `json:"last_list,omitempty"`
+	// StaleLabeled maps owner/repo#number to when the daemon labeled it
+	// stale, so it only takes off labels it applied.
+	StaleLabeled map[string]time.Time `json:"stale_labeled,omitempty"`
+	// Digest is what the last digest saw.
+	Digest DigestState `json:"digest,omitzero"`
+	// Update is the last update_check result.
+	Update UpdateState `json:"update,omitzero"`
+}
+
+// StateStore reads and writes the state file. It goes through
+// readLocalFile/writeLocalFile so it is encrypted along with the cache.
+type StateStore struct {
+	path     string
+	security SecurityConfig
+}
+
+func NewStateStore(cfg *Config) (*StateStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return &StateStore{path: filepath.Join(dir, stateFileName), security: cfg.Security}, nil
+}
+
+func (s *StateStore) Load() (*State, error) {
+	st := &State{}
+	data, err := readLocalFile(s.security, s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return st, nil
+}
+
+// Update applies fn to the current state under the file lock and saves it.
+func (s *StateStore) Update(fn func(st *State) error) error {
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	st, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(st); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeLocalFile(s.security, s.path, append(data, '\n'))
+}
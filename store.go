@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	repoFileName   = "repos.json"
+	sqliteFileName = "pr-view.db"
+)
+
+// storeVersion is the schema version written by Save. Bump it and append a
+// migration to storeMigrations whenever the document format changes.
+const storeVersion = 2
+
+// storeDocument is the on-disk format of repos.json.
+type storeDocument struct {
+	Version int      `json:"version"`
+	Repos   []string `json:"repos"`
+}
+
+// storeMigrations[i] upgrades a raw document from version i+1 to i+2.
+var storeMigrations = []func(raw []byte) ([]byte, error){
+	migrateStoreV1ToV2,
+}
+
+// migrateStoreV1ToV2 wraps the original bare JSON array of repos in a
+// versioned document.
+func migrateStoreV1ToV2(raw []byte) ([]byte, error) {
+	var repos []string
+	if err := json.Unmarshal(raw, &repos); err != nil {
+		return nil, err
+	}
+	return json.Marshal(storeDocument{Version: 2, Repos: repos})
+}
+
+// decodeStoreDocument parses repos.json in any known schema version and
+// migrates it to the current one.
+func decodeStoreDocument(data []byte) (storeDocument, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return storeDocument{Version: storeVersion}, nil
+	}
+	version := 1 // version 1 files are a bare array
+	if data[0] != '[' {
+		var hdr struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(data, &hdr); err != nil {
+			return storeDocument{}, err
+		}
+		version = hdr.Version
+	}
+	if version < 1 {
+		return storeDocument{}, fmt.Errorf("missing or invalid schema version")
+	}
+	if version > storeVersion {
+		return storeDocument{}, fmt.Errorf("schema version %d is newer than supported version %d, upgrade pr-view", version, storeVersion)
+	}
+	for v := version; v < storeVersion; v++ {
+		migrated, err := storeMigrations[v-1](data)
+		if err != nil {
+			return storeDocument{}, fmt.Errorf("migrating schema v%d to v%d: %w", v, v+1, err)
+		}
+		data = migrated
+	}
+	var doc storeDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return storeDocument{}, err
+	}
+	return doc, nil
+}
+
+// repoBackend persists the list of tracked repos. Implementations must make
+// Update atomic with respect to other processes using the same storage.
+type repoBackend interface {
+	Load() ([]string, error)
+	Save(repos []string) error
+	Update(fn func(repos []string) ([]string, error)) error
+}
+
+// RepoStore validates and normalizes tracked repos on top of a storage
+// backend selected in the config file.
+type RepoStore struct {
+	backend repoBackend
+}
+
+func NewRepoStore() (*RepoStore, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newRepoBackend(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return &RepoStore{backend: backend}, nil
+}
+
+func newRepoBackend(cfg StorageConfig) (repoBackend, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Backend {
+	case "", "json":
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dir, repoFileName)
+		}
+		return &jsonBackend{path: path}, nil
+	case "sqlite":
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dir, sqliteFileName)
+		}
+		return newSQLiteBackend(path, cfg.SQLiteBinary)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected json or sqlite)", cfg.Backend)
+	}
+}
+
+func (s *RepoStore) Load() ([]string, error) {
+	return s.backend.Load()
+}
+
+func (s *RepoStore) Save(repos []string) error {
+	return s.backend.Save(repos)
+}
+
+// Repos returns the distinct repos of the tracked entries in order, PR
+// entries counting for their repo.
+func (s *RepoStore) Repos() ([]string, error) {
+	entries, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var repos []string
+	for _, e := range entries {
+		if name := repoName(e); !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			repos = append(repos, name)
+		}
+	}
+	return repos, nil
+}
+
+// jsonBackend stores repos in a versioned JSON document. It is the default
+// backend.
+type jsonBackend struct {
+	path string
+}
+
+func (b *jsonBackend) Load() ([]string, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	doc, err := decodeStoreDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.path, err)
+	}
+	if doc.Repos == nil {
+		return []string{}, nil
+	}
+	return doc.Repos, nil
+}
+
+// Save replaces the stored repo list. It takes the store lock so it is safe
+// to call while other processes are modifying the file.
+func (b *jsonBackend) Save(repos []string) error {
+	unlock, err := lockFile(b.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return b.save(repos)
+}
+
+func (b *jsonBackend) save(repos []string) error {
+	data, err := json.MarshalIndent(storeDocument{Version: storeVersion, Repos: repos}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(b.path, append(data, '\n'), 0o644)
+}
+
+// Update runs fn on the current repo list while holding the store lock and
+// saves the result, so concurrent add/remove calls can't lose each other's
+// changes.
+func (b *jsonBackend) Update(fn func(repos []string) ([]string, error)) error {
+	unlock, err := lockFile(b.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	repos, err := b.Load()
+	if err != nil {
+		return err
+	}
+	repos, err = fn(repos)
+	if err != nil {
+		return err
+	}
+	return b.save(repos)
+}
+
+// normalizeEntry turns a repo or PR reference (owner/repo, owner/repo#number
+// or a GitHub URL) into the owner/repo[#number] form the store uses.
+func normalizeEntry(repo string) (string, error) {
+	repo = strings.TrimSpace(repo)
+	if repo == "" {
+		return "", fmt.Errorf("empty repo")
+	}
+	// accept GitHub URLs and normalize them to owner/repo or owner/repo#number
+	if strings.Contains(repo, "github.com/") || strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://") {
+		if u, err := url.Parse(repo); err == nil {
+			path := strings.Trim(u.Path, "/")
+			parts := strings.Split(path, "/")
+			if len(parts) >= 4 && parts[2] == "pull" {
+				// owner/repo/pull/NUMBER[/...]
+				if _, err := strconv.Atoi(parts[3]); err == nil {
+					repo = fmt.Sprintf("%s/%s#%s", parts[0], parts[1], parts[3])
+				}
+			} else if len(parts) >= 2 {
+				// owner/repo or github.com/owner/repo
+				repo = fmt.Sprintf("%s/%s", parts[0], parts[1])
+			}
+		}
+	}
+	// support owner/repo or owner/repo#number
+	repoPart := repo
+	if strings.Contains(repo, "#") {
+		parts := strings.SplitN(repo, "#", 2)
+		repoPart = strings.TrimSpace(parts[0])
+		numStr := strings.TrimSpace(parts[1])
+		if repoPart == "" || numStr == "" {
+			return "", fmt.Errorf("invalid format, expected owner/repo or owner/repo#number")
+		}
+		if _, err := strconv.Atoi(numStr); err != nil {
+			return "", fmt.Errorf("invalid pull request number: %s", numStr)
+		}
+	}
+	if !strings.Contains(repoPart, "/") {
+		return "", fmt.Errorf("repo must be in owner/repo format")
+	}
+	return repo, nil
+}
+
+func (s *RepoStore) Add(repo string) error {
+	repo, err := normalizeEntry(repo)
+	if err != nil {
+		return err
+	}
+	return s.backend.Update(func(repos []string) ([]string, error) {
+		for _, r := range repos {
+			if strings.EqualFold(r, repo) {
+				return nil, fmt.Errorf("repo already exists")
+			}
+		}
+		return append(repos, repo), nil
+	})
+}
+
+func (s *RepoStore) Remove(repo string) error {
+	repo = strings.TrimSpace(repo)
+	if repo == "" {
+		return fmt.Errorf("empty repo")
+	}
+	return s.backend.Update(func(repos []string) ([]string, error) {
+		idx := -1
+		for i, r := range repos {
+			if strings.EqualFold(r, repo) || r == repo {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, fmt.Errorf("repo not found")
+		}
+		return append(repos[:idx], repos[idx+1:]...), nil
+	})
+}
+
+// trackedPRs returns whether a PR is tracked, as part of its repo or on
+// its own, and not archived, as of now: long-running commands call it again
+// so added repos take effect without a restart.
+func trackedPRs(cfg *Config) (func(repo string, number int) bool, error) {
+	store, err := NewRepoStore()
+	if err != nil {
+		return nil, err
+	}
+	repos, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	st, err := states.Load()
+	if err != nil {
+		return nil, err
+	}
+	entries := withPins(repos, st.Pins)
+	return func(repo string, number int) bool {
+		key := prKey(repo, number)
+		if _, ok := st.Archived[key]; ok {
+			return false
+		}
+		return containsFold(entries, repo) || containsFold(entries, key)
+	}, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sqliteBackend stores repos in an SQLite database. It drives the sqlite3
+// command line tool rather than linking a driver, which keeps the binary
+// free of cgo and third-party dependencies.
+type sqliteBackend struct {
+	path   string
+	binary string
+}
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS repos (
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+`
+
+func newSQLiteBackend(path, binary string) (*sqliteBackend, error) {
+	if binary == "" {
+		binary = "sqlite3"
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, fmt.Errorf("sqlite backend needs the sqlite3 command line tool: %w", err)
+	}
+	b := &sqliteBackend{path: path, binary: binary}
+	if _, err := b.exec(sqliteSchema + fmt.Sprintf("PRAGMA user_version = %d;\n", storeVersion)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// exec runs a SQL script against the database and returns its output, one
+// row per line.
+func (b *sqliteBackend) exec(script string) (string, error) {
+	cmd := exec.Command(b.binary, "-batch", "-bail", "-noheader", b.path)
+	cmd.Stdin = strings.NewReader(script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("sqlite3: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (b *sqliteBackend) Load() ([]string, error) {
+	out, err := b.exec("SELECT name FROM repos ORDER BY position;\n")
+	if err != nil {
+		return nil, err
+	}
+	repos := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			repos = append(repos, line)
+		}
+	}
+	return repos, nil
+}
+
+func (b *sqliteBackend) Save(repos []string) error {
+	unlock, err := lockFile(b.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return b.save(repos)
+}
+
+func (b *sqliteBackend) save(repos []string) error {
+	var sb strings.Builder
+	sb.WriteString("BEGIN IMMEDIATE;\nDELETE FROM repos;\n")
+	for i, r := range repos {
+		fmt.Fprintf(&sb, "INSERT INTO repos (position, name) VALUES (%d, %s);\n", i, sqlQuote(r))
+	}
+	sb.WriteString("COMMIT;\n")
+	_, err := b.exec(sb.String())
+	return err
+}
+
+// Update holds the same advisory lock as the JSON backend across the read
+// and the write, since each sqlite3 invocation is its own transaction.
+func (b *sqliteBackend) Update(fn func(repos []string) ([]string, error)) error {
+	unlock, err := lockFile(b.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	repos, err := b.Load()
+	if err != nil {
+		return err
+	}
+	repos, err = fn(repos)
+	if err != nil {
+		return err
+	}
+	return b.save(repos)
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Subscription states of GraphQL's updateSubscription. Unsubscribed still
+// notifies on mentions and review requests, ignored never does.
+const (
+	subSubscribed   = "SUBSCRIBED"
+	subUnsubscribed = "UNSUBSCRIBED"
+	subIgnored      = "IGNORED"
+)
+
+// setSubscription sets the viewer's notification subscription of a PR and
+// returns the previous state.
+func setSubscription(c *GitHubClient, repo string, number int, state string) (string, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	const query = `query($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) { pullRequest(number: $number) { id viewerSubscription } }
+}`
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ID                 string `json:"id"`
+				ViewerSubscription string `json:"viewerSubscription"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	if err := c.graphql(query, map[string]any{"owner": owner, "name": name, "number": number}, &data); err != nil {
+		return "", err
+	}
+	pr := data.Repository.PullRequest
+	if pr.ViewerSubscription == state {
+		return state, nil
+	}
+	const mutation = `mutation($id: ID!, $state: SubscriptionState!) {
+	updateSubscription(input: {subscribableId: $id, state: $state}) { subscribable { viewerSubscription } }
+}`
+	if err := c.graphql(mutation, map[string]any{"id": pr.ID, "state": state}, nil); err != nil {
+		return "", err
+	}
+	return pr.ViewerSubscription, nil
+}
+
+// cmdSubscribe is `subscribe` and `unsubscribe`: watch PRs on GitHub, or
+// stop notifications for them.
+func cmdSubscribe(cfg *Config, hc *http.Client, args []string, subscribe bool) int {
+	usage := "usage: pr-view subscribe owner/repo#number..."
+	fs := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	state, verb := subSubscribed, "subscribed to"
+	var ignore *bool
+	if !subscribe {
+		usage = "usage: pr-view unsubscribe [--ignore] owner/repo#number..."
+		ignore = fs.Bool("ignore", false, "ignore the PR: no notifications even for mentions and review requests")
+		state, verb = subUnsubscribed, "unsubscribed from"
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) == 0 {
+		fmt.Println(usage)
+		return 2
+	}
+	if ignore != nil && *ignore {
+		state, verb = subIgnored, "ignoring"
+	}
+	type target struct {
+		repo   string
+		number int
+	}
+	var prs []target
+	for _, arg := range positional {
+		repo, number, err := parsePRArg(arg)
+		if err != nil {
+			fmt.Println(usage)
+			return 2
+		}
+		prs = append(prs, target{repo, number})
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	code := 0
+	for _, pr := range prs {
+		entry := fmt.Sprintf("%s#%d", pr.repo, pr.number)
+		prev, err := setSubscription(gh, pr.repo, pr.number, state)
+		switch {
+		case err != nil:
+			slog.Error("updating subscription", "pr", entry, "err", err)
+			code = 1
+		case prev == state:
+			fmt.Printf("already %s %s\n", verb, entry)
+		default:
+			fmt.Println(verb, entry)
+		}
+	}
+	return code
+}
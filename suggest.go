@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// reviewerCandidate is someone who recently changed files a PR touches.
+type reviewerCandidate struct {
+	Login   string
+	Commits int // recent commits to the PR's files
+	Files   int // PR files among them
+	Load    int // open PRs awaiting their review
+}
+
+// score ranks candidates: familiarity with the files, discounted by how
+// many reviews they already have waiting.
+func (rc reviewerCandidate) score() float64 {
+	return float64(rc.Commits+rc.Files) / float64(1+rc.Load)
+}
+
+// suggestFiles caps the files whose history is read, the biggest PRs would
+// otherwise cost a request per file.
+const suggestFiles = 30
+
+// fileAuthors counts the authors of the last commits to each path,
+// skipping bots.
+func fileAuthors(c *GitHubClient, repo string, paths []string) (map[string]*reviewerCandidate, error) {
+	found := map[string]*reviewerCandidate{}
+	for _, p := range paths {
+		var commits []struct {
+			Author *User `json:"author"` // nil when the email isn't linked to an account
+		}
+		if err := c.do("GET", fmt.Sprintf("/repos/%s/commits?path=%s&per_page=30", repo, url.QueryEscape(p)), nil, &commits); err != nil {
+			return nil, fmt.Errorf("reading history of %s: %w", p, err)
+		}
+		touched := map[string]bool{}
+		for _, cm := range commits {
+			if cm.Author == nil || isBot(*cm.Author) {
+				continue
+			}
+			key := strings.ToLower(cm.Author.Login)
+			rc := found[key]
+			if rc == nil {
+				rc = &reviewerCandidate{Login: cm.Author.Login}
+				found[key] = rc
+			}
+			rc.Commits++
+			if !touched[key] {
+				touched[key] = true
+				rc.Files++
+			}
+		}
+	}
+	return found, nil
+}
+
+// openReviewRequests counts the open PRs awaiting each login's review,
+// anywhere on GitHub, in one aliased search query.
+func openReviewRequests(c *GitHubClient, logins []string) (map[string]int, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+	var params, fields []string
+	vars := map[string]any{}
+	for i, l := range logins {
+		params = append(params, fmt.Sprintf("$q%d: String!", i))
+		fields = append(fields, fmt.Sprintf("u%d: search(query: $q%d, type: ISSUE) { issueCount }", i, i))
+		vars[fmt.Sprintf("q%d", i)] = "is:pr is:open archived:false review-requested:" + l
+	}
+	query := fmt.Sprintf("query(%s) {\n%s\n}", strings.Join(params, ", "), strings.Join(fields, "\n"))
+	var data map[string]struct {
+		IssueCount int `json:"issueCount"`
+	}
+	if err := c.graphql(query, vars, &data); err != nil {
+		return nil, err
+	}
+	load := map[string]int{}
+	for i, l := range logins {
+		load[strings.ToLower(l)] = data[fmt.Sprintf("u%d", i)].IssueCount
+	}
+	return load, nil
+}
+
+// requestReviewers asks logins to review a PR.
+func requestReviewers(c *GitHubClient, repo string, number int, logins []string) error {
+	return c.do("POST", fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repo, number), map[string]any{"reviewers": logins}, nil)
+}
+
+const suggestUsage = "usage: pr-view suggest-reviewers owner/repo#number [--count 2] [--apply]"
+
+// cmdSuggestReviewers proposes reviewers for a PR from who recently changed
+// the files it touches, preferring those with fewer reviews waiting, and
+// with --apply requests their review.
+func cmdSuggestReviewers(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("suggest-reviewers", flag.ContinueOnError)
+	count := fs.Int("count", 2, "number of reviewers to suggest")
+	apply := fs.Bool("apply", false, "request reviews from the suggested reviewers")
+	repo, number, err := parsePRCommand(fs, args)
+	if err != nil || *count < 1 {
+		fmt.Println(suggestUsage)
+		return 2
+	}
+	entry := fmt.Sprintf("%s#%d", repo, number)
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	prs, err := fetchPRs(gh, entry, prQuery{})
+	if err != nil {
+		slog.Error("fetching PR", "err", err)
+		return 1
+	}
+	pr := prs[0]
+	if err := enrichFiles.run(gh, repo, &pr); err != nil {
+		slog.Error("fetching changed files", "err", err)
+		return 1
+	}
+	files := pr.Files
+	if len(files) > suggestFiles {
+		slog.Info("reading the history of the first files only", "files", suggestFiles, "changed", len(files))
+		files = files[:suggestFiles]
+	}
+	found, err := fileAuthors(gh, repo, files)
+	if err != nil {
+		slog.Error("finding recent authors", "err", err)
+		return 1
+	}
+	// the author can't review, and those already asked needn't be again
+	delete(found, strings.ToLower(pr.User.Login))
+	for _, u := range pr.RequestedReviewers {
+		delete(found, strings.ToLower(u.Login))
+	}
+	if len(found) == 0 {
+		fmt.Println("no one else changed these files recently, nothing to suggest")
+		return 0
+	}
+	var candidates []reviewerCandidate
+	var logins []string
+	for _, rc := range found {
+		candidates = append(candidates, *rc)
+		logins = append(logins, rc.Login)
+	}
+	load, err := openReviewRequests(gh, logins)
+	if err != nil {
+		slog.Error("counting open review requests", "err", err)
+		return 1
+	}
+	for i := range candidates {
+		candidates[i].Load = load[strings.ToLower(candidates[i].Login)]
+	}
+	slices.SortFunc(candidates, func(a, b reviewerCandidate) int {
+		if a.score() != b.score() {
+			if a.score() > b.score() {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.Login), strings.ToLower(b.Login))
+	})
+
+	picked := candidates[:min(*count, len(candidates))]
+	fmt.Printf("%s  %s\n", entry, pr.Title)
+	width := 0
+	for _, rc := range candidates {
+		width = max(width, displayWidth(rc.Login))
+	}
+	for i, rc := range candidates {
+		mark := "  "
+		if i < len(picked) {
+			mark = "* "
+		}
+		fmt.Printf("%s%s  %d %s to %d changed %s, %d %s waiting\n", mark, padWidth(rc.Login, width, false),
+			rc.Commits, plural(rc.Commits, "commit"), rc.Files, plural(rc.Files, "file"), rc.Load, plural(rc.Load, "review"))
+	}
+	var suggested []string
+	for _, rc := range picked {
+		suggested = append(suggested, rc.Login)
+	}
+	if !*apply {
+		fmt.Printf("suggested: %s (request them with --apply)\n", strings.Join(suggested, ", "))
+		return 0
+	}
+	if err := requestReviewers(gh, repo, number, suggested); err != nil {
+		slog.Error("requesting reviews", "err", err)
+		return 1
+	}
+	fmt.Println("requested reviews from", strings.Join(suggested, ", "))
+	return 0
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	gistDescription = "pr-view config"
+	gistReposFile   = "repos.json"
+	gistConfigFile  = "config.json"
+)
+
+type gistFile struct {
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+	RawURL    string `json:"raw_url,omitempty"`
+}
+
+type gist struct {
+	ID      string              `json:"id"`
+	HTMLURL string              `json:"html_url"`
+	Files   map[string]gistFile `json:"files"`
+}
+
+// pushConfig uploads the repo list and settings to the configured private
+// gist, creating the gist on first use. It returns the gist.
+func pushConfig(c *GitHubClient) (*gist, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN with gist scope is required")
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		return nil, err
+	}
+	repos, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	reposJSON, err := json.MarshalIndent(storeDocument{Version: storeVersion, Repos: repos}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	cfgJSON, err := json.MarshalIndent(withoutSecrets(cfg), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]any{
+		"description": gistDescription,
+		"files": map[string]gistFile{
+			gistReposFile:  {Content: string(reposJSON)},
+			gistConfigFile: {Content: string(cfgJSON)},
+		},
+	}
+	var g gist
+	if cfg.Sync.GistID == "" {
+		payload["public"] = false
+		if err := c.do("POST", "/gists", payload, &g); err != nil {
+			return nil, err
+		}
+		cfg.Sync.GistID = g.ID
+		if err := SaveConfig(cfg); err != nil {
+			return nil, fmt.Errorf("created gist %s but failed to record it: %w", g.ID, err)
+		}
+		return &g, nil
+	}
+	if err := c.do("PATCH", "/gists/"+cfg.Sync.GistID, payload, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// pullConfig replaces the local repo list and settings with the contents of
+// the gist. gistID overrides the configured gist when non-empty.
+func pullConfig(c *GitHubClient, gistID string) (*gist, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if gistID == "" {
+		gistID = cfg.Sync.GistID
+	}
+	if gistID == "" {
+		return nil, fmt.Errorf("no gist configured, run `pr-view config push` first or pass a gist id")
+	}
+	var g gist
+	if err := c.do("GET", "/gists/"+gistID, nil, &g); err != nil {
+		return nil, err
+	}
+	reposFile, ok := g.Files[gistReposFile]
+	if !ok {
+		return nil, fmt.Errorf("gist %s has no %s", gistID, gistReposFile)
+	}
+	reposData, err := gistContent(c, reposFile)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeStoreDocument(reposData)
+	if err != nil {
+		return nil, fmt.Errorf("gist %s: %w", gistReposFile, err)
+	}
+	newCfg := &Config{}
+	if f, ok := g.Files[gistConfigFile]; ok {
+		data, err := gistContent(c, f)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, newCfg); err != nil {
+			return nil, fmt.Errorf("gist %s: %w", gistConfigFile, err)
+		}
+	}
+	newCfg.Sync.GistID = gistID
+	keepSecrets(newCfg, cfg)
+	// save the config first so the repos land in the backend it selects
+	if err := SaveConfig(newCfg); err != nil {
+		return nil, err
+	}
+	store, err := NewRepoStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Save(doc.Repos); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// withoutSecrets returns a copy of cfg for the gist, leaving out the
+// secrets that stay on this machine.
+func withoutSecrets(cfg *Config) *Config {
+	pub := *cfg
+	pub.Webhook.Secret = ""
+	pub.Auth.Vault.SecretID = ""
+	// collector API keys go in the headers
+	pub.Tracing.Headers = nil
+	return &pub
+}
+
+// keepSecrets carries the local secrets withoutSecrets left out of the gist
+// over to the config pulled from it.
+func keepSecrets(pulled, local *Config) {
+	pulled.Webhook.Secret = firstNonEmpty(pulled.Webhook.Secret, local.Webhook.Secret)
+	pulled.Auth.Vault.SecretID = firstNonEmpty(pulled.Auth.Vault.SecretID, local.Auth.Vault.SecretID)
+	if len(pulled.Tracing.Headers) == 0 {
+		pulled.Tracing.Headers = local.Tracing.Headers
+	}
+}
+
+// gistContent returns the full content of a gist file, following raw_url
+// for files the API truncated.
+func gistContent(c *GitHubClient, f gistFile) ([]byte, error) {
+	if !f.Truncated {
+		return []byte(f.Content), nil
+	}
+	resp, err := c.http.Get(f.RawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", f.RawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// teamMembers returns the logins of a team's members, including those of
+// its child teams.
+func teamMembers(c *GitHubClient, org, slug string) ([]string, error) {
+	var logins []string
+	next := fmt.Sprintf("/orgs/%s/teams/%s/members?per_page=100", org, slug)
+	for next != "" {
+		var page []User
+		var err error
+		if next, err = c.doPage("GET", next, nil, &page); err != nil {
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return nil, fmt.Errorf("team %s/%s not found, or the token lacks the read:org scope", org, slug)
+			}
+			return nil, err
+		}
+		for _, u := range page {
+			logins = append(logins, u.Login)
+		}
+	}
+	return logins, nil
+}
+
+// searchSorts maps list sorts onto the search API's; it has no long-running
+// order, so that lists the oldest first like created.
+var searchSorts = map[string]string{"created": "created", "updated": "updated", "popularity": "comments", "long-running": "created"}
+
+// searchPRs returns the PRs matching a search query, with the repo of each,
+// following every page. The search API stops at 1000 results.
+func searchPRs(c *GitHubClient, q string) ([]PullRequest, []string, error) {
+	query := `query($q: String!, $after: String) {
+	search(query: $q, type: ISSUE, first: 100, after: $after) {
+		pageInfo { hasNextPage endCursor }
+		nodes { ... on PullRequest { ...prFields repository { nameWithOwner } } }
+	}
+}
+` + prFragment
+	vars := map[string]any{"q": q}
+	var prs []PullRequest
+	var repos []string
+	for {
+		var data struct {
+			Search struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					gqlPullRequest
+					Repository struct {
+						NameWithOwner string `json:"nameWithOwner"`
+					} `json:"repository"`
+				} `json:"nodes"`
+			} `json:"search"`
+		}
+		if err := c.graphql(query, vars, &data); err != nil {
+			return nil, nil, err
+		}
+		for _, n := range data.Search.Nodes {
+			prs = append(prs, n.toPullRequest())
+			repos = append(repos, n.Repository.NameWithOwner)
+		}
+		if !data.Search.PageInfo.HasNextPage {
+			return prs, repos, nil
+		}
+		vars["after"] = data.Search.PageInfo.EndCursor
+	}
+}
+
+// teamSearchConcurrency bounds the searches of a team listing in flight.
+const teamSearchConcurrency = 4
+
+// teamPRs finds the org's open PRs authored by a member of the team or
+// awaiting review from one of them or the team itself, one search per
+// member and role, and groups them by repo in name order.
+func teamPRs(gh *GitHubClient, opts *listOptions, org, slug string) ([]PRResult, error) {
+	members, err := teamMembers(gh, org, slug)
+	if err != nil {
+		return nil, err
+	}
+	base := fmt.Sprintf("is:pr is:open archived:false org:%s sort:%s-%s", org, searchSorts[opts.sort], opts.direction)
+	queries := []string{fmt.Sprintf("%s team-review-requested:%s/%s", base, org, slug)}
+	for _, m := range members {
+		queries = append(queries, base+" author:"+m, base+" review-requested:"+m)
+	}
+	slog.Debug("searching team PRs", "team", org+"/"+slug, "members", len(members), "queries", len(queries))
+
+	type found struct {
+		prs   []PullRequest
+		repos []string
+		err   error
+	}
+	results := make([]found, len(queries))
+	// few at a time, the search API has a tighter secondary rate limit
+	sem := make(chan struct{}, teamSearchConcurrency)
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			prs, repos, err := searchPRs(gh, q)
+			results[i] = found{prs, repos, err}
+		}()
+	}
+	wg.Wait()
+
+	byRepo := map[string]*PRResult{}
+	seen := map[string]bool{}
+	for _, f := range results {
+		if f.err != nil {
+			return nil, fmt.Errorf("searching PRs: %w", f.err)
+		}
+		for i, pr := range f.prs {
+			if seen[pr.NodeID] {
+				continue
+			}
+			seen[pr.NodeID] = true
+			res := byRepo[f.repos[i]]
+			if res == nil {
+				res = &PRResult{Repo: f.repos[i]}
+				byRepo[f.repos[i]] = res
+			}
+			res.PRs = append(res.PRs, pr)
+		}
+	}
+	var grouped []PRResult
+	for _, res := range byRepo {
+		// the searches were merged, restore the order within each repo
+		slices.SortStableFunc(res.PRs, func(a, b PullRequest) int {
+			ta, tb := a.UpdatedAt, b.UpdatedAt
+			if searchSorts[opts.sort] == "created" {
+				ta, tb = a.CreatedAt, b.CreatedAt
+			}
+			if opts.direction == "asc" {
+				return ta.Compare(tb)
+			}
+			return tb.Compare(ta)
+		})
+		if limit := opts.limitFor(res.Repo); limit > 0 && len(res.PRs) > limit {
+			res.PRs = res.PRs[:limit]
+		}
+		grouped = append(grouped, *res)
+	}
+	slices.SortFunc(grouped, func(a, b PRResult) int { return strings.Compare(strings.ToLower(a.Repo), strings.ToLower(b.Repo)) })
+	return grouped, nil
+}
+
+const teamUsage = "usage: pr-view team <org/team> [list flags]"
+
+// cmdTeam lists the PRs a GitHub team's members authored or are asked to
+// review, across the team's org, using the filters, columns and output
+// options of list.
+func cmdTeam(cfg *Config, hc *http.Client, args []string) int {
+	var team string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		team, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("team", flag.ContinueOnError)
+	opts, err := parseListFlags(cfg, fs, args)
+	if err != nil {
+		return 2
+	}
+	if team == "" && fs.NArg() == 1 {
+		team = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		team = ""
+	}
+	org, slug, ok := strings.Cut(team, "/")
+	if !ok || org == "" || slug == "" || strings.Contains(slug, "/") {
+		fmt.Println(teamUsage)
+		return 2
+	}
+	if opts.issues {
+		fmt.Println("team lists PRs, --issues doesn't apply")
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	opts.source = func(gh *GitHubClient, opts *listOptions) ([]PRResult, error) {
+		return teamPRs(gh, opts, org, slug)
+	}
+	return runList(cfg, gh, opts)
+}
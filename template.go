@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+)
+
+// templateColors are the names `color` accepts.
+var templateColors = map[string]string{
+	"red":     ansiRed,
+	"green":   ansiGreen,
+	"yellow":  ansiYellow,
+	"blue":    "\x1b[34m",
+	"magenta": "\x1b[35m",
+	"cyan":    "\x1b[36m",
+	"gray":    "\x1b[90m",
+	"bold":    "\x1b[1m",
+	"dim":     "\x1b[2m",
+}
+
+// parseTemplate compiles a --template, reading it from a file when it
+// starts with @. The template runs once with the list of PRs as its data,
+// so it can print headers and totals around a range over them.
+func parseTemplate(cfg *Config, src string) (*template.Template, error) {
+	if name, ok := strings.CutPrefix(src, "@"); ok {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		src = string(data)
+	}
+	t, err := template.New("list").Funcs(templateFuncs(cfg)).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return t, nil
+}
+
+// templateFuncs are the helpers available to --template, enough to rebuild
+// the table: colors, relative and configured times, width-aware truncation and padding,
+// plurals, joins, aligned tables and every --columns value.
+func templateFuncs(cfg *Config) template.FuncMap {
+	var table [][]string
+	return template.FuncMap{
+		"color": func(name string, v any) (string, error) {
+			code, ok := templateColors[name]
+			if !ok {
+				return "", fmt.Errorf("unknown color %q", name)
+			}
+			return paint(code, fmt.Sprint(v)), nil
+		},
+		"timeago": func(v any) (string, error) {
+			t, err := templateTime(v)
+			if err != nil || t.IsZero() {
+				return "", err
+			}
+			return fmtDuration(time.Since(t)) + " ago", nil
+		},
+		"time": func(v any) (string, error) {
+			t, err := templateTime(v)
+			if err != nil || t.IsZero() {
+				return "", err
+			}
+			return fmtTime(cfg, t), nil
+		},
+		"truncate": func(width int, v any) string { return truncateWidth(fmt.Sprint(v), width) },
+		"pad":      func(width int, v any) string { return padWidth(fmt.Sprint(v), width, false) },
+		"padLeft":  func(width int, v any) string { return padWidth(fmt.Sprint(v), width, true) },
+		"pluralize": func(n int, word string) string {
+			return fmt.Sprintf("%d %s", n, plural(n, word))
+		},
+		"join": func(sep string, v any) (string, error) {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				return "", fmt.Errorf("join needs a list, not %T", v)
+			}
+			parts := make([]string, rv.Len())
+			for i := range parts {
+				parts[i] = fmt.Sprint(rv.Index(i).Interface())
+			}
+			return strings.Join(parts, sep), nil
+		},
+		"column": func(name string, pr jsonPR) (string, error) {
+			for _, c := range columnRegistry {
+				if c.name == name {
+					return c.value(cfg, PRResult{Repo: pr.Repo, Pinned: pr.Pinned}, pr.PullRequest), nil
+				}
+			}
+			return "", fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(columnNames(), ", "))
+		},
+		// tablerow collects a row and tablerender prints the rows collected
+		// so far aligned like the list table
+		"tablerow": func(cells ...any) string {
+			row := make([]string, len(cells))
+			for i, c := range cells {
+				row[i] = fmt.Sprint(c)
+			}
+			table = append(table, row)
+			return ""
+		},
+		"tablerender": func() string {
+			var widths []int
+			for _, row := range table {
+				for i, c := range row {
+					if i == len(widths) {
+						widths = append(widths, 0)
+					}
+					widths[i] = max(widths[i], displayWidth(c))
+				}
+			}
+			var b strings.Builder
+			for _, row := range table {
+				var line strings.Builder
+				for i, c := range row {
+					line.WriteString(padWidth(c, widths[i], false) + "  ")
+				}
+				b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+			}
+			table = nil
+			return b.String()
+		},
+	}
+}
+
+// templateColumns returns the columns t reads with `column "name"`, so
+// their enrichers run before it executes.
+func templateColumns(t *template.Template) []column {
+	var cols []column
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, c := range n.Nodes {
+					walk(c)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, c := range n.Cmds {
+					walk(c)
+				}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) > 1 {
+				id, ok1 := n.Args[0].(*parse.IdentifierNode)
+				name, ok2 := n.Args[1].(*parse.StringNode)
+				if ok1 && ok2 && id.Ident == "column" {
+					if c, err := parseColumns(name.Text); err == nil {
+						cols = append(cols, c...)
+					}
+				}
+			}
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil {
+			walk(tt.Tree.Root)
+		}
+	}
+	return cols
+}
+
+// templateTime accepts the time fields of a PR, set or not.
+func templateTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, nil
+		}
+		return *t, nil
+	case string:
+		return time.Parse(time.RFC3339, t)
+	}
+	return time.Time{}, fmt.Errorf("timeago needs a time, not %T", v)
+}
+
+// runeWidth is the number of terminal cells r takes: 0 for combining marks,
+// 2 for East Asian wide characters and emoji, 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || r == '\u200d':
+		return 0
+	case r >= 0x1100 && r <= 0x115f, // Hangul Jamo
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f, // CJK ... Yi
+		r >= 0xac00 && r <= 0xd7a3,                // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,                // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,                // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,                // fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,                // fullwidth signs
+		r >= 0x1f300 && r <= 0x1faff,              // emoji
+		r >= 0x20000 && r <= 0x3fffd:              // CJK extensions
+		return 2
+	}
+	return 1
+}
+
+// displayWidth is the on-screen width of s, ignoring color escapes.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range ansiEscape.ReplaceAllString(s, "") {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// truncateWidth cuts s to at most width cells, ending in "..." when cut.
+// Color escapes are dropped from cut strings.
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	s = ansiEscape.ReplaceAllString(s, "")
+	ellipsis := "..."
+	if width <= 3 {
+		ellipsis = ""
+	}
+	var b strings.Builder
+	used := len(ellipsis)
+	for _, r := range s {
+		if used+runeWidth(r) > width {
+			break
+		}
+		used += runeWidth(r)
+		b.WriteRune(r)
+	}
+	return b.String() + ellipsis
+}
+
+// padWidth pads s with spaces to width cells, on the left when alignRight
+// is set.
+func padWidth(s string, width int, alignRight bool) string {
+	fill := strings.Repeat(" ", max(0, width-displayWidth(s)))
+	if alignRight {
+		return fill + s
+	}
+	return s + fill
+}
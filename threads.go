@@ -0,0 +1,322 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// reviewThread is a conversation on a PR's diff.
+type reviewThread struct {
+	ID       string
+	Path     string
+	Line     int
+	Resolved bool
+	Outdated bool
+	// Author and Body are from the comment that started the thread.
+	Author   string
+	Body     string
+	Comments int
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) {
+		pullRequest(number: $number) {
+			reviewThreads(first: 100) {
+				nodes {
+					id isResolved isOutdated path line originalLine
+					comments(first: 1) { totalCount nodes { author { login } body } }
+				}
+			}
+		}
+	}
+}`
+
+// fetchReviewThreads returns the review threads of a PR in the order
+// GitHub lists them, which `threads` numbers from 1.
+func fetchReviewThreads(c *GitHubClient, repo string, number int) ([]reviewThread, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	var data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						ID           string `json:"id"`
+						IsResolved   bool   `json:"isResolved"`
+						IsOutdated   bool   `json:"isOutdated"`
+						Path         string `json:"path"`
+						Line         int    `json:"line"`
+						OriginalLine int    `json:"originalLine"`
+						Comments     struct {
+							TotalCount int `json:"totalCount"`
+							Nodes      []struct {
+								Author struct {
+									Login string `json:"login"`
+								} `json:"author"`
+								Body string `json:"body"`
+							} `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": owner, "name": name, "number": number}
+	if err := c.graphql(reviewThreadsQuery, vars, &data); err != nil {
+		return nil, err
+	}
+	var threads []reviewThread
+	for _, n := range data.Repository.PullRequest.ReviewThreads.Nodes {
+		t := reviewThread{
+			ID:       n.ID,
+			Path:     n.Path,
+			Line:     n.Line,
+			Resolved: n.IsResolved,
+			Outdated: n.IsOutdated,
+			Comments: n.Comments.TotalCount,
+		}
+		if t.Line == 0 {
+			// outdated threads only know where they started
+			t.Line = n.OriginalLine
+		}
+		if len(n.Comments.Nodes) > 0 {
+			t.Author = n.Comments.Nodes[0].Author.Login
+			t.Body = n.Comments.Nodes[0].Body
+		}
+		threads = append(threads, t)
+	}
+	return threads, nil
+}
+
+// pickThread finds a thread of the PR by node ID or by its number in the
+// `threads` listing.
+func pickThread(threads []reviewThread, id string) (*reviewThread, error) {
+	if n, err := strconv.Atoi(id); err == nil {
+		if n < 1 || n > len(threads) {
+			return nil, fmt.Errorf("PR has %d review %s, no thread %d", len(threads), plural(len(threads), "thread"), n)
+		}
+		return &threads[n-1], nil
+	}
+	for i, t := range threads {
+		if t.ID == id {
+			return &threads[i], nil
+		}
+	}
+	return nil, fmt.Errorf("PR has no review thread %s, list them with: pr-view threads", id)
+}
+
+// threadsSummary is the Threads line of `show`, e.g. "2 unresolved of 5".
+func threadsSummary(threads []reviewThread) string {
+	open := 0
+	for _, t := range threads {
+		if !t.Resolved {
+			open++
+		}
+	}
+	if len(threads) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d unresolved of %d", open, len(threads))
+}
+
+// parsePRCommand parses fs from args, accepting the PR before or after the
+// flags, and returns the PR's repo and number. A missing PR is reported as
+// an error for the caller to answer with its usage line.
+func parsePRCommand(fs *flag.FlagSet, args []string) (string, int, error) {
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(positional) != 1 {
+		return "", 0, fmt.Errorf("expected one PR, got %d arguments", len(positional))
+	}
+	return parsePRArg(positional[0])
+}
+
+// parseInterleaved parses fs from args with positional arguments allowed
+// between the flags, and returns the positional arguments.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return positional, nil
+}
+
+// parsePRArg resolves owner/repo#number or a PR URL, printing why it isn't
+// one.
+func parsePRArg(arg string) (string, int, error) {
+	entry, err := normalizeEntry(arg)
+	if err != nil || !strings.Contains(entry, "#") {
+		err := fmt.Errorf("expected a PR: owner/repo#number or a PR URL")
+		fmt.Println(err)
+		return "", 0, err
+	}
+	repo, num, _ := strings.Cut(entry, "#")
+	number, _ := strconv.Atoi(num)
+	return repo, number, nil
+}
+
+const threadsUsage = "usage: pr-view threads owner/repo#number [--all]"
+
+// cmdThreads lists a PR's unresolved review threads, numbered for reply and
+// resolve.
+func cmdThreads(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("threads", flag.ContinueOnError)
+	all := fs.Bool("all", false, "include resolved threads")
+	repo, number, err := parsePRCommand(fs, args)
+	if err != nil {
+		fmt.Println(threadsUsage)
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	threads, err := fetchReviewThreads(gh, repo, number)
+	if err != nil {
+		slog.Error("fetching review threads", "err", err)
+		return 1
+	}
+	shown := 0
+	for i, t := range threads {
+		if t.Resolved && !*all {
+			continue
+		}
+		shown++
+		var tags []string
+		if t.Resolved {
+			tags = append(tags, "resolved")
+		}
+		if t.Outdated {
+			tags = append(tags, "outdated")
+		}
+		if t.Comments > 1 {
+			tags = append(tags, fmt.Sprintf("%d comments", t.Comments))
+		}
+		line := fmt.Sprintf("%3d  %s  %s:%d", i+1, t.ID, t.Path, t.Line)
+		if len(tags) > 0 {
+			line += " (" + strings.Join(tags, ", ") + ")"
+		}
+		fmt.Println(line)
+		first, _, _ := strings.Cut(strings.TrimSpace(t.Body), "\n")
+		fmt.Printf("     %s: %s\n", t.Author, truncate(first, 100))
+	}
+	if shown == 0 {
+		fmt.Println("no unresolved review threads")
+	}
+	return 0
+}
+
+const replyUsage = `usage: pr-view reply owner/repo#number --thread <id|n> -m "message" [--resolve]`
+
+// cmdReply answers a review thread, optionally resolving it too.
+func cmdReply(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("reply", flag.ContinueOnError)
+	threadID := fs.String("thread", "", "thread ID or number from `pr-view threads`")
+	message := fs.String("m", "", "reply text (Markdown)")
+	resolve := fs.Bool("resolve", false, "resolve the thread after replying")
+	repo, number, err := parsePRCommand(fs, args)
+	if err != nil || *threadID == "" || strings.TrimSpace(*message) == "" {
+		fmt.Println(replyUsage)
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	thread, code := lookupThread(gh, repo, number, *threadID)
+	if thread == nil {
+		return code
+	}
+	const mutation = `mutation($id: ID!, $body: String!) {
+	addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $id, body: $body}) { comment { url } }
+}`
+	var data struct {
+		AddPullRequestReviewThreadReply struct {
+			Comment struct {
+				URL string `json:"url"`
+			} `json:"comment"`
+		} `json:"addPullRequestReviewThreadReply"`
+	}
+	if err := gh.graphql(mutation, map[string]any{"id": thread.ID, "body": *message}, &data); err != nil {
+		slog.Error("replying", "err", err)
+		return 1
+	}
+	fmt.Println("replied", data.AddPullRequestReviewThreadReply.Comment.URL)
+	if *resolve {
+		return setThreadResolved(gh, thread, true)
+	}
+	return 0
+}
+
+const resolveUsage = "usage: pr-view resolve owner/repo#number --thread <id|n> [--unresolve]"
+
+// cmdResolve marks a review thread resolved, or unresolved again.
+func cmdResolve(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	threadID := fs.String("thread", "", "thread ID or number from `pr-view threads`")
+	unresolve := fs.Bool("unresolve", false, "reopen a resolved thread")
+	repo, number, err := parsePRCommand(fs, args)
+	if err != nil || *threadID == "" {
+		fmt.Println(resolveUsage)
+		return 2
+	}
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	thread, code := lookupThread(gh, repo, number, *threadID)
+	if thread == nil {
+		return code
+	}
+	return setThreadResolved(gh, thread, !*unresolve)
+}
+
+// lookupThread fetches the PR's threads and picks one, returning the exit
+// code when that fails.
+func lookupThread(gh *GitHubClient, repo string, number int, id string) (*reviewThread, int) {
+	threads, err := fetchReviewThreads(gh, repo, number)
+	if err != nil {
+		slog.Error("fetching review threads", "err", err)
+		return nil, 1
+	}
+	thread, err := pickThread(threads, id)
+	if err != nil {
+		fmt.Printf("%s#%d: %s\n", repo, number, err)
+		return nil, 1
+	}
+	return thread, 0
+}
+
+func setThreadResolved(gh *GitHubClient, thread *reviewThread, resolved bool) int {
+	mutation := `mutation($id: ID!) { resolveReviewThread(input: {threadId: $id}) { thread { isResolved } } }`
+	verb := "resolved"
+	if !resolved {
+		mutation = `mutation($id: ID!) { unresolveReviewThread(input: {threadId: $id}) { thread { isResolved } } }`
+		verb = "unresolved"
+	}
+	if thread.Resolved == resolved {
+		fmt.Printf("thread on %s:%d is already %s\n", thread.Path, thread.Line, verb)
+		return 0
+	}
+	if err := gh.graphql(mutation, map[string]any{"id": thread.ID}, nil); err != nil {
+		slog.Error("updating thread", "err", err)
+		return 1
+	}
+	fmt.Printf("%s thread on %s:%d\n", verb, thread.Path, thread.Line)
+	return 0
+}
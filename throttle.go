@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxRetryAfter bounds how long one request waits out secondary
+// rate limits before it fails after all.
+const defaultMaxRetryAfter = 5 * time.Minute
+
+// throttleTransport pauses every request while GitHub's secondary rate
+// limit is in effect. The limit is per account, not per request, so when
+// one response says to back off, all concurrent fetches wait, and the
+// refused request is sent again once the pause is over.
+type throttleTransport struct {
+	next    http.RoundTripper
+	maxWait time.Duration
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func newThrottleTransport(next http.RoundTripper, cfg NetworkConfig) *throttleTransport {
+	t := &throttleTransport{next: next, maxWait: defaultMaxRetryAfter}
+	if cfg.MaxRetryAfter > 0 {
+		t.maxWait = time.Duration(cfg.MaxRetryAfter)
+	}
+	return t
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	waited := time.Duration(0)
+	for {
+		start := time.Now()
+		if err := t.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		waited += time.Since(start)
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		d, limited := secondaryLimitWait(resp)
+		if !limited || waited+d > t.maxWait || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		t.pause(d)
+		// refused requests did nothing, so even a POST can go again
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// wait blocks until no pause is in effect.
+func (t *throttleTransport) wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		d := time.Until(t.until)
+		t.mu.Unlock()
+		if d <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pause holds all requests for d, unless a longer pause is running.
+func (t *throttleTransport) pause(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+		slog.Warn("secondary rate limit hit, pausing requests", "for", d.Round(time.Second))
+	}
+}
+
+// secondaryLimitWait reports whether resp is a secondary rate limit and how
+// long to back off: Retry-After when given, otherwise the minute GitHub
+// asks for. Running out of the primary quota isn't one; that only resets
+// with the hour.
+func secondaryLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return max(time.Duration(secs)*time.Second, time.Second), true
+		}
+		if at, err := http.ParseTime(ra); err == nil {
+			return max(time.Until(at), time.Second), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	// a 403 is usually a permission error; only the message tells them apart
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	if strings.Contains(strings.ToLower(string(b)), "secondary rate limit") {
+		return time.Minute, true
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// strftimeLayouts are the strftime directives time formats accept, as Go
+// layouts.
+var strftimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'Z': "MST", 'z': "-0700",
+	'F': "2006-01-02", 'T': "15:04:05", 'R': "15:04",
+}
+
+const defaultTimeFormat = "%Y-%m-%d %H:%M"
+
+// strftime formats t with a strftime-style format such as "%Y-%m-%d %H:%M".
+// Text between directives is copied as is.
+func strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch c := format[i]; c {
+		case '%':
+			b.WriteByte('%')
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			if layout, ok := strftimeLayouts[c]; ok {
+				b.WriteString(t.Format(layout))
+			} else {
+				b.WriteString("%" + string(c))
+			}
+		}
+	}
+	return b.String()
+}
+
+// checkStrftime rejects directives strftime doesn't know.
+func checkStrftime(format string) error {
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if _, ok := strftimeLayouts[format[i]]; !ok && !strings.ContainsRune("%js", rune(format[i])) {
+			return fmt.Errorf("unknown directive %%%c", format[i])
+		}
+	}
+	return nil
+}
+
+// validateTime checks the time settings, from the config or the flags.
+func validateTime(tc TimeConfig) error {
+	if s := tc.Style; s != "" && s != "relative" && s != "absolute" {
+		return fmt.Errorf("time.style: unknown style %q (expected relative or absolute)", s)
+	}
+	if err := checkStrftime(tc.Format); err != nil {
+		return fmt.Errorf("time.format: %w", err)
+	}
+	if _, err := timeLocation(tc.Timezone); err != nil {
+		return fmt.Errorf("time.timezone: %w", err)
+	}
+	return nil
+}
+
+var locations sync.Map // time zone name -> *time.Location
+
+// timeLocation loads a time zone by IANA name once; "" and "Local" are the
+// system's.
+func timeLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// fmtTime renders a timestamp as time.style says: relative like "3h ago"
+// (the default), or absolute in time.format and time.timezone.
+func fmtTime(cfg *Config, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if cfg.Time.Style != "absolute" {
+		if d := time.Until(t); d > 0 {
+			return "in " + fmtDuration(d)
+		}
+		return fmtDuration(time.Since(t)) + " ago"
+	}
+	// validated with the config, fall back to local time just in case
+	loc, err := timeLocation(cfg.Time.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	return strftime(t.In(loc), firstNonEmpty(cfg.Time.Format, defaultTimeFormat))
+}
+
+// parseDuration is time.ParseDuration that also takes leading weeks and
+// days, as in "2w", "7d" or "1d12h", since PR ages are counted in days.
+func parseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	rest := s
+	for _, u := range []struct {
+		suffix byte
+		unit   time.Duration
+	}{{'w', 7 * 24 * time.Hour}, {'d', 24 * time.Hour}} {
+		i := strings.IndexByte(rest, u.suffix)
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total += time.Duration(n) * u.unit
+		rest = rest[i+1:]
+	}
+	if rest == "" && rest != s {
+		return total, nil
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return total + d, nil
+}
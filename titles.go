@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// titlePresets are the title_patterns that name a common convention instead
+// of spelling out a regexp.
+var titlePresets = map[string]string{
+	// https://www.conventionalcommits.org: "feat(api)!: drop v1"
+	"conventional": `^(build|chore|ci|docs|feat|fix|perf|refactor|revert|style|test)(\([^)]+\))?!?: \S`,
+	// a Jira-style ticket ID first: "ABC-123: fix login", "[ABC-123] fix login"
+	"ticket": `^\[?[A-Z][A-Z0-9]+-\d+\]?:? \S`,
+}
+
+var (
+	titleRegexpsMu sync.Mutex
+	titleRegexps   = map[string]*regexp.Regexp{}
+)
+
+// titleRegexp compiles a title pattern or preset, once.
+func titleRegexp(pattern string) (*regexp.Regexp, error) {
+	titleRegexpsMu.Lock()
+	defer titleRegexpsMu.Unlock()
+	if re, ok := titleRegexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(firstNonEmpty(titlePresets[pattern], pattern))
+	if err != nil {
+		return nil, err
+	}
+	titleRegexps[pattern] = re
+	return re, nil
+}
+
+// titlePatternsFor is the title patterns of repo: its repo_settings, else
+// list.title_patterns.
+func titlePatternsFor(cfg *Config, repo string) []string {
+	for k, rs := range cfg.RepoSettings {
+		if strings.EqualFold(k, repo) && len(rs.TitlePatterns) > 0 {
+			return rs.TitlePatterns
+		}
+	}
+	return cfg.List.TitlePatterns
+}
+
+// titleChecked reports whether title patterns are set anywhere in the
+// config, which turns the check on.
+func titleChecked(cfg *Config) bool {
+	if len(cfg.List.TitlePatterns) > 0 {
+		return true
+	}
+	for _, rs := range cfg.RepoSettings {
+		if len(rs.TitlePatterns) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTitlePatterns checks that every pattern is a preset or compiles.
+func validateTitlePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := titleRegexp(p); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// titleOK reports whether title matches one of repo's patterns; without
+// patterns every title is fine.
+func titleOK(cfg *Config, repo, title string) bool {
+	patterns := titlePatternsFor(cfg, repo)
+	for _, p := range patterns {
+		// validated with the config, an invalid pattern matches nothing
+		if re, err := titleRegexp(p); err == nil && re.MatchString(title) {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
+
+// titleLabel renders the title check: "ok", "no match" in red, or "-" when
+// the repo has no patterns.
+func titleLabel(cfg *Config, repo, title string) string {
+	switch {
+	case len(titlePatternsFor(cfg, repo)) == 0:
+		return "-"
+	case titleOK(cfg, repo, title):
+		return "ok"
+	}
+	return paint(ansiRed, "no match")
+}
+
+// badTitleFilter keeps PRs whose title matches none of their repo's
+// patterns.
+func badTitleFilter(cfg *Config) prFilter {
+	return prFilter{
+		name: "bad-title",
+		keep: func(c *GitHubClient, repo string, pr PullRequest) bool {
+			return !titleOK(cfg, repo, pr.Title)
+		},
+	}
+}
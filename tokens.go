@@ -0,0 +1,216 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// poolToken is one of the configured tokens and the quota GitHub last
+// reported for it, by rate limit resource.
+type poolToken struct {
+	label  string
+	value  string
+	limits map[string]rateLimit
+}
+
+// tokenPool spreads reads over several tokens. Cached responses are kept
+// per token, so most-remaining sticks with a token until another has
+// clearly more left rather than alternating on every request.
+type tokenPool struct {
+	mu         sync.Mutex
+	tokens     []*poolToken
+	roundRobin bool
+	next       int
+	// current is the token last picked for each resource.
+	current map[string]*poolToken
+}
+
+// switchMargin is how many more requests another token must have left
+// before most-remaining moves a resource over to it.
+const switchMargin = 500
+
+// extraTokens reads the tokens named by auth.env_vars and auth.op_refs and
+// registers them for redaction. Unset variables are skipped.
+func extraTokens(cfg AuthConfig) ([]*poolToken, error) {
+	var tokens []*poolToken
+	for _, name := range cfg.EnvVars {
+		if t, _ := (envTokenSource{name: name}).Token(); t != "" {
+			tokens = append(tokens, &poolToken{label: name, value: t})
+		}
+	}
+	binary := firstNonEmpty(cfg.OpBinary, "op")
+	for _, ref := range cfg.OpRefs {
+		t, err := opTokenSource{ref: ref, binary: binary}.Token()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &poolToken{label: ref, value: t})
+	}
+	for _, t := range tokens {
+		registerSecret(t.value)
+	}
+	return tokens, nil
+}
+
+// tokenLabel names the primary token in output, without revealing it.
+func tokenLabel(cfg AuthConfig) string {
+	switch cfg.TokenSource {
+	case "op":
+		return cfg.OpRef
+	case "vault":
+		return "vault:" + cfg.Vault.Path
+	case "app":
+		return "app"
+	}
+	return firstNonEmpty(cfg.EnvVar, "GITHUB_TOKEN")
+}
+
+// newTokenPool returns a pool of the primary token and the extra ones, or
+// nil when there is only one token to use.
+func newTokenPool(cfg AuthConfig, primary string) (*tokenPool, error) {
+	extra, err := extraTokens(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*poolToken
+	if primary != "" {
+		tokens = append(tokens, &poolToken{label: tokenLabel(cfg), value: primary})
+	}
+	seen := map[string]bool{primary: true}
+	for _, t := range extra {
+		// the same token under two names would only skew the counts
+		if !seen[t.value] {
+			seen[t.value] = true
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) < 2 {
+		return nil, nil
+	}
+	return &tokenPool{tokens: tokens, roundRobin: cfg.Rotation == "round-robin", current: map[string]*poolToken{}}, nil
+}
+
+// remaining is what t has left of resource now. A token not used yet, or
+// whose window has reset, counts as full.
+func (t *poolToken) remaining(resource string, now time.Time, full int) int {
+	l, ok := t.limits[resource]
+	if !ok || now.After(l.resetAt()) {
+		return full
+	}
+	return l.Remaining
+}
+
+// pick returns the token for the next read of resource, skipping exclude
+// (a token that just ran out). It returns nil when every other token is
+// out of quota too.
+func (p *tokenPool) pick(resource string, exclude *poolToken) *poolToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	// unused tokens are assumed to have the limit the others report
+	full := 5000
+	for _, t := range p.tokens {
+		if l, ok := t.limits[resource]; ok && l.Limit > 0 {
+			full = l.Limit
+		}
+	}
+	left := func(t *poolToken) int { return t.remaining(resource, now, full) }
+	if p.roundRobin {
+		for range p.tokens {
+			t := p.tokens[p.next%len(p.tokens)]
+			p.next++
+			if t != exclude && left(t) > 0 {
+				return t
+			}
+		}
+		return nil
+	}
+	var best *poolToken
+	for _, t := range p.tokens {
+		if t != exclude && (best == nil || left(t) > left(best)) {
+			best = t
+		}
+	}
+	if best == nil || left(best) <= 0 {
+		return nil
+	}
+	if cur := p.current[resource]; cur != nil && cur != exclude && left(cur) > 0 && left(best)-left(cur) < switchMargin {
+		return cur
+	}
+	p.current[resource] = best
+	return best
+}
+
+// observe records the quota a response reports for the token that sent it.
+func (p *tokenPool) observe(t *poolToken, resource string, h http.Header) {
+	rem, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	resource = firstNonEmpty(h.Get("X-RateLimit-Resource"), resource)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.limits == nil {
+		t.limits = map[string]rateLimit{}
+	}
+	t.limits[resource] = rateLimit{Limit: limit, Remaining: rem, Used: limit - rem, Reset: reset}
+}
+
+// rateLimitResource guesses which quota a request counts against, until the
+// response says.
+func rateLimitResource(path string) string {
+	switch {
+	case strings.Contains(path, "/search/"):
+		return "search"
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	}
+	return "core"
+}
+
+// isRead reports whether a request only reads, so any token may send it.
+// Writes and GraphQL mutations go out as the primary token, keeping
+// comments, labels and reviews under one account.
+func isRead(method string, in any) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+	body, ok := in.(map[string]any)
+	if !ok || method != http.MethodPost {
+		return false
+	}
+	q, ok := body["query"].(string)
+	return ok && !strings.HasPrefix(strings.TrimSpace(q), "mutation")
+}
+
+// viewerField matches the GraphQL fields answered for whoever asks: viewer
+// itself and the viewerSubscription, viewerCanUpdate, ... of objects.
+var viewerField = regexp.MustCompile(`\bviewer`)
+
+// asViewer reports whether a read is about the token's own account, like
+// /user, /notifications or a query selecting viewer fields. Another pool
+// token would answer it for its account, so it goes out as the primary
+// token.
+func asViewer(path string, in any) bool {
+	for _, p := range []string{"/user", "/notifications"} {
+		if path == p || strings.HasPrefix(path, p+"/") || strings.HasPrefix(path, p+"?") {
+			return true
+		}
+	}
+	body, _ := in.(map[string]any)
+	q, _ := body["query"].(string)
+	return viewerField.MatchString(q)
+}
+
+// rateLimited reports whether resp was refused for lack of quota, as
+// opposed to a permission error.
+func rateLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
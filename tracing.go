@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP span kinds and status codes, see the OpenTelemetry protocol.
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusError      = 2
+)
+
+// tracer records spans and exports them as OTLP/HTTP JSON. It's nil unless
+// an endpoint is configured, which makes every span a no-op.
+var tracer *spanExporter
+
+type spanExporter struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+
+	mu    sync.Mutex
+	spans []*span
+}
+
+// span is one timed operation. A nil *span is valid and records nothing.
+type span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []any
+	err      error
+}
+
+type spanKey struct{}
+
+// setupTracing enables tracing when cfg or the standard OTEL_EXPORTER_OTLP_*
+// variables name a collector.
+func setupTracing(cfg TracingConfig) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return
+	}
+	headers := map[string]string{}
+	// OTEL_EXPORTER_OTLP_HEADERS is key=value,key=value
+	for _, kv := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	tracer = &spanExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  firstNonEmpty(cfg.ServiceName, os.Getenv("OTEL_SERVICE_NAME"), "pr-view"),
+		// the collector is not GitHub: skip the cache and retries
+		client: &http.Client{Transport: baseTransport, Timeout: 10 * time.Second},
+	}
+}
+
+// startSpan starts a span as a child of the one in ctx, if any. attrs are
+// key/value pairs like slog's.
+func startSpan(ctx context.Context, name string, attrs ...any) (context.Context, *span) {
+	if tracer == nil {
+		return ctx, nil
+	}
+	s := &span{name: name, kind: spanKindInternal, start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// set adds attributes to the span.
+func (s *span) set(attrs ...any) {
+	if s != nil {
+		s.attrs = append(s.attrs, attrs...)
+	}
+}
+
+// finish ends the span, marking it failed when err is non-nil, and queues
+// it for export.
+func (s *span) finish(err error) {
+	if s == nil {
+		return
+	}
+	s.end, s.err = time.Now(), err
+	tracer.mu.Lock()
+	tracer.spans = append(tracer.spans, s)
+	tracer.mu.Unlock()
+}
+
+// flushTraces exports the spans finished so far. Export failures are only
+// logged, tracing never fails a command.
+func flushTraces() {
+	if tracer == nil {
+		return
+	}
+	tracer.mu.Lock()
+	spans := tracer.spans
+	tracer.spans = nil
+	tracer.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := tracer.export(spans); err != nil {
+		slog.Warn("exporting traces", "endpoint", tracer.endpoint, "spans", len(spans), "err", err)
+	}
+}
+
+func (e *spanExporter) export(spans []*span) error {
+	type kv struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+	attrs := func(args []any) []kv {
+		out := []kv{}
+		for i := 0; i+1 < len(args); i += 2 {
+			k, _ := args[i].(string)
+			var v map[string]any
+			switch x := args[i+1].(type) {
+			case string:
+				v = map[string]any{"stringValue": redact(x)}
+			case bool:
+				v = map[string]any{"boolValue": x}
+			case int:
+				v = map[string]any{"intValue": strconv.Itoa(x)}
+			case float64:
+				v = map[string]any{"doubleValue": x}
+			default:
+				v = map[string]any{"stringValue": redact(x)}
+			}
+			out = append(out, kv{k, v})
+		}
+		return out
+	}
+	var out []map[string]any
+	for _, s := range spans {
+		o := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        attrs(s.attrs),
+		}
+		if s.parentID != [8]byte{} {
+			o["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			o["status"] = map[string]any{"code": statusError, "message": redact(s.err.Error())}
+		}
+		out = append(out, o)
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   map[string]any{"attributes": attrs([]any{"service.name", e.service})},
+			"scopeSpans": []any{map[string]any{"scope": map[string]any{"name": "pr-view"}, "spans": out}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// traceTransport records a client span for every request that reaches the
+// network, below the cache so cache hits stay in the cache lookup span.
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, s := startSpan(req.Context(), req.Method, "http.request.method", req.Method, "url.full", req.URL.String())
+	if s == nil {
+		return t.next.RoundTrip(req)
+	}
+	s.kind = spanKindClient
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		s.finish(err)
+		return nil, err
+	}
+	s.set("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 500 {
+		s.finish(fmt.Errorf("%s", resp.Status))
+	} else {
+		s.finish(nil)
+	}
+	return resp, nil
+}
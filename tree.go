@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// treeNode is an org or repo of the --tree output with the lines under it.
+type treeNode struct {
+	name     string
+	count    int
+	lines    []string
+	children []*treeNode
+	empty    []string // repos without open PRs, collapsed into one line
+}
+
+// printTree prints the listing nested as org, repo and PRs with tree
+// drawing. Repos without open PRs are collapsed into one line per org.
+func printTree(cfg *Config, results []PRResult, opts *listOptions) {
+	var orgs []*treeNode
+	orgIndex := map[string]*treeNode{}
+	repoIndex := map[string]*treeNode{}
+	for _, res := range results {
+		full := repoName(res.Repo)
+		owner, name, _ := strings.Cut(full, "/")
+		org, ok := orgIndex[strings.ToLower(owner)]
+		if !ok {
+			org = &treeNode{name: owner}
+			orgIndex[strings.ToLower(owner)] = org
+			orgs = append(orgs, org)
+		}
+		if res.Err == nil && len(res.PRs) == 0 && len(res.Bots) == 0 {
+			if repoIndex[strings.ToLower(full)] == nil {
+				org.empty = append(org.empty, name)
+			}
+			continue
+		}
+		repo, ok := repoIndex[strings.ToLower(full)]
+		if !ok {
+			repo = &treeNode{name: name}
+			repoIndex[strings.ToLower(full)] = repo
+			org.children = append(org.children, repo)
+		}
+		var open *breakerOpenError
+		switch {
+		case errors.As(res.Err, &open):
+			repo.lines = append(repo.lines, "("+redact(res.Err)+")")
+			continue
+		case res.Err != nil:
+			repo.lines = append(repo.lines, "(error: "+redact(res.Err)+")")
+			continue
+		}
+		for _, pr := range res.PRs {
+			repo.lines = append(repo.lines, treePRLine(cfg, res, pr, opts.columns))
+		}
+		repo.count += len(res.PRs) + len(res.Bots)
+		org.count += len(res.PRs) + len(res.Bots)
+		if len(res.Bots) > 0 {
+			repo.lines = append(repo.lines, botSummary(res.Bots))
+		}
+	}
+
+	noun := opts.noun()
+	counted := func(n *treeNode) string {
+		if n.count == 0 {
+			return n.name
+		}
+		return fmt.Sprintf("%s (%d %s)", n.name, n.count, plural(n.count, noun))
+	}
+	for _, org := range orgs {
+		fmt.Println(counted(org))
+		items := len(org.children)
+		if len(org.empty) > 0 {
+			items++
+		}
+		for i, repo := range org.children {
+			branch, indent := treeBranch(i == items-1)
+			fmt.Println(branch + counted(repo))
+			for j, l := range repo.lines {
+				b, _ := treeBranch(j == len(repo.lines)-1)
+				fmt.Println(indent + b + l)
+			}
+		}
+		if len(org.empty) > 0 {
+			branch, _ := treeBranch(true)
+			fmt.Printf("%s(%d %s without open %ss: %s)\n", branch, len(org.empty), plural(len(org.empty), "repo"), noun, strings.Join(org.empty, ", "))
+		}
+	}
+}
+
+// treeBranch returns the connector of a tree item and the indent of its
+// children.
+func treeBranch(last bool) (branch, indent string) {
+	if last {
+		return "└── ", "    "
+	}
+	return "├── ", "│   "
+}
+
+// treePRLine renders a PR as its #number and the values of the selected
+// columns, but the repo, which the tree already shows.
+func treePRLine(cfg *Config, res PRResult, pr PullRequest, cols []column) string {
+	parts := []string{fmt.Sprintf("#%d", pr.Number)}
+	for _, c := range cols {
+		switch c.name {
+		case "repo", "number":
+			continue
+		}
+		v := c.value(cfg, res, pr)
+		if c.maxWidth > 0 {
+			v = truncate(v, c.maxWidth)
+		}
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
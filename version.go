@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Set by the release build with -ldflags "-X main.version=...". Builds
+// without them fall back to the module and VCS info Go embeds.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// releasesURL is the latest-release endpoint of the upstream repo, always
+// on github.com whatever github.api_url says.
+const releasesURL = githubAPI + "/repos/mtintes/pr-view/releases/latest"
+
+// pseudoVersion matches the vX.Y.Z-yyyymmddhhmmss-commit versions Go
+// gives untagged builds.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}(\+.*)?$`)
+
+// updateCheckInterval spaces out the background checks of update_check.
+const updateCheckInterval = 24 * time.Hour
+
+// UpdateState remembers the last background update check.
+type UpdateState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// buildInfo returns the version, commit and build date of this binary.
+func buildInfo() (v, c, d string) {
+	v, c, d = version, commit, date
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return firstNonEmpty(v, "dev"), c, d
+	}
+	// go install pr-view@vX.Y.Z stamps the tag; local builds get a
+	// pseudo-version, which is no release
+	if v == "" && bi.Main.Version != "(devel)" && !pseudoVersion.MatchString(bi.Main.Version) {
+		v = bi.Main.Version
+	}
+	modified := false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			c = firstNonEmpty(c, s.Value)
+		case "vcs.time":
+			d = firstNonEmpty(d, s.Value)
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if modified && commit == "" {
+		c += "-dirty"
+	}
+	return firstNonEmpty(v, "dev"), c, d
+}
+
+func versionString() string {
+	v, c, d := buildInfo()
+	s := "pr-view " + v
+	var details []string
+	if c != "" {
+		details = append(details, "commit "+c)
+	}
+	if d != "" {
+		details = append(details, "built "+d)
+	}
+	details = append(details, runtime.Version()+" "+runtime.GOOS+"/"+runtime.GOARCH)
+	return s + " (" + strings.Join(details, ", ") + ")"
+}
+
+// latestRelease returns the tag of the newest published release.
+func latestRelease() (string, error) {
+	req, err := http.NewRequest("GET", releasesURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "pr-view")
+	// releases are public: no token, and never the cache
+	resp, err := (&http.Client{Transport: baseTransport, Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+	var rel struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return "", err
+	}
+	return rel.TagName, nil
+}
+
+// newerVersion reports whether release is newer than current. Development
+// builds are never considered outdated.
+func newerVersion(release, current string) bool {
+	r, ok1 := parseVersion(release)
+	c, ok2 := parseVersion(current)
+	if !ok1 || !ok2 {
+		return false
+	}
+	for i := range r {
+		if r[i] != c[i] {
+			return r[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses vMAJOR.MINOR.PATCH, ignoring any pre-release or build
+// suffix.
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, _, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+func updateNotice(latest, current string) string {
+	return fmt.Sprintf("pr-view %s is available (you have %s): brew upgrade pr-view, or see https://github.com/mtintes/pr-view/releases", latest, current)
+}
+
+func cmdVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	check := fs.Bool("check", false, "check GitHub releases for a newer version")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	fmt.Println(versionString())
+	if !*check {
+		return 0
+	}
+	latest, err := latestRelease()
+	if err != nil {
+		slog.Error("checking for updates", "err", err)
+		return 1
+	}
+	current, _, _ := buildInfo()
+	switch {
+	case newerVersion(latest, current):
+		fmt.Println(updateNotice(latest, current))
+	case current == "dev" || strings.HasSuffix(current, "-dirty"):
+		fmt.Println("latest release is", latest, "(this is a development build)")
+	default:
+		fmt.Println("up to date, latest release is", latest)
+	}
+	return 0
+}
+
+// backgroundUpdateCheck implements the opt-in update_check setting: at most
+// once a day it looks for a newer release and logs a notice on stderr.
+// Failures are only logged at debug level, they never affect a command.
+func backgroundUpdateCheck(cfg *Config) {
+	states, err := NewStateStore(cfg)
+	if err != nil {
+		return
+	}
+	st, err := states.Load()
+	if err != nil {
+		return
+	}
+	current, _, _ := buildInfo()
+	latest := st.Update.Latest
+	if time.Since(st.Update.CheckedAt) > updateCheckInterval {
+		if latest, err = latestRelease(); err != nil {
+			slog.Debug("checking for updates", "err", err)
+			return
+		}
+		err = states.Update(func(st *State) error {
+			st.Update = UpdateState{CheckedAt: time.Now(), Latest: latest}
+			return nil
+		})
+		if err != nil {
+			slog.Debug("saving update check", "err", err)
+		}
+	}
+	if newerVersion(latest, current) {
+		slog.Info(updateNotice(latest, current))
+	}
+}
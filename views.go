@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// viewFilters are the boolean list filters a view can turn on, by flag name.
+var viewFilters = []string{"ready", "blocked-on-me", "unread", "needs-rebase", "sla-breach", "auto-merge", "external-only", "internal-only", "missing-signoff", "unverified-only", "bad-title", "unfilled-template"}
+
+// args returns the list flags equivalent to the view.
+func (v ViewConfig) args() []string {
+	var args []string
+	for _, f := range v.Filters {
+		args = append(args, "--"+f)
+	}
+	add := func(name, value string) {
+		if value != "" {
+			args = append(args, "--"+name, value)
+		}
+	}
+	add("sort", v.Sort)
+	add("direction", v.Direction)
+	add("columns", strings.Join(v.Columns, ","))
+	add("max-size", v.MaxSize)
+	add("group-by", v.GroupBy)
+	if v.Limit > 0 {
+		add("limit", strconv.Itoa(v.Limit))
+	}
+	if v.MaxTotal > 0 {
+		add("max-total", strconv.Itoa(v.MaxTotal))
+	}
+	if v.ShowBots {
+		args = append(args, "--show-bots")
+	}
+	return args
+}
+
+func (v ViewConfig) validate() error {
+	for _, f := range v.Filters {
+		if !slices.Contains(viewFilters, f) {
+			return fmt.Errorf("unknown filter %q (expected %s)", f, strings.Join(viewFilters, ", "))
+		}
+	}
+	if v.GroupBy != "" && !slices.Contains(groupBys, v.GroupBy) {
+		return fmt.Errorf("unknown group_by %q (expected %s)", v.GroupBy, strings.Join(groupBys, ", "))
+	}
+	return nil
+}
+
+// expandView replaces --view NAME in args with the view's flags. They go
+// first, so flags given next to --view override the view.
+func expandView(cfg *Config, args []string) ([]string, error) {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		name, ok := strings.CutPrefix(strings.TrimPrefix(a, "-"), "-view")
+		if !ok || (name != "" && name[0] != '=') {
+			continue
+		}
+		rest := slices.Clone(args[:i])
+		if name != "" {
+			name = name[1:]
+			rest = append(rest, args[i+1:]...)
+		} else if i+1 < len(args) {
+			name = args[i+1]
+			rest = append(rest, args[i+2:]...)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("--view needs a view name")
+		}
+		view, ok := cfg.Views[name]
+		if !ok {
+			names := make([]string, 0, len(cfg.Views))
+			for n := range cfg.Views {
+				names = append(names, n)
+			}
+			slices.Sort(names)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown view %q, define views in the config under \"views\"", name)
+			}
+			return nil, fmt.Errorf("unknown view %q (have %s)", name, strings.Join(names, ", "))
+		}
+		if err := view.validate(); err != nil {
+			return nil, fmt.Errorf("view %s: %w", name, err)
+		}
+		return append(view.args(), rest...), nil
+	}
+	return args, nil
+}
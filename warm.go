@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// warmMarker is written by a daemon warming the cache after every poll.
+// While it is recent, other commands trust cached responses younger than
+// the interval instead of revalidating each one.
+type warmMarker struct {
+	At       time.Time `json:"at"`
+	Interval Duration  `json:"interval"`
+	PID      int       `json:"pid"`
+}
+
+// warmSlack covers a poll's own duration and a late tick.
+const warmSlack = time.Minute
+
+func warmMarkerPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	// next to the HTTP cache, not in it, where entries are *.json
+	return filepath.Join(filepath.Dir(dir), "warm.json"), nil
+}
+
+// warmFreshness is how old a cached response may be and still be served
+// without asking GitHub: zero unless a warming daemon polled recently.
+func warmFreshness() time.Duration {
+	path, err := warmMarkerPath()
+	if err != nil {
+		return 0
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	var m warmMarker
+	if json.Unmarshal(data, &m) != nil || m.Interval <= 0 {
+		return 0
+	}
+	interval := time.Duration(m.Interval)
+	// a daemon that missed two polls is stopped or stuck
+	if time.Since(m.At) > 2*interval+warmSlack {
+		return 0
+	}
+	return interval + warmSlack
+}
+
+type revalidateKey struct{}
+
+// withRevalidate marks requests that must not be answered from a warm
+// cache, like the daemon's own, which keep it warm.
+func withRevalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func mustRevalidate(ctx context.Context) bool {
+	v, _ := ctx.Value(revalidateKey{}).(bool)
+	return v
+}
+
+// warmCache refreshes what a plain `list` needs, on top of the daemon's
+// own poll, and stamps the marker.
+func warmCache(cfg *Config, gh *GitHubClient, interval time.Duration) {
+	opts, err := parseListFlags(cfg, flag.NewFlagSet("warm", flag.ContinueOnError), nil)
+	if err != nil {
+		slog.Error("warming cache", "err", err)
+		return
+	}
+	start := time.Now()
+	if _, err := collectPRs(cfg, gh, opts); err != nil && err != errNoRepos {
+		slog.Error("warming cache", "err", err)
+		return
+	}
+	path, err := warmMarkerPath()
+	if err != nil {
+		slog.Error("warming cache", "err", err)
+		return
+	}
+	data, err := json.Marshal(warmMarker{At: time.Now(), Interval: Duration(interval), PID: os.Getpid()})
+	if err == nil {
+		err = writeFileAtomic(path, data, 0o600)
+	}
+	if err != nil {
+		slog.Error("writing cache marker", "err", err)
+		return
+	}
+	slog.Debug("cache warmed", "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// stopWarming removes the marker when the daemon stops, so nothing trusts
+// a cache that is no longer kept warm.
+func stopWarming() {
+	if path, err := warmMarkerPath(); err == nil {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultWebhookAddr = ":9000"
+
+// webhookEvents are the deliveries the receiver acts on; GitHub can send
+// others to the same URL, which are acknowledged and ignored. The
+// installation events only reach GitHub Apps.
+var webhookEvents = []string{"pull_request", "pull_request_review", "check_suite", "installation", "installation_repositories"}
+
+// maxWebhookBody is GitHub's cap on delivery payloads.
+const maxWebhookBody = 25 << 20
+
+// webhookDelivery is one verified delivery waiting to be handled.
+type webhookDelivery struct {
+	event string
+	id    string
+	body  []byte
+}
+
+type webhookPayload struct {
+	Action      string       `json:"action"`
+	PullRequest *PullRequest `json:"pull_request"`
+	Repository  struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	RequestedReviewer *User `json:"requested_reviewer"`
+	RequestedTeam     *Team `json:"requested_team"`
+	CheckSuite        *struct {
+		Conclusion   string `json:"conclusion"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"check_suite"`
+}
+
+// verifySignature checks the X-Hub-Signature-256 header GitHub computes
+// over the body with the webhook's secret.
+func verifySignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// cmdWebhook receives GitHub webhook deliveries for the tracked repos and
+// acts on them as they arrive: the daemon's hooks run within seconds and
+// the PRs kept for list --incremental are updated, without polling.
+func cmdWebhook(cfg *Config, hc *http.Client, args []string) int {
+	fs := flag.NewFlagSet("webhook", flag.ContinueOnError)
+	addr := fs.String("addr", firstNonEmpty(cfg.Webhook.Addr, defaultWebhookAddr), "address to listen on")
+	secret := fs.String("secret", firstNonEmpty(cfg.Webhook.Secret, os.Getenv("PR_VIEW_WEBHOOK_SECRET")), "the webhook's secret, to verify deliveries (default: webhook.secret or PR_VIEW_WEBHOOK_SECRET)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Println("usage: pr-view webhook [--addr :9000] [--secret SECRET]")
+		return 2
+	}
+	if *secret == "" {
+		fmt.Println("a secret is required to verify deliveries: pass --secret or set webhook.secret or PR_VIEW_WEBHOOK_SECRET, and use the same one for the webhook on GitHub")
+		return 2
+	}
+	registerSecret(*secret)
+	gh, err := newGitHubClient(cfg, hc)
+	if err != nil {
+		slog.Error("reading token", "err", err)
+		return 1
+	}
+	var me *viewer
+	if hasHook(cfg, eventCIFailed) || hasHook(cfg, eventReviewRequested) {
+		if me, err = gh.viewer(); err != nil {
+			slog.Error("ci_failed and review_requested hooks need your identity", "err", err)
+			return 1
+		}
+	}
+
+	// deliveries are handled one at a time in the background: GitHub gives
+	// up on a delivery after 10s, and hooks can take longer
+	queue := make(chan webhookDelivery, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for d := range queue {
+			for _, u := range deliveryUpdates(cfg, gh, me, d) {
+				if u.event != "" {
+					runHooks(cfg, hookPayload{Event: u.event, Repo: u.repo, PR: u.pr})
+				}
+			}
+		}
+	}()
+	srv, errc := serveWebhooks(*addr, *secret, queue)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	slog.Info("webhook receiver started", "addr", *addr, "pid", os.Getpid(), "hooks", len(cfg.hooks()))
+	select {
+	case err := <-errc:
+		slog.Error("listening for webhooks", "err", err)
+		return 1
+	case sig := <-stop:
+		slog.Info("webhook receiver stopping", "signal", sig.String())
+	}
+	stopWebhooks(srv)
+	close(queue)
+	<-done
+	return 0
+}
+
+// maxDeliveryIDs is how many queued deliveries are remembered to tell
+// redeliveries apart.
+const maxDeliveryIDs = 1000
+
+// deliveryIDs remembers the IDs of the last maxDeliveryIDs queued
+// deliveries, forgetting the oldest first rather than all at once.
+type deliveryIDs struct {
+	mu    sync.Mutex
+	ids   map[string]bool
+	order []string
+}
+
+// claim records id and reports whether it is new. Deliveries without an ID
+// are always new.
+func (d *deliveryIDs) claim(id string) bool {
+	if id == "" {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ids[id] {
+		return false
+	}
+	if len(d.order) >= maxDeliveryIDs {
+		delete(d.ids, d.order[0])
+		d.order = d.order[1:]
+	}
+	d.ids[id] = true
+	d.order = append(d.order, id)
+	return true
+}
+
+// release forgets id again, for a delivery that wasn't queued: GitHub's
+// redelivery of it has to be taken.
+func (d *deliveryIDs) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.ids, id)
+	d.order = slices.DeleteFunc(d.order, func(o string) bool { return o == id })
+}
+
+// serveWebhooks listens on addr and queues the verified deliveries of
+// webhookEvents. The returned channel gets the error the server stopped
+// with, unless stopWebhooks stopped it.
+func serveWebhooks(addr, secret string, queue chan<- webhookDelivery) (*http.Server, <-chan error) {
+	recent := &deliveryIDs{ids: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "reading body", http.StatusBadRequest)
+			return
+		}
+		if !verifySignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+			slog.Warn("rejected delivery with a bad signature", "remote", r.RemoteAddr)
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		event, id := r.Header.Get("X-GitHub-Event"), r.Header.Get("X-GitHub-Delivery")
+		switch {
+		case event == "ping":
+			slog.Info("webhook pinged", "delivery", id)
+			fmt.Fprintln(w, "pong")
+			return
+		case !containsFold(webhookEvents, event):
+			slog.Debug("ignored delivery", "event", event, "delivery", id)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		// redeliveries keep their ID; the first one may have gone through
+		if !recent.claim(id) {
+			slog.Debug("ignored redelivery", "event", event, "delivery", id)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		select {
+		case queue <- webhookDelivery{event: event, id: id, body: body}:
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			// GitHub shows it as failed, to be redelivered
+			recent.release(id)
+			http.Error(w, "too many deliveries queued", http.StatusServiceUnavailable)
+		}
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errc <- err
+		}
+	}()
+	return srv, errc
+}
+
+// stopWebhooks stops taking deliveries, letting those being received finish.
+func stopWebhooks(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("stopping webhook receiver", "err", err)
+	}
+}
+
+// deliveryUpdate is a tracked PR a delivery was about, as it is now, and
+// the hook event it calls for on its own, if any.
+type deliveryUpdate struct {
+	repo  string
+	pr    PullRequest
+	event string
+}
+
+// deliveryUpdates decodes d and returns its tracked PRs, updating the ones
+// kept for list --incremental on the way. Deliveries for anything else are
+// dropped. me is only needed for review_requested and ci_failed events.
+func deliveryUpdates(cfg *Config, gh *GitHubClient, me *viewer, d webhookDelivery) []deliveryUpdate {
+	var p webhookPayload
+	if err := json.Unmarshal(d.body, &p); err != nil {
+		slog.Warn("decoding delivery", "event", d.event, "delivery", d.id, "err", err)
+		return nil
+	}
+	repo := p.Repository.FullName
+	attrs := []any{"event", d.event, "action", p.Action, "repo", repo, "delivery", d.id}
+	if strings.HasPrefix(d.event, "installation") {
+		// the app was installed or uninstalled somewhere, or given other repos
+		slog.Info("installation changed", attrs...)
+		if gh.app != nil {
+			gh.app.forget()
+		}
+		return nil
+	}
+	isTracked, err := trackedPRs(cfg)
+	if err != nil {
+		slog.Error("loading tracked entries", append(attrs, "err", err)...)
+		return nil
+	}
+	tracked := func(number int) bool { return isTracked(repo, number) }
+	switch d.event {
+	case "pull_request", "pull_request_review":
+		if p.PullRequest == nil || !tracked(p.PullRequest.Number) {
+			slog.Debug("ignored delivery for an untracked PR", attrs...)
+			return nil
+		}
+		u := deliveryUpdate{repo: repo, pr: *p.PullRequest}
+		slog.Info("delivery received", append(attrs, "pr", prKey(repo, u.pr.Number))...)
+		if err := mergeSynced(repo, u.pr); err != nil {
+			slog.Warn("updating synced PRs", append(attrs, "err", err)...)
+		}
+		if d.event == "pull_request" {
+			switch p.Action {
+			case "opened", "reopened":
+				u.event = eventNewPR
+			case "review_requested":
+				if me != nil && requestedFromMe(p, repo, me) {
+					u.event = eventReviewRequested
+				}
+			}
+		}
+		return []deliveryUpdate{u}
+	case "check_suite":
+		if p.CheckSuite == nil || p.Action != "completed" {
+			return nil
+		}
+		var updates []deliveryUpdate
+		for _, ref := range p.CheckSuite.PullRequests {
+			if !tracked(ref.Number) {
+				continue
+			}
+			// the suite only lists PR numbers; who opened it takes the PR
+			key := prKey(repo, ref.Number)
+			prs, err := fetchPRs(gh, key, prQuery{})
+			if err != nil || len(prs) == 0 {
+				slog.Warn("fetching PR", append(attrs, "pr", key, "err", err)...)
+				continue
+			}
+			u := deliveryUpdate{repo: repo, pr: prs[0]}
+			slog.Info("delivery received", append(attrs, "pr", key)...)
+			if p.CheckSuite.Conclusion == ciFailure && me != nil && strings.EqualFold(u.pr.User.Login, me.Login) {
+				u.pr.CIState = ciFailure
+				u.event = eventCIFailed
+			}
+			updates = append(updates, u)
+		}
+		return updates
+	}
+	return nil
+}
+
+// requestedFromMe reports whether a review_requested delivery asks you or
+// one of your teams.
+func requestedFromMe(p webhookPayload, repo string, me *viewer) bool {
+	if u := p.RequestedReviewer; u != nil {
+		return strings.EqualFold(u.Login, me.Login)
+	}
+	if t := p.RequestedTeam; t != nil {
+		owner, _, _ := strings.Cut(repo, "/")
+		return containsFold(me.Teams, "@"+owner+"/"+t.Slug)
+	}
+	return false
+}
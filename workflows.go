@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logTailLines is how much of a failed job's log `show --logs` prints.
+const logTailLines = 20
+
+type workflowRun struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	HTMLURL      string    `json:"html_url"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// duration is how long the run took, or has been running.
+func (w workflowRun) duration(now time.Time) time.Duration {
+	if w.RunStartedAt.IsZero() {
+		return 0
+	}
+	if w.Status != "completed" {
+		return now.Sub(w.RunStartedAt)
+	}
+	return w.UpdatedAt.Sub(w.RunStartedAt)
+}
+
+type workflowJob struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	Steps      []struct {
+		Name       string `json:"name"`
+		Conclusion string `json:"conclusion"`
+	} `json:"steps"`
+}
+
+// failedStep is the name of the first step that failed, if any.
+func (j workflowJob) failedStep() string {
+	for _, s := range j.Steps {
+		if s.Conclusion == "failure" || s.Conclusion == "timed_out" {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// fetchWorkflowRuns returns the Actions workflow runs for sha.
+func fetchWorkflowRuns(c *GitHubClient, repo, sha string) ([]workflowRun, error) {
+	var runs struct {
+		WorkflowRuns []workflowRun `json:"workflow_runs"`
+	}
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/actions/runs?head_sha=%s&per_page=100", repo, sha), nil, &runs); err != nil {
+		return nil, err
+	}
+	return runs.WorkflowRuns, nil
+}
+
+// fetchFailedJobs returns the failed jobs of the latest attempt of a run.
+func fetchFailedJobs(c *GitHubClient, repo string, runID int64) ([]workflowJob, error) {
+	var jobs struct {
+		Jobs []workflowJob `json:"jobs"`
+	}
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/actions/runs/%d/jobs?filter=latest&per_page=100", repo, runID), nil, &jobs); err != nil {
+		return nil, err
+	}
+	var failed []workflowJob
+	for _, j := range jobs.Jobs {
+		if checkRunState(checkRun{Status: j.Status, Conclusion: j.Conclusion}) == ciFailure {
+			failed = append(failed, j)
+		}
+	}
+	return failed, nil
+}
+
+// logTimestamp prefixes every line of an Actions log.
+var logTimestamp = regexp.MustCompile(`^\d{4}-\d\d-\d\dT[\d:.]+Z `)
+
+// jobLogTail returns the last n lines of a job's log, without timestamps.
+func jobLogTail(c *GitHubClient, repo string, jobID int64, n int) ([]string, error) {
+	var log []byte
+	if err := c.do("GET", fmt.Sprintf("/repos/%s/actions/jobs/%d/logs", repo, jobID), nil, &log); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(log), "\r\n"), "\n")
+	lines = lines[max(0, len(lines)-n):]
+	for i, l := range lines {
+		lines[i] = logTimestamp.ReplaceAllString(strings.TrimRight(l, "\r"), "")
+	}
+	return lines, nil
+}
+
+// workflowLines is the Workflows section of `show`: every Actions run for
+// the head commit with its result and duration, and for failed runs the
+// failed jobs with the failing step, a link, and with logs the log tail.
+func workflowLines(c *GitHubClient, repo string, runs []workflowRun, logs bool) []string {
+	now := time.Now()
+	width := 0
+	for _, w := range runs {
+		width = max(width, len(w.Name))
+	}
+	var lines []string
+	for _, w := range runs {
+		state := firstNonEmpty(w.Conclusion, strings.ReplaceAll(w.Status, "_", " "))
+		line := fmt.Sprintf("%-*s  %-11s", width, w.Name, state)
+		if d := w.duration(now); d > 0 {
+			line += "  " + fmtDuration(d)
+		}
+		lines = append(lines, line)
+		if checkRunState(checkRun{Status: w.Status, Conclusion: w.Conclusion}) != ciFailure {
+			continue
+		}
+		jobs, err := fetchFailedJobs(c, repo, w.ID)
+		if err != nil {
+			lines = append(lines, "  (error: "+redact(err)+")")
+			continue
+		}
+		if len(jobs) == 0 {
+			lines = append(lines, "  "+w.HTMLURL)
+		}
+		for _, j := range jobs {
+			job := "  " + j.Name
+			if step := j.failedStep(); step != "" {
+				job += fmt.Sprintf(": step %q failed", step)
+			}
+			lines = append(lines, job+"  "+j.HTMLURL)
+			if !logs {
+				continue
+			}
+			tail, err := jobLogTail(c, repo, j.ID, logTailLines)
+			if err != nil {
+				lines = append(lines, "    (error: "+redact(err)+")")
+				continue
+			}
+			for _, l := range tail {
+				lines = append(lines, "    | "+l)
+			}
+		}
+	}
+	return lines
+}